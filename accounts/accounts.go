@@ -0,0 +1,291 @@
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xpzouying/xiaohongshu-mcp/browser"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+)
+
+// DefaultAccount 未指定账号时使用的默认账号，沿用原来的 cookies 路径
+const DefaultAccount = "default"
+
+var (
+	ErrAccountNotFound = errors.New("账号不存在")
+	ErrAccountExists   = errors.New("账号已存在")
+	ErrInvalidName     = errors.New("账号名只能包含字母、数字、下划线和中划线，长度 1-32")
+)
+
+var nameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Account 小红书账号，每个账号有独立的 cookies 存储和浏览器池
+type Account struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	CookiesPath string `json:"cookies_path"` // cookies 的存储位置，由 cookies 后端决定
+	// Browser 账号专属的浏览器指纹和代理，覆盖全局配置中对应的字段
+	Browser *browser.Profile `json:"browser,omitempty"`
+
+	cookier cookies.Cookier
+}
+
+// Redacted 返回隐藏了代理密码的副本，用于接口响应
+func (a Account) Redacted() *Account {
+	a.Browser = a.Browser.Redacted()
+	return &a
+}
+
+// Cookies 返回账号的 cookies 存储
+func (a *Account) Cookies() cookies.Cookier {
+	return a.cookier
+}
+
+// LoginState 账号最近一次检查到的登录状态
+type LoginState struct {
+	LoggedIn  bool      `json:"logged_in"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Info 账号及其登录状态
+type Info struct {
+	Account
+	Login *LoginState `json:"login,omitempty"` // 未检查过时为空
+}
+
+// Registry 账号注册表，账号列表保存在 dir/accounts.json，
+// 每个账号的 cookies 由 cookies 后端保存
+type Registry struct {
+	dir     string
+	backend cookies.Backend
+
+	mu       sync.RWMutex
+	accounts map[string]*Account
+	states   map[string]LoginState
+}
+
+// NewRegistry 加载账号注册表，默认账号总是存在，各账号的 cookies 保存在 backend 中
+func NewRegistry(dir string, backend cookies.Backend) (*Registry, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "创建账号目录失败")
+	}
+
+	r := &Registry{
+		dir:      dir,
+		backend:  backend,
+		accounts: map[string]*Account{},
+		states:   map[string]LoginState{},
+	}
+	r.accounts[DefaultAccount] = r.newAccount(DefaultAccount, "")
+
+	data, err := os.ReadFile(r.indexPath())
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "读取账号列表失败")
+	}
+
+	var saved []*Account
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, errors.Wrap(err, "解析账号列表失败")
+	}
+	for _, a := range saved {
+		if !nameRe.MatchString(a.Name) {
+			continue
+		}
+		// cookies 位置以当前后端为准
+		account := r.newAccount(a.Name, a.DisplayName)
+		account.Browser = a.Browser
+		r.accounts[a.Name] = account
+	}
+	return r, nil
+}
+
+func (r *Registry) newAccount(name, displayName string) *Account {
+	return &Account{
+		Name:        name,
+		DisplayName: displayName,
+		CookiesPath: r.backend.Location(name),
+		cookier:     r.backend.Cookier(name),
+	}
+}
+
+// Get 获取账号，name 为空时返回默认账号
+func (r *Registry) Get(name string) (*Account, error) {
+	if name == "" {
+		name = DefaultAccount
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.accounts[name]
+	if !ok {
+		return nil, errors.Wrap(ErrAccountNotFound, name)
+	}
+	copied := *a
+	return &copied, nil
+}
+
+// List 返回所有账号及登录状态，默认账号在最前，代理密码已隐藏
+func (r *Registry) List() []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]Info, 0, len(r.accounts))
+	for _, a := range r.accounts {
+		info := Info{Account: *a.Redacted()}
+		if state, ok := r.states[a.Name]; ok {
+			info.Login = &state
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Name == DefaultAccount || infos[j].Name == DefaultAccount {
+			return infos[i].Name == DefaultAccount
+		}
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Add 注册新账号，注册后需要扫码登录
+func (r *Registry) Add(name, displayName string) (*Account, error) {
+	if !nameRe.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[name]; ok {
+		return nil, errors.Wrap(ErrAccountExists, name)
+	}
+
+	a := r.newAccount(name, displayName)
+	r.accounts[name] = a
+	if err := r.saveLocked(); err != nil {
+		delete(r.accounts, name)
+		return nil, err
+	}
+
+	copied := *a
+	return &copied, nil
+}
+
+// Remove 删除账号及其 cookies，默认账号不能删除
+func (r *Registry) Remove(name string) error {
+	if name == DefaultAccount {
+		return errors.New("默认账号不能删除")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.accounts[name]
+	if !ok {
+		return errors.Wrap(ErrAccountNotFound, name)
+	}
+
+	delete(r.accounts, name)
+	delete(r.states, name)
+	if err := r.saveLocked(); err != nil {
+		r.accounts[name] = a
+		return err
+	}
+
+	if err := a.cookier.DeleteCookies(); err != nil {
+		return errors.Wrap(err, "删除账号 cookies 失败")
+	}
+	return nil
+}
+
+// SetBrowser 设置账号的浏览器配置，profile 为空时恢复使用全局配置
+func (r *Registry) SetBrowser(name string, profile *browser.Profile) (*Account, error) {
+	if profile != nil {
+		if err := profile.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, ok := r.accounts[name]
+	if !ok {
+		return nil, errors.Wrap(ErrAccountNotFound, name)
+	}
+
+	previous := a.Browser
+	a.Browser = profile
+	if err := r.saveLocked(); err != nil {
+		a.Browser = previous
+		return nil, err
+	}
+
+	copied := *a
+	return &copied, nil
+}
+
+// SetLoginState 记录账号的登录状态
+func (r *Registry) SetLoginState(name string, loggedIn bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.accounts[name]; ok {
+		r.states[name] = LoginState{LoggedIn: loggedIn, CheckedAt: time.Now()}
+	}
+}
+
+// saveLocked 保存账号列表，默认账号只在有浏览器配置时保存
+func (r *Registry) saveLocked() error {
+	saved := make([]*Account, 0, len(r.accounts))
+	for _, a := range r.accounts {
+		if a.Name != DefaultAccount || a.Browser != nil {
+			saved = append(saved, a)
+		}
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Name < saved[j].Name })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "序列化账号列表失败")
+	}
+
+	// 先写临时文件再重命名，避免写入中断损坏账号列表
+	tmp := r.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return errors.Wrap(err, "保存账号列表失败")
+	}
+	return errors.Wrap(os.Rename(tmp, r.indexPath()), "保存账号列表失败")
+}
+
+func (r *Registry) indexPath() string {
+	return filepath.Join(r.dir, "accounts.json")
+}
+
+type contextKey struct{}
+
+// WithAccount 在 ctx 中指定本次操作使用的账号，name 为空时使用默认账号
+func WithAccount(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// FromContext 获取 ctx 中指定的账号，未指定时返回默认账号
+func FromContext(ctx context.Context) string {
+	if name, ok := ctx.Value(contextKey{}).(string); ok && name != "" {
+		return name
+	}
+	return DefaultAccount
+}
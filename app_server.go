@@ -13,6 +13,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/publishers"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/scheduler"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/session"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 )
 
@@ -23,6 +24,7 @@ type AppServer struct {
 	router             *gin.Engine
 	httpServer         *http.Server
 	scheduler          *scheduler.Scheduler
+	sessions           *session.Monitor // 未启用会话监控时为空
 	publishers         map[types.Platform]publishers.Publisher
 }
 
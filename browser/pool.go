@@ -0,0 +1,465 @@
+package browser
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+)
+
+// ErrPoolClosed 浏览器池已关闭
+var ErrPoolClosed = errors.New("浏览器池已关闭")
+
+// PoolConfig 浏览器池配置
+type PoolConfig struct {
+	Size                int           // 常驻浏览器数量，同时也是最大并发租用数
+	MaxUses             int           // 单个浏览器最多租用次数，达到后回收重建，0 表示不限制
+	HealthCheckInterval time.Duration // 空闲浏览器健康检查间隔，0 表示不检查
+	Headless            bool
+	BinPath             string
+	Cookies             cookies.Cookier // 浏览器加载的 cookies，为空时使用默认 cookies 文件
+	Profile             Profile         // 浏览器指纹和代理配置
+}
+
+// PoolStats 浏览器池使用情况
+type PoolStats struct {
+	Size        int     `json:"size"`
+	Idle        int     `json:"idle"`
+	InUse       int     `json:"in_use"`
+	Waiting     int     `json:"waiting"`
+	Utilization float64 `json:"utilization"` // InUse / Size
+	Leases      int64   `json:"leases"`      // 累计租用次数
+	Launched    int64   `json:"launched"`    // 累计启动浏览器次数
+	Recycled    int64   `json:"recycled"`    // 达到使用次数或 cookies 变更后回收的浏览器
+	Crashed     int64   `json:"crashed"`     // 健康检查失败或崩溃的浏览器
+	AvgWaitMs   float64 `json:"avg_wait_ms"` // 平均等待租用时间
+}
+
+// instance 池中的单个浏览器
+type instance interface {
+	NewPage() (*rod.Page, error)
+	Healthy() bool
+	Close()
+}
+
+// pooled 记录浏览器的使用次数和 cookies 版本
+type pooled struct {
+	instance
+	uses       int
+	generation uint64
+}
+
+// Pool 常驻浏览器池，每个浏览器同一时间只出借一个页面
+type Pool struct {
+	cfg    PoolConfig
+	launch func() (instance, error)
+
+	// slots 控制并发租用数，持有 slot 才能取用或创建浏览器，保证浏览器总数不超过 Size
+	slots chan struct{}
+
+	mu         sync.Mutex
+	idle       []*pooled
+	generation uint64
+	closed     bool
+
+	inUse    atomic.Int64
+	waiting  atomic.Int64
+	leases   atomic.Int64
+	launched atomic.Int64
+	recycled atomic.Int64
+	crashed  atomic.Int64
+	waitNs   atomic.Int64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPool 创建浏览器池，浏览器在首次租用时启动，可调用 Warm 预热
+func NewPool(cfg PoolConfig) *Pool {
+	return newPool(cfg, func() (instance, error) {
+		return launchInstance(cfg)
+	})
+}
+
+func newPool(cfg PoolConfig, launch func() (instance, error)) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = 1
+	}
+
+	p := &Pool{
+		cfg:    cfg,
+		launch: launch,
+		slots:  make(chan struct{}, cfg.Size),
+		stop:   make(chan struct{}),
+	}
+
+	if cfg.HealthCheckInterval > 0 {
+		p.wg.Add(1)
+		go p.healthLoop()
+	}
+	return p
+}
+
+// Lease 租用的页面，使用完毕必须调用 Release 归还
+type Lease struct {
+	Page *rod.Page
+
+	// page 是未绑定请求 ctx 的页面，ctx 取消后仍可用它关闭页面
+	page      *rod.Page
+	pool      *Pool
+	browser   *pooled
+	dedicated bool // 独立浏览器，不占用 slot，归还时直接关闭
+	once      sync.Once
+	stop      func() bool
+	broken    bool
+}
+
+// Discard 标记浏览器已损坏，归还时直接销毁
+func (l *Lease) Discard() {
+	l.broken = true
+}
+
+// Release 关闭页面并归还浏览器，可重复调用
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		if l.stop != nil {
+			l.stop()
+		}
+		if l.page != nil {
+			if err := l.page.Close(); err != nil {
+				logrus.Debugf("关闭页面失败: %v", err)
+			}
+		}
+		if l.dedicated {
+			l.browser.Close()
+			return
+		}
+		l.pool.put(l.browser, l.broken)
+	})
+}
+
+// Acquire 租用一个页面，池满时等待直到有浏览器归还或 ctx 取消。
+// 页面绑定 ctx，ctx 取消后页面操作中止并自动归还。
+func (p *Pool) Acquire(ctx context.Context) (*Lease, error) {
+	start := time.Now()
+
+	p.waiting.Add(1)
+	select {
+	case p.slots <- struct{}{}:
+		p.waiting.Add(-1)
+	case <-ctx.Done():
+		p.waiting.Add(-1)
+		return nil, ctx.Err()
+	case <-p.stop:
+		p.waiting.Add(-1)
+		return nil, ErrPoolClosed
+	}
+	p.waitNs.Add(int64(time.Since(start)))
+
+	lease, err := p.lease()
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+
+	p.inUse.Add(1)
+	p.leases.Add(1)
+
+	lease.bind(ctx)
+	return lease, nil
+}
+
+// Dedicated 按池的配置启动一个独立浏览器并打开页面，不占用池的 slot。
+// 用于扫码登录这类长时间等待、但不应阻塞其他操作的场景，Release 时关闭浏览器。
+func (p *Pool) Dedicated(ctx context.Context) (*Lease, error) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+
+	inst, err := p.launch()
+	if err != nil {
+		return nil, err
+	}
+	p.launched.Add(1)
+
+	page, err := inst.NewPage()
+	if err != nil {
+		inst.Close()
+		return nil, errors.Wrap(err, "浏览器打开页面失败")
+	}
+
+	lease := &Lease{Page: page, page: page, pool: p, browser: &pooled{instance: inst}, dedicated: true}
+	lease.bind(ctx)
+	return lease, nil
+}
+
+// KeepOnCancel 取消 ctx 结束时的自动归还：页面操作仍随 ctx 中止，但页面保持打开直到调用 Release。
+// 用于 ctx 超时后还要读取页面的场景（如保存诊断信息），调用方必须保证调用 Release
+func (l *Lease) KeepOnCancel() {
+	if l.stop != nil {
+		l.stop()
+	}
+}
+
+// bind 将页面绑定到 ctx，ctx 取消后页面操作中止并自动归还
+func (l *Lease) bind(ctx context.Context) {
+	if l.Page != nil {
+		l.Page = l.Page.Context(ctx)
+	}
+	l.stop = context.AfterFunc(ctx, l.Release)
+}
+
+// lease 取出空闲浏览器或启动新浏览器并打开页面，调用方需持有 slot
+func (p *Pool) lease() (*Lease, error) {
+	// 已有浏览器打开页面失败时视为崩溃，换一个再试
+	for attempt := 0; attempt < 2; attempt++ {
+		b, err := p.take()
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := b.NewPage()
+		if err != nil {
+			logrus.Warnf("浏览器打开页面失败，重新启动: %v", err)
+			p.crashed.Add(1)
+			b.Close()
+			continue
+		}
+
+		return &Lease{Page: page, page: page, pool: p, browser: b}, nil
+	}
+	return nil, errors.New("浏览器打开页面失败")
+}
+
+// take 取出一个可用的空闲浏览器，没有时启动新浏览器
+func (p *Pool) take() (*pooled, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	generation := p.generation
+
+	var stale []*pooled
+	var b *pooled
+	for len(p.idle) > 0 {
+		last := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if last.generation != generation {
+			stale = append(stale, last)
+			continue
+		}
+		b = last
+		break
+	}
+	p.mu.Unlock()
+
+	for _, s := range stale {
+		p.recycled.Add(1)
+		s.Close()
+	}
+	if b != nil {
+		return b, nil
+	}
+
+	inst, err := p.launch()
+	if err != nil {
+		return nil, err
+	}
+	p.launched.Add(1)
+	return &pooled{instance: inst, generation: generation}, nil
+}
+
+// put 归还浏览器，达到使用次数、cookies 已变更或损坏时销毁
+func (p *Pool) put(b *pooled, broken bool) {
+	defer func() { <-p.slots }()
+	p.inUse.Add(-1)
+
+	b.uses++
+
+	p.mu.Lock()
+	switch {
+	case broken:
+		p.mu.Unlock()
+		p.crashed.Add(1)
+		b.Close()
+	case p.closed:
+		p.mu.Unlock()
+		b.Close()
+	case b.generation != p.generation || (p.cfg.MaxUses > 0 && b.uses >= p.cfg.MaxUses):
+		p.mu.Unlock()
+		p.recycled.Add(1)
+		b.Close()
+	default:
+		p.idle = append(p.idle, b)
+		p.mu.Unlock()
+	}
+}
+
+// Refresh 使所有浏览器失效，登录或删除 cookies 后调用，之后的租用会使用新的 cookies
+func (p *Pool) Refresh() {
+	p.mu.Lock()
+	p.generation++
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, b := range idle {
+		p.recycled.Add(1)
+		b.Close()
+	}
+}
+
+// Warm 预先启动浏览器，直到池中的浏览器数量达到 Size
+func (p *Pool) Warm(ctx context.Context) error {
+	leases := make([]*Lease, 0, p.cfg.Size)
+	defer func() {
+		for _, l := range leases {
+			l.Release()
+		}
+	}()
+
+	for i := 0; i < p.cfg.Size; i++ {
+		l, err := p.Acquire(ctx)
+		if err != nil {
+			return err
+		}
+		leases = append(leases, l)
+	}
+	return nil
+}
+
+// healthLoop 定期检查空闲浏览器，销毁已崩溃的浏览器
+func (p *Pool) healthLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.checkIdle()
+		}
+	}
+}
+
+// checkIdle 检查空闲浏览器，检查期间持有 slot，不与租用冲突
+func (p *Pool) checkIdle() {
+	p.mu.Lock()
+	n := len(p.idle)
+	p.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			// 池已满载，下次再检查
+			return
+		}
+
+		p.mu.Lock()
+		if len(p.idle) == 0 || p.closed {
+			p.mu.Unlock()
+			<-p.slots
+			return
+		}
+		// 从队首取出，检查后放回队尾，避免重复检查同一个浏览器
+		b := p.idle[0]
+		p.idle = p.idle[1:]
+		p.mu.Unlock()
+
+		if b.Healthy() {
+			p.mu.Lock()
+			p.idle = append(p.idle, b)
+			p.mu.Unlock()
+		} else {
+			logrus.Warn("空闲浏览器健康检查失败，已销毁")
+			p.crashed.Add(1)
+			b.Close()
+		}
+		<-p.slots
+	}
+}
+
+// Stats 返回浏览器池使用情况
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	idle := len(p.idle)
+	p.mu.Unlock()
+
+	stats := PoolStats{
+		Size:     p.cfg.Size,
+		Idle:     idle,
+		InUse:    int(p.inUse.Load()),
+		Waiting:  int(p.waiting.Load()),
+		Leases:   p.leases.Load(),
+		Launched: p.launched.Load(),
+		Recycled: p.recycled.Load(),
+		Crashed:  p.crashed.Load(),
+	}
+	stats.Utilization = float64(stats.InUse) / float64(stats.Size)
+	if stats.Leases > 0 {
+		stats.AvgWaitMs = float64(p.waitNs.Load()) / float64(stats.Leases) / float64(time.Millisecond)
+	}
+	return stats
+}
+
+// Close 关闭浏览器池和所有空闲浏览器，租用中的浏览器在归还时关闭
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	close(p.stop)
+	p.wg.Wait()
+
+	for _, b := range idle {
+		b.Close()
+	}
+}
+
+// chromeInstance 池中的 Chrome 浏览器
+type chromeInstance struct {
+	browser *Browser
+}
+
+// launchInstance 按池配置启动浏览器
+func launchInstance(cfg PoolConfig) (instance, error) {
+	b, err := Launch(context.Background(), cfg.Headless,
+		WithBinPath(cfg.BinPath), WithCookies(cfg.Cookies), WithProfile(cfg.Profile))
+	if err != nil {
+		return nil, err
+	}
+	return &chromeInstance{browser: b}, nil
+}
+
+func (c *chromeInstance) NewPage() (*rod.Page, error) {
+	return c.browser.OpenPage()
+}
+
+func (c *chromeInstance) Healthy() bool {
+	_, err := proto.BrowserGetVersion{}.Call(c.browser.Rod().Timeout(5 * time.Second))
+	return err == nil
+}
+
+func (c *chromeInstance) Close() {
+	c.browser.Close()
+}
@@ -0,0 +1,181 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInstance struct {
+	pageErr error
+	healthy atomic.Bool
+	closed  atomic.Bool
+}
+
+func (f *fakeInstance) NewPage() (*rod.Page, error) { return nil, f.pageErr }
+func (f *fakeInstance) Healthy() bool               { return f.healthy.Load() }
+func (f *fakeInstance) Close()                      { f.closed.Store(true) }
+
+func newFakePool(cfg PoolConfig) (*Pool, *[]*fakeInstance) {
+	var instances []*fakeInstance
+	p := newPool(cfg, func() (instance, error) {
+		inst := &fakeInstance{}
+		inst.healthy.Store(true)
+		instances = append(instances, inst)
+		return inst, nil
+	})
+	return p, &instances
+}
+
+func TestPoolReusesBrowsers(t *testing.T) {
+	p, instances := newFakePool(PoolConfig{Size: 2})
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		lease, err := p.Acquire(context.Background())
+		require.NoError(t, err)
+		lease.Release()
+		lease.Release() // 重复归还无副作用
+	}
+
+	stats := p.Stats()
+	assert.Len(t, *instances, 1)
+	assert.Equal(t, int64(3), stats.Leases)
+	assert.Equal(t, 1, stats.Idle)
+	assert.Equal(t, 0, stats.InUse)
+}
+
+func TestPoolRecyclesAfterMaxUses(t *testing.T) {
+	p, instances := newFakePool(PoolConfig{Size: 1, MaxUses: 2})
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		lease, err := p.Acquire(context.Background())
+		require.NoError(t, err)
+		lease.Release()
+	}
+
+	require.Len(t, *instances, 2)
+	assert.True(t, (*instances)[0].closed.Load())
+	assert.Equal(t, int64(1), p.Stats().Recycled)
+}
+
+func TestPoolWaitsForFreeBrowser(t *testing.T) {
+	p, _ := newFakePool(PoolConfig{Size: 1})
+	defer p.Close()
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, p.Stats().Utilization)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = p.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, p.Stats().Waiting)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		lease.Release()
+	}()
+	next, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	next.Release()
+}
+
+func TestPoolReleasesOnContextCancel(t *testing.T) {
+	p, _ := newFakePool(PoolConfig{Size: 1})
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := p.Acquire(ctx)
+	require.NoError(t, err)
+
+	cancel()
+	assert.Eventually(t, func() bool { return p.Stats().InUse == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestLeaseKeepOnCancel(t *testing.T) {
+	p, _ := newFakePool(PoolConfig{Size: 1})
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lease, err := p.Acquire(ctx)
+	require.NoError(t, err)
+	lease.KeepOnCancel()
+
+	// 页面留给调用方在 ctx 结束后继续读取，直到显式归还
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, p.Stats().InUse)
+
+	lease.Release()
+	assert.Equal(t, 0, p.Stats().InUse)
+}
+
+func TestPoolRefreshAndCrash(t *testing.T) {
+	p, instances := newFakePool(PoolConfig{Size: 1})
+	defer p.Close()
+
+	lease, err := p.Acquire(context.Background())
+	require.NoError(t, err)
+	lease.Release()
+
+	// cookies 变更后不再复用旧浏览器
+	p.Refresh()
+	assert.True(t, (*instances)[0].closed.Load())
+
+	lease, err = p.Acquire(context.Background())
+	require.NoError(t, err)
+	lease.Release()
+	require.Len(t, *instances, 2)
+
+	// 打开页面失败视为崩溃，自动换新浏览器
+	(*instances)[1].pageErr = errors.New("target closed")
+	lease, err = p.Acquire(context.Background())
+	require.NoError(t, err)
+	lease.Release()
+	assert.Len(t, *instances, 3)
+	assert.Equal(t, int64(1), p.Stats().Crashed)
+
+	// 健康检查销毁无响应的空闲浏览器
+	(*instances)[2].healthy.Store(false)
+	p.checkIdle()
+	assert.True(t, (*instances)[2].closed.Load())
+	assert.Equal(t, 0, p.Stats().Idle)
+}
+
+func TestPoolClosed(t *testing.T) {
+	p, _ := newFakePool(PoolConfig{Size: 1})
+	p.Close()
+
+	_, err := p.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestPoolDedicatedDoesNotTakeSlot(t *testing.T) {
+	p, instances := newFakePool(PoolConfig{Size: 1})
+	defer p.Close()
+
+	dedicated, err := p.Dedicated(context.Background())
+	require.NoError(t, err)
+
+	// 独立浏览器不影响池的租用
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	lease, err := p.Acquire(ctx)
+	require.NoError(t, err)
+	lease.Release()
+
+	dedicated.Release()
+	require.Len(t, *instances, 2)
+	assert.True(t, (*instances)[0].closed.Load())
+	assert.False(t, (*instances)[1].closed.Load())
+	assert.Equal(t, 1, p.Stats().Idle)
+}
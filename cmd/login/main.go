@@ -4,23 +4,69 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/accounts"
 	"github.com/xpzouying/xiaohongshu-mcp/browser"
 	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/qrterm"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 )
 
 func main() {
 	var (
-		binPath string // 浏览器二进制文件路径
+		binPath     string // 浏览器二进制文件路径
+		accountName string // 登录的账号
+		accountsDir string // 账号目录
+		headless    bool   // 无头模式，二维码打印在终端
+		qrInvert    bool   // 反色打印二维码，适用于浅色背景的终端
+		timeout     time.Duration
 	)
 	flag.StringVar(&binPath, "bin", "", "浏览器二进制文件路径")
+	flag.StringVar(&accountName, "account", accounts.DefaultAccount, "登录的账号，需先添加")
+	flag.StringVar(&accountsDir, "accounts-dir", "data/accounts", "账号目录，与服务的 ACCOUNTS_DIR 一致")
+	flag.BoolVar(&headless, "headless", false, "无头模式运行浏览器，用于没有桌面的服务器（通过 SSH 在终端扫码）")
+	flag.BoolVar(&qrInvert, "qr-invert", false, "反色打印二维码，终端为浅色背景时使用")
+	flag.DurationVar(&timeout, "timeout", 5*time.Minute, "等待扫码登录的最长时间，期间二维码过期会自动刷新")
 	flag.Parse()
 
-	// 登录的时候，需要界面，所以不能无头模式
-	b := browser.NewBrowser(false, browser.WithBinPath(binPath))
+	// 与服务使用相同的 cookies 后端（COOKIES_BACKEND、COOKIES_ENCRYPTION_KEY 等环境变量）
+	backend, err := cookies.NewBackendFromEnv(accountsDir, accounts.DefaultAccount)
+	if err != nil {
+		logrus.Fatalf("failed to create cookies backend: %v", err)
+	}
+	registry, err := accounts.NewRegistry(accountsDir, backend)
+	if err != nil {
+		logrus.Fatalf("failed to load accounts: %v", err)
+	}
+	account, err := registry.Get(accountName)
+	if err != nil {
+		logrus.Fatalf("failed to get account: %v", err)
+	}
+
+	// 与服务使用相同的浏览器指纹和代理，避免登录环境与使用环境不一致
+	profile, err := browser.ProfileFromEnv()
+	if err != nil {
+		logrus.Fatalf("invalid browser config: %v", err)
+	}
+
+	if err := xiaohongshu.LoadSelectors(os.Getenv("SELECTORS_FILE")); err != nil {
+		logrus.Fatalf("invalid selectors file: %v", err)
+	}
+
+	// 二维码同时打印在终端，无桌面的服务器也可以使用无头模式登录
+	b, err := browser.Launch(context.Background(), headless,
+		browser.WithBinPath(binPath),
+		browser.WithCookies(account.Cookies()),
+		browser.WithProfile(profile.Merge(account.Browser)),
+	)
+	if err != nil {
+		logrus.Fatalf("failed to launch browser: %v", err)
+	}
 	defer b.Close()
 
 	page := b.NewPage()
@@ -41,14 +87,36 @@ func main() {
 
 	// 开始登录流程
 	logrus.Info("开始登录流程...")
-	if err = action.Login(context.Background()); err != nil {
-		logrus.Fatalf("登录失败: %v", err)
-	} else {
-		if err := saveCookies(page); err != nil {
-			logrus.Fatalf("failed to save cookies: %v", err)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	img, loggedIn, err := action.FetchQrcodeImage(ctx)
+	if err != nil {
+		logrus.Fatalf("获取登录二维码失败: %v", err)
+	}
+	if !loggedIn {
+		printQrcode(img, qrInvert)
+
+		err := action.WaitForQrcodeLogin(ctx, func(status xiaohongshu.QrcodeStatus, img string) {
+			switch {
+			case img != "":
+				logrus.Info("二维码已过期，已刷新，请扫描新的二维码")
+				printQrcode(img, qrInvert)
+			case status == xiaohongshu.QrcodeScanned:
+				logrus.Info("已扫码，请在手机上确认登录")
+			case status == xiaohongshu.QrcodeExpired:
+				logrus.Info("二维码已过期，正在刷新...")
+			}
+		})
+		if err != nil {
+			logrus.Fatalf("登录失败: %v", err)
 		}
 	}
 
+	if err := saveCookies(page, account.Cookies()); err != nil {
+		logrus.Fatalf("failed to save cookies: %v", err)
+	}
+
 	// 再次检查登录状态确认成功
 	status, err = action.CheckLoginStatus(context.Background())
 	if err != nil {
@@ -63,7 +131,18 @@ func main() {
 
 }
 
-func saveCookies(page *rod.Page) error {
+// printQrcode 在终端打印二维码，无法渲染时提示在浏览器窗口中扫码
+func printQrcode(img string, invert bool) {
+	qr, err := qrterm.RenderDataURI(img, invert)
+	if err != nil {
+		logrus.Warnf("无法在终端显示二维码，请在浏览器窗口中扫码: %v", err)
+		return
+	}
+	fmt.Println(qr)
+	fmt.Println("请用小红书 App 扫描上方二维码登录（无法识别时可加 -qr-invert 参数重试）")
+}
+
+func saveCookies(page *rod.Page, cookieLoader cookies.Cookier) error {
 	cks, err := page.Browser().GetCookies()
 	if err != nil {
 		return err
@@ -74,6 +153,5 @@ func saveCookies(page *rod.Page) error {
 		return err
 	}
 
-	cookieLoader := cookies.NewLoadCookie(cookies.GetCookiesFilePath())
 	return cookieLoader.SaveCookies(data)
 }
@@ -0,0 +1,130 @@
+// secretstore 本地密钥存储服务，实现 cookies.SecretStoreBackend 使用的 HTTP 接口，
+// 用于开发和单机部署时替代外部密钥存储（如 Vault）。密钥以 0600 权限保存在本地目录。
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxSecretSize 单个密钥的最大长度
+const maxSecretSize = 1 << 20
+
+func main() {
+	var (
+		addr string // 监听地址
+		dir  string // 密钥保存目录
+	)
+	flag.StringVar(&addr, "addr", "127.0.0.1:18061", "监听地址，默认只监听本机")
+	flag.StringVar(&dir, "dir", "data/secrets", "密钥保存目录")
+	flag.Parse()
+
+	token := os.Getenv("SECRET_STORE_TOKEN")
+	if token == "" {
+		logrus.Warn("SECRET_STORE_TOKEN 未设置，任何能访问该地址的进程都可以读取密钥")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		logrus.Fatalf("创建密钥目录失败: %v", err)
+	}
+
+	store := &fileStore{dir: dir, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/secrets/{name}", store.auth(store.get))
+	mux.HandleFunc("PUT /v1/secrets/{name}", store.auth(store.put))
+	mux.HandleFunc("DELETE /v1/secrets/{name}", store.auth(store.delete))
+
+	logrus.Infof("密钥存储服务启动: http://%s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logrus.Fatalf("密钥存储服务退出: %v", err)
+	}
+}
+
+// fileStore 每个密钥保存为一个文件
+type fileStore struct {
+	dir   string
+	token string
+}
+
+// auth 校验 Bearer token
+func (s *fileStore) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			got := []byte(r.Header.Get("Authorization"))
+			want := []byte("Bearer " + s.token)
+			if subtle.ConstantTimeCompare(got, want) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// path 密钥文件路径，名称编码后作为文件名，避免路径穿越
+func (s *fileStore) path(r *http.Request) string {
+	name := base64.RawURLEncoding.EncodeToString([]byte(r.PathValue("name")))
+	return filepath.Join(s.dir, name)
+}
+
+func (s *fileStore) get(w http.ResponseWriter, r *http.Request) {
+	data, err := os.ReadFile(s.path(r))
+	if os.IsNotExist(err) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("读取密钥失败: %v", err)
+		http.Error(w, "read failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
+func (s *fileStore) put(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSecretSize))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+
+	// 先写临时文件再重命名
+	path := s.path(r)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		logrus.Errorf("保存密钥失败: %v", err)
+		http.Error(w, "write failed", http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		logrus.Errorf("保存密钥失败: %v", err)
+		http.Error(w, "write failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *fileStore) delete(w http.ResponseWriter, r *http.Request) {
+	err := os.Remove(s.path(r))
+	if os.IsNotExist(err) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logrus.Errorf("删除密钥失败: %v", err)
+		http.Error(w, "delete failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
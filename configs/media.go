@@ -0,0 +1,15 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const (
+	MediaDir = "xiaohongshu_media"
+)
+
+// GetMediaPath 返回媒体下载缓存目录
+func GetMediaPath() string {
+	return filepath.Join(os.TempDir(), MediaDir)
+}
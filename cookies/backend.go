@@ -0,0 +1,166 @@
+package cookies
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Backend cookies 存储后端，为每个账号提供独立的 Cookier
+type Backend interface {
+	// Cookier 返回账号的 cookies 存储
+	Cookier(account string) Cookier
+	// Location 返回账号 cookies 的存储位置，用于展示
+	Location(account string) string
+}
+
+// DirBackend 每个账号一个目录的文件后端：<root>/<account>/cookies.json，设置密钥时加密保存
+type DirBackend struct {
+	root  string
+	key   []byte
+	paths map[string]string
+}
+
+// NewDirBackend 创建目录后端，key 为空时明文保存；paths 指定部分账号使用的文件路径，如默认账号沿用旧的 cookies 路径
+func NewDirBackend(root string, key []byte, paths map[string]string) (*DirBackend, error) {
+	if key != nil && len(key) != KeySize {
+		return nil, errors.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return &DirBackend{root: root, key: key, paths: paths}, nil
+}
+
+// Cookier 返回账号的文件存储
+func (b *DirBackend) Cookier(account string) Cookier {
+	path := b.Location(account)
+	if b.key == nil {
+		return NewLoadCookie(path)
+	}
+
+	c, err := NewEncryptedCookie(path, b.key)
+	if err != nil {
+		// 密钥长度已在 NewDirBackend 中校验
+		panic(err)
+	}
+	return c
+}
+
+// Location 返回账号的 cookies 文件路径
+func (b *DirBackend) Location(account string) string {
+	if path, ok := b.paths[account]; ok {
+		return path
+	}
+	return filepath.Join(b.root, account, "cookies.json")
+}
+
+// Migrate 把 src 中的 cookies 迁移到 dst 并删除 src，src 不存在时返回 false
+func Migrate(src, dst Cookier) (bool, error) {
+	data, err := src.LoadCookies()
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "failed to load cookies to migrate")
+	}
+
+	if err := dst.SaveCookies(data); err != nil {
+		return false, errors.Wrap(err, "failed to save migrated cookies")
+	}
+	if err := src.DeleteCookies(); err != nil {
+		return true, errors.Wrap(err, "cookies migrated but failed to delete the old file")
+	}
+	return true, nil
+}
+
+// NewBackendFromEnv 根据环境变量创建 cookies 后端，并迁移旧的明文 cookies：
+//   - COOKIES_BACKEND=file（默认）：每个账号保存在 root/<account>/cookies.json，
+//     设置 COOKIES_ENCRYPTION_KEY 或 COOKIES_KEY_FILE 时加密保存
+//   - COOKIES_BACKEND=secret：保存在 SECRET_STORE_URL 指定的密钥存储服务中，使用 SECRET_STORE_TOKEN 认证，
+//     所有账号的本地 cookies 文件会迁移过去，设置的密钥只用于读取本地的加密文件
+//
+// 默认账号 defaultAccount 沿用原来的 cookies 路径。
+func NewBackendFromEnv(root, defaultAccount string) (Backend, error) {
+	key, err := LoadKey()
+	if err != nil {
+		return nil, err
+	}
+
+	switch backend := os.Getenv("COOKIES_BACKEND"); backend {
+	case "", "file":
+		if key == nil {
+			logrus.Warn("cookies are stored in plaintext, set COOKIES_ENCRYPTION_KEY or COOKIES_KEY_FILE to encrypt them")
+			return NewDirBackend(root, nil, map[string]string{defaultAccount: GetCookiesFilePath()})
+		}
+
+		// 加密保存时不再使用所有用户可读的 /tmp/cookies.json，将其迁移到新路径
+		b, err := NewDirBackend(root, key, map[string]string{defaultAccount: defaultCookiesFilePath()})
+		if err != nil {
+			return nil, err
+		}
+		migrateLegacy(NewLoadCookie(legacyCookiesFilePath()), b.Cookier(defaultAccount), b.Location(defaultAccount))
+		return b, nil
+
+	case "secret":
+		url := os.Getenv("SECRET_STORE_URL")
+		if url == "" {
+			return nil, errors.New("SECRET_STORE_URL is required for the secret cookies backend")
+		}
+		if key != nil {
+			logrus.Warn("COOKIES_ENCRYPTION_KEY/COOKIES_KEY_FILE is not used to store cookies with COOKIES_BACKEND=secret, " +
+				"it is only used to read local cookies files during migration")
+		}
+		b := NewSecretStoreBackend(url, os.Getenv("SECRET_STORE_TOKEN"), nil)
+
+		// 迁移所有账号留在本地的 cookies 文件
+		local, err := NewDirBackend(root, key, map[string]string{defaultAccount: GetCookiesFilePath()})
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range localAccounts(root, defaultAccount) {
+			migrateLegacy(local.Cookier(account), b.Cookier(account), b.Location(account))
+		}
+		return b, nil
+
+	default:
+		return nil, errors.Errorf("unsupported COOKIES_BACKEND: %s", backend)
+	}
+}
+
+// migrateLegacy 迁移旧的明文 cookies，失败只记录日志
+func migrateLegacy(src, dst Cookier, location string) {
+	migrated, err := Migrate(src, dst)
+	if err != nil {
+		logrus.Warnf("failed to migrate plaintext cookies to %s: %v", location, err)
+		return
+	}
+	if migrated {
+		logrus.Infof("migrated plaintext cookies to %s", location)
+	}
+}
+
+// localAccounts 返回在 root 下有 cookies 文件的账号，默认账号总是在内
+func localAccounts(root, defaultAccount string) []string {
+	accounts := []string{defaultAccount}
+
+	paths, _ := filepath.Glob(filepath.Join(root, "*", "cookies.json"))
+	for _, path := range paths {
+		if account := filepath.Base(filepath.Dir(path)); account != defaultAccount {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
+
+// legacyCookiesFilePath 旧版本使用的 cookies 路径 /tmp/cookies.json
+func legacyCookiesFilePath() string {
+	return filepath.Join(os.TempDir(), "cookies.json")
+}
+
+// defaultCookiesFilePath 不考虑旧路径时的 cookies 路径
+func defaultCookiesFilePath() string {
+	if path := os.Getenv("COOKIES_PATH"); path != "" {
+		return path
+	}
+	return "cookies.json"
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 )
 
 type Cookier interface {
@@ -27,7 +28,7 @@ func NewLoadCookie(path string) Cookier {
 	}
 }
 
-// LoadCookies 从文件中加载 cookies。
+// LoadCookies 从文件中加载 cookies，旧版本写入的文件其他用户可读，读取时收紧为 0600。
 func (c *localCookie) LoadCookies() ([]byte, error) {
 
 	data, err := os.ReadFile(c.path)
@@ -35,12 +36,13 @@ func (c *localCookie) LoadCookies() ([]byte, error) {
 		return nil, errors.Wrap(err, "failed to read cookies from tmp file")
 	}
 
+	restrictPermissions(c.path)
 	return data, nil
 }
 
-// SaveCookies 保存 cookies 到文件中。
+// SaveCookies 保存 cookies 到文件中，文件仅当前用户可读写。
 func (c *localCookie) SaveCookies(data []byte) error {
-	return os.WriteFile(c.path, data, 0644)
+	return writeFileAtomic(c.path, data)
 }
 
 // DeleteCookies 删除 cookies 文件。
@@ -56,21 +58,57 @@ func (c *localCookie) DeleteCookies() error {
 // 为了向后兼容，如果旧路径 /tmp/cookies.json 存在，则继续使用；
 // 否则使用当前目录下的 cookies.json
 func GetCookiesFilePath() string {
-	// 旧路径：/tmp/cookies.json
-	tmpDir := os.TempDir()
-	oldPath := filepath.Join(tmpDir, "cookies.json")
-
-	// 检查旧路径文件是否存在
-	if _, err := os.Stat(oldPath); err == nil {
+	// 检查旧路径 /tmp/cookies.json 是否存在
+	if oldPath := legacyCookiesFilePath(); fileExists(oldPath) {
 		// 文件存在，使用旧路径（向后兼容）
 		return oldPath
 	}
 
-	path := os.Getenv("COOKIES_PATH") // 判断环境变量
-	if path == "" {
-		path = "cookies.json" // fallback，本地调试时用当前目录
+	// 文件不存在，使用新路径（环境变量或当前目录）
+	return defaultCookiesFilePath()
+}
+
+// restrictPermissions 把其他用户可访问的 cookies 文件改为 0600，失败只记录日志
+func restrictPermissions(path string) {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm()&0o077 == 0 {
+		return
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		logrus.Warnf("cookies file %s is accessible by other users and chmod failed: %v", path, err)
+		return
+	}
+	logrus.Infof("restricted permissions of cookies file %s to 0600", path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，文件权限 0600，避免写入中断损坏 cookies 或被其他用户读取
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.Wrap(err, "failed to create cookies dir")
+	}
+
+	tmp, err := os.CreateTemp(dir, ".cookies-*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create cookies temp file")
 	}
+	defer os.Remove(tmp.Name())
 
-	// 文件不存在，使用新路径（当前目录）
-	return path
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "failed to write cookies")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "failed to write cookies")
+	}
+	// CreateTemp 创建的文件已是 0600，这里显式设置以防 umask 差异
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return errors.Wrap(err, "failed to chmod cookies")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "failed to save cookies")
 }
@@ -0,0 +1,186 @@
+package cookies
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCookies = `[{"name":"web_session","value":"secret"}]`
+
+func newKey(t *testing.T) []byte {
+	key := make([]byte, KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestEncryptedCookie(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	key := newKey(t)
+
+	c, err := NewEncryptedCookie(path, key)
+	require.NoError(t, err)
+	require.NoError(t, c.SaveCookies([]byte(testCookies)))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "web_session")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	data, err := c.LoadCookies()
+	require.NoError(t, err)
+	assert.Equal(t, testCookies, string(data))
+
+	other, err := NewEncryptedCookie(path, newKey(t))
+	require.NoError(t, err)
+	_, err = other.LoadCookies()
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestEncryptedCookieMigratesPlaintext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	require.NoError(t, os.WriteFile(path, []byte(testCookies), 0o644))
+
+	c, err := NewEncryptedCookie(path, newKey(t))
+	require.NoError(t, err)
+
+	data, err := c.LoadCookies()
+	require.NoError(t, err)
+	assert.Equal(t, testCookies, string(data))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(raw), string(encryptedMagic)))
+
+	data, err = c.LoadCookies()
+	require.NoError(t, err)
+	assert.Equal(t, testCookies, string(data))
+}
+
+func TestParseKey(t *testing.T) {
+	_, err := ParseKey("too-short")
+	assert.Error(t, err)
+
+	key, err := ParseKey(strings.Repeat("ab", KeySize))
+	require.NoError(t, err)
+	assert.Len(t, key, KeySize)
+}
+
+func TestSecretStoreBackend(t *testing.T) {
+	srv, _ := newSecretServer(t)
+
+	backend := NewSecretStoreBackend(srv.URL, "token", nil)
+	c := backend.Cookier("brand_a")
+
+	_, err := c.LoadCookies()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// 从明文文件迁移
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	require.NoError(t, os.WriteFile(path, []byte(testCookies), 0o644))
+	migrated, err := Migrate(NewLoadCookie(path), c)
+	require.NoError(t, err)
+	assert.True(t, migrated)
+	assert.NoFileExists(t, path)
+
+	data, err := c.LoadCookies()
+	require.NoError(t, err)
+	assert.Equal(t, testCookies, string(data))
+
+	require.NoError(t, c.DeleteCookies())
+	_, err = c.LoadCookies()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewSecretStoreBackend(srv.URL, "wrong", nil).Cookier("brand_a").LoadCookies()
+	assert.Error(t, err)
+}
+
+func TestSecretBackendFromEnvMigratesAllAccounts(t *testing.T) {
+	srv, secrets := newSecretServer(t)
+	root := t.TempDir()
+	key := newKey(t)
+
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("COOKIES_PATH", filepath.Join(root, "cookies.json"))
+	t.Setenv("COOKIES_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(key))
+	t.Setenv("COOKIES_BACKEND", "secret")
+	t.Setenv("SECRET_STORE_URL", srv.URL)
+	t.Setenv("SECRET_STORE_TOKEN", "token")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "cookies.json"), []byte(testCookies), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "brand_a"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "brand_a", "cookies.json"), []byte(testCookies), 0o600))
+	// 之前加密保存的账号用配置的密钥读取
+	encrypted, err := NewEncryptedCookie(filepath.Join(root, "brand_b", "cookies.json"), key)
+	require.NoError(t, err)
+	require.NoError(t, encrypted.SaveCookies([]byte(testCookies)))
+
+	_, err = NewBackendFromEnv(root, "default")
+	require.NoError(t, err)
+
+	for _, account := range []string{"default", "brand_a", "brand_b"} {
+		assert.Equal(t, testCookies, string(secrets[secretNamePrefix+account]), account)
+	}
+	assert.NoFileExists(t, filepath.Join(root, "brand_a", "cookies.json"))
+	assert.NoFileExists(t, filepath.Join(root, "brand_b", "cookies.json"))
+}
+
+func TestLocalCookieRestrictsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	require.NoError(t, os.WriteFile(path, []byte(testCookies), 0o644))
+	require.NoError(t, os.Chmod(path, 0o644))
+
+	_, err := NewLoadCookie(path).LoadCookies()
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+// newSecretServer 模拟密钥存储服务，需要 Bearer token 认证
+func newSecretServer(t *testing.T) (*httptest.Server, map[string][]byte) {
+	var mu sync.Mutex
+	secrets := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+
+		name := strings.TrimPrefix(r.URL.Path, "/v1/secrets/")
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := secrets[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(data)
+		case http.MethodPut:
+			secrets[name], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			delete(secrets, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, secrets
+}
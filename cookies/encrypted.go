@@ -0,0 +1,143 @@
+package cookies
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// encryptedMagic 加密 cookies 文件的头部标识，同时作为 AES-GCM 的附加数据
+var encryptedMagic = []byte("XHSC1")
+
+// KeySize 加密密钥长度（AES-256）
+const KeySize = 32
+
+// ErrDecrypt 密钥错误或文件被篡改
+var ErrDecrypt = errors.New("failed to decrypt cookies: wrong key or corrupted file")
+
+type encryptedCookie struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedCookie 使用 AES-256-GCM 加密保存 cookies 的文件后端。
+// 读取到旧版本的明文 cookies 时会自动加密后写回。
+func NewEncryptedCookie(path string, key []byte) (Cookier, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	if len(key) != KeySize {
+		return nil, errors.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create gcm")
+	}
+
+	return &encryptedCookie{path: path, aead: aead}, nil
+}
+
+// LoadCookies 读取并解密 cookies，明文文件会被迁移为加密文件。
+func (c *encryptedCookie) LoadCookies() ([]byte, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cookies file")
+	}
+
+	if !bytes.HasPrefix(data, encryptedMagic) {
+		if !json.Valid(data) {
+			return nil, ErrDecrypt
+		}
+		// 旧版本的明文 cookies，加密后写回
+		if err := c.SaveCookies(data); err != nil {
+			logrus.Warnf("failed to migrate plaintext cookies %s: %v", c.path, err)
+		} else {
+			logrus.Infof("migrated plaintext cookies to encrypted file: %s", c.path)
+		}
+		return data, nil
+	}
+
+	body := data[len(encryptedMagic):]
+	nonceSize := c.aead.NonceSize()
+	if len(body) < nonceSize {
+		return nil, ErrDecrypt
+	}
+
+	plain, err := c.aead.Open(nil, body[:nonceSize], body[nonceSize:], encryptedMagic)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plain, nil
+}
+
+// SaveCookies 加密保存 cookies，文件格式为 magic + nonce + 密文。
+func (c *encryptedCookie) SaveCookies(data []byte) error {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return errors.Wrap(err, "failed to generate nonce")
+	}
+
+	out := make([]byte, 0, len(encryptedMagic)+len(nonce)+len(data)+c.aead.Overhead())
+	out = append(out, encryptedMagic...)
+	out = append(out, nonce...)
+	out = c.aead.Seal(out, nonce, data, encryptedMagic)
+
+	return writeFileAtomic(c.path, out)
+}
+
+// DeleteCookies 删除 cookies 文件。
+func (c *encryptedCookie) DeleteCookies() error {
+	return (&localCookie{path: c.path}).DeleteCookies()
+}
+
+// ParseKey 解析密钥，支持 base64 或 hex 编码的 32 字节密钥
+func ParseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, errors.Errorf("encryption key must be %d bytes encoded as base64 or hex", KeySize)
+}
+
+// LoadKey 从环境变量 COOKIES_ENCRYPTION_KEY 或 COOKIES_KEY_FILE 指定的文件读取密钥，都未设置时返回 nil
+func LoadKey() ([]byte, error) {
+	if s := os.Getenv("COOKIES_ENCRYPTION_KEY"); s != "" {
+		return ParseKey(s)
+	}
+
+	path := os.Getenv("COOKIES_KEY_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read key file")
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		logrus.Warnf("cookies key file %s is accessible by other users, consider chmod 600", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read key file")
+	}
+	return ParseKey(string(data))
+}
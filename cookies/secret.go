@@ -0,0 +1,135 @@
+package cookies
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// secretNamePrefix 密钥存储中 cookies 的名称前缀，完整名称为 <prefix><account>
+const secretNamePrefix = "xiaohongshu-mcp.cookies."
+
+// SecretStoreBackend 外部密钥存储后端，通过 HTTP 读写密钥：
+//
+//	GET    <baseURL>/v1/secrets/<name>  读取，不存在时返回 404
+//	PUT    <baseURL>/v1/secrets/<name>  写入，请求体为密钥内容
+//	DELETE <baseURL>/v1/secrets/<name>  删除
+//
+// 请求带 Authorization: Bearer <token>。本地可使用 cmd/secretstore 作为替代服务。
+type SecretStoreBackend struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewSecretStoreBackend 创建密钥存储后端，client 为空时使用 10 秒超时的默认客户端
+func NewSecretStoreBackend(baseURL, token string, client *http.Client) *SecretStoreBackend {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &SecretStoreBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+// Cookier 返回账号在密钥存储中的 cookies
+func (b *SecretStoreBackend) Cookier(account string) Cookier {
+	return &secretCookie{backend: b, name: secretNamePrefix + account}
+}
+
+// Location 返回账号 cookies 在密钥存储中的地址
+func (b *SecretStoreBackend) Location(account string) string {
+	return b.secretURL(secretNamePrefix + account)
+}
+
+func (b *SecretStoreBackend) secretURL(name string) string {
+	return b.baseURL + "/v1/secrets/" + url.PathEscape(name)
+}
+
+// do 发送请求，返回状态码和响应体
+func (b *SecretStoreBackend) do(method, name string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, b.secretURL(name), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to create secret store request")
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "secret store request failed")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, errors.Wrap(err, "failed to read secret store response")
+	}
+	return resp.StatusCode, data, nil
+}
+
+type secretCookie struct {
+	backend *SecretStoreBackend
+	name    string
+}
+
+// LoadCookies 从密钥存储读取 cookies，不存在时返回的错误满足 errors.Is(err, os.ErrNotExist)
+func (c *secretCookie) LoadCookies() ([]byte, error) {
+	status, data, err := c.backend.do(http.MethodGet, c.name, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return data, nil
+	case http.StatusNotFound:
+		return nil, errors.Wrapf(os.ErrNotExist, "secret %s", c.name)
+	default:
+		return nil, statusError("load", status, data)
+	}
+}
+
+// SaveCookies 写入 cookies 到密钥存储
+func (c *secretCookie) SaveCookies(data []byte) error {
+	status, body, err := c.backend.do(http.MethodPut, c.name, data)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusCreated && status != http.StatusNoContent {
+		return statusError("save", status, body)
+	}
+	return nil
+}
+
+// DeleteCookies 从密钥存储删除 cookies，不存在时视为已删除
+func (c *secretCookie) DeleteCookies() error {
+	status, body, err := c.backend.do(http.MethodDelete, c.name, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNoContent && status != http.StatusNotFound {
+		return statusError("delete", status, body)
+	}
+	return nil
+}
+
+func statusError(op string, status int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 200 {
+		msg = msg[:200]
+	}
+	return fmt.Errorf("failed to %s cookies in secret store: HTTP %d %s", op, status, msg)
+}
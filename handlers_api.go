@@ -1,9 +1,16 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 
-	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/accounts"
+	"github.com/xpzouying/xiaohongshu-mcp/browser"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/diagnostics"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/session"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 
 	"github.com/gin-gonic/gin"
@@ -12,18 +19,39 @@ import (
 
 // respondError 返回错误响应
 func respondError(c *gin.Context, statusCode int, code, message string, details any) {
-	response := ErrorResponse{
+	writeError(c, statusCode, ErrorResponse{
 		Error:   message,
 		Code:    code,
 		Details: details,
-	}
+	})
+}
+
+// respondActionError 返回浏览器操作失败的响应，附带失败的步骤和诊断记录 ID
+func respondActionError(c *gin.Context, statusCode int, code, message string, err error) {
+	writeError(c, statusCode, ErrorResponse{
+		Error:         message,
+		Code:          code,
+		Details:       errorDetails(err),
+		DiagnosticsID: diagnostics.ReportID(err),
+	})
+}
 
+func writeError(c *gin.Context, statusCode int, response ErrorResponse) {
 	logrus.Errorf("%s %s %s %d", c.Request.Method, c.Request.URL.Path,
 		c.GetString("account"), statusCode)
 
 	c.JSON(statusCode, response)
 }
 
+// errorDetails 浏览器操作失败时返回失败的步骤、页面、选择器和已完成的步骤，其他错误返回错误信息
+func errorDetails(err error) any {
+	var actionErr *xiaohongshu.ActionError
+	if errors.As(err, &actionErr) {
+		return actionErr
+	}
+	return err.Error()
+}
+
 // respondSuccess 返回成功响应
 func respondSuccess(c *gin.Context, data any, message string) {
 	response := SuccessResponse{
@@ -42,8 +70,8 @@ func respondSuccess(c *gin.Context, data any, message string) {
 func (s *AppServer) checkLoginStatusHandler(c *gin.Context) {
 	status, err := s.xiaohongshuService.CheckLoginStatus(c.Request.Context())
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "STATUS_CHECK_FAILED",
-			"检查登录状态失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "STATUS_CHECK_FAILED",
+			"检查登录状态失败", err)
 		return
 	}
 
@@ -56,24 +84,35 @@ func (s *AppServer) checkLoginStatusHandler(c *gin.Context) {
 func (s *AppServer) getLoginQrcodeHandler(c *gin.Context) {
 	result, err := s.xiaohongshuService.GetLoginQrcode(c.Request.Context())
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "STATUS_CHECK_FAILED",
-			"获取登录二维码失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "STATUS_CHECK_FAILED",
+			"获取登录二维码失败", err)
 		return
 	}
 
 	respondSuccess(c, result, "获取登录二维码成功")
 }
 
+// getLoginSessionHandler 查询扫码登录会话的状态，二维码过期刷新后返回新的二维码
+func (s *AppServer) getLoginSessionHandler(c *gin.Context) {
+	session, err := s.xiaohongshuService.GetLoginSession(c.Param("id"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "LOGIN_SESSION_NOT_FOUND",
+			"登录会话不存在", err.Error())
+		return
+	}
+
+	respondSuccess(c, session, "获取登录会话成功")
+}
+
 // deleteCookiesHandler 删除 cookies，重置登录状态
 func (s *AppServer) deleteCookiesHandler(c *gin.Context) {
-	err := s.xiaohongshuService.DeleteCookies(c.Request.Context())
+	cookiePath, err := s.xiaohongshuService.DeleteCookies(c.Request.Context())
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "DELETE_COOKIES_FAILED",
-			"删除 cookies 失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "DELETE_COOKIES_FAILED",
+			"删除 cookies 失败", err)
 		return
 	}
 
-	cookiePath := cookies.GetCookiesFilePath()
 	respondSuccess(c, map[string]interface{}{
 		"cookie_path": cookiePath,
 		"message":     "Cookies 已成功删除，登录状态已重置。下次操作时需要重新登录。",
@@ -92,8 +131,8 @@ func (s *AppServer) publishHandler(c *gin.Context) {
 	// 执行发布
 	result, err := s.xiaohongshuService.PublishContent(c.Request.Context(), &req)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "PUBLISH_FAILED",
-			"发布失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "PUBLISH_FAILED",
+			"发布失败", err)
 		return
 	}
 
@@ -112,21 +151,83 @@ func (s *AppServer) publishVideoHandler(c *gin.Context) {
 	// 执行视频发布
 	result, err := s.xiaohongshuService.PublishVideo(c.Request.Context(), &req)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "PUBLISH_VIDEO_FAILED",
-			"视频发布失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "PUBLISH_VIDEO_FAILED",
+			"视频发布失败", err)
 		return
 	}
 
 	respondSuccess(c, result, "视频发布成功")
 }
 
+// uploadMediaHandler 上传图片或视频，返回可在发布接口中使用的媒体句柄 (media://...)
+func (s *AppServer) uploadMediaHandler(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST",
+			"请求参数错误", err.Error())
+		return
+	}
+
+	files := form.File["file"]
+	if len(files) == 0 {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST",
+			"请求参数错误", "缺少 file 字段")
+		return
+	}
+
+	uploads, err := media.DefaultUploads()
+	if err != nil {
+		respondActionError(c, http.StatusInternalServerError, "UPLOAD_FAILED",
+			"上传失败", err)
+		return
+	}
+
+	results := make([]*media.Upload, 0, len(files))
+	for _, fh := range files {
+		upload, err := saveUploadedFile(uploads, fh)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "UPLOAD_FAILED",
+				"上传失败", fmt.Sprintf("%s: %v", fh.Filename, err))
+			return
+		}
+		results = append(results, upload)
+	}
+
+	respondSuccess(c, map[string]any{"media": results}, "上传成功")
+}
+
+// saveUploadedFile 保存单个上传文件，只接受图片和视频
+// 上传时按视频的大小上限接收，识别出是图片后再按图片的上限校验
+func saveUploadedFile(uploads *media.Uploads, fh *multipart.FileHeader) (*media.Upload, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	upload, err := uploads.Save(f, media.MaxVideoSize)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case upload.IsImage():
+		if upload.Size > media.MaxImageSize {
+			return nil, &media.ErrTooLarge{URL: fh.Filename, Limit: media.MaxImageSize}
+		}
+	case upload.IsVideo():
+	default:
+		return nil, fmt.Errorf("不支持的文件类型: %s", upload.ContentType)
+	}
+	return upload, nil
+}
+
 // listFeedsHandler 获取Feeds列表
 func (s *AppServer) listFeedsHandler(c *gin.Context) {
 	// 获取 Feeds 列表
 	result, err := s.xiaohongshuService.ListFeeds(c.Request.Context())
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "LIST_FEEDS_FAILED",
-			"获取Feeds列表失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "LIST_FEEDS_FAILED",
+			"获取Feeds列表失败", err)
 		return
 	}
 
@@ -163,8 +264,8 @@ func (s *AppServer) searchFeedsHandler(c *gin.Context) {
 	// 搜索 Feeds
 	result, err := s.xiaohongshuService.SearchFeeds(c.Request.Context(), keyword, filters)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "SEARCH_FEEDS_FAILED",
-			"搜索Feeds失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "SEARCH_FEEDS_FAILED",
+			"搜索Feeds失败", err)
 		return
 	}
 
@@ -184,8 +285,8 @@ func (s *AppServer) getFeedDetailHandler(c *gin.Context) {
 	// 获取 Feed 详情
 	result, err := s.xiaohongshuService.GetFeedDetail(c.Request.Context(), req.FeedID, req.XsecToken)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "GET_FEED_DETAIL_FAILED",
-			"获取Feed详情失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "GET_FEED_DETAIL_FAILED",
+			"获取Feed详情失败", err)
 		return
 	}
 
@@ -205,8 +306,8 @@ func (s *AppServer) userProfileHandler(c *gin.Context) {
 	// 获取用户信息
 	result, err := s.xiaohongshuService.UserProfile(c.Request.Context(), req.UserID, req.XsecToken)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "GET_USER_PROFILE_FAILED",
-			"获取用户主页失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "GET_USER_PROFILE_FAILED",
+			"获取用户主页失败", err)
 		return
 	}
 
@@ -226,8 +327,8 @@ func (s *AppServer) postCommentHandler(c *gin.Context) {
 	// 发表评论
 	result, err := s.xiaohongshuService.PostCommentToFeed(c.Request.Context(), req.FeedID, req.XsecToken, req.Content)
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "POST_COMMENT_FAILED",
-			"发表评论失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "POST_COMMENT_FAILED",
+			"发表评论失败", err)
 		return
 	}
 
@@ -235,14 +336,126 @@ func (s *AppServer) postCommentHandler(c *gin.Context) {
 	respondSuccess(c, result, result.Message)
 }
 
-// healthHandler 健康检查
-func healthHandler(c *gin.Context) {
-	respondSuccess(c, map[string]any{
+// healthHandler 健康检查，启用会话监控时同时返回各账号的登录会话状态
+func (s *AppServer) healthHandler(c *gin.Context) {
+	data := map[string]any{
 		"status":    "healthy",
 		"service":   "xiaohongshu-mcp",
 		"account":   "ai-report",
 		"timestamp": "now",
-	}, "服务正常")
+	}
+
+	if s.sessions != nil {
+		statuses := s.sessions.Statuses()
+		reloginRequired := []string{}
+		for _, st := range statuses {
+			if st.State == session.StateExpired {
+				reloginRequired = append(reloginRequired, st.Account)
+			}
+		}
+		data["sessions"] = statuses
+		data["relogin_required"] = reloginRequired
+	}
+
+	respondSuccess(c, data, "服务正常")
+}
+
+// browserPoolHandler 浏览器池使用情况
+func (s *AppServer) browserPoolHandler(c *gin.Context) {
+	respondSuccess(c, s.xiaohongshuService.BrowserPoolStats(), "获取浏览器池状态成功")
+}
+
+// actionQueueHandler 各账号操作队列的状态
+func (s *AppServer) actionQueueHandler(c *gin.Context) {
+	respondSuccess(c, map[string]any{"queues": s.xiaohongshuService.ActionQueueStats()}, "获取操作队列状态成功")
+}
+
+// selectorsHandler 当前生效的页面选择器及版本
+func (s *AppServer) selectorsHandler(c *gin.Context) {
+	respondSuccess(c, xiaohongshu.CurrentSelectors(), "获取选择器成功")
+}
+
+// reloadSelectorsHandler 重新读取选择器覆盖文件，无需重启即可修复选择器
+func (s *AppServer) reloadSelectorsHandler(c *gin.Context) {
+	if err := xiaohongshu.ReloadSelectors(); err != nil {
+		respondError(c, http.StatusBadRequest, "RELOAD_SELECTORS_FAILED",
+			"重新加载选择器失败", err.Error())
+		return
+	}
+
+	respondSuccess(c, xiaohongshu.CurrentSelectors(), "重新加载选择器成功")
+}
+
+// listAccountsHandler 列出所有账号及登录状态
+func (s *AppServer) listAccountsHandler(c *gin.Context) {
+	respondSuccess(c, map[string]any{"accounts": s.xiaohongshuService.ListAccounts()}, "获取账号列表成功")
+}
+
+// addAccountHandler 注册新账号
+func (s *AppServer) addAccountHandler(c *gin.Context) {
+	var req AddAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST",
+			"请求参数错误", err.Error())
+		return
+	}
+
+	account, err := s.xiaohongshuService.AddAccount(c.Request.Context(), req.Name, req.DisplayName, req.Browser)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, accounts.ErrAccountExists) {
+			status = http.StatusConflict
+		}
+		respondError(c, status, "ADD_ACCOUNT_FAILED",
+			"添加账号失败", err.Error())
+		return
+	}
+
+	respondSuccess(c, account, "添加账号成功，请使用 account 参数获取二维码登录")
+}
+
+// setAccountBrowserHandler 设置账号的浏览器指纹和代理，请求体为空对象时恢复使用全局配置
+func (s *AppServer) setAccountBrowserHandler(c *gin.Context) {
+	var profile browser.Profile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		respondError(c, http.StatusBadRequest, "INVALID_REQUEST",
+			"请求参数错误", err.Error())
+		return
+	}
+
+	var p *browser.Profile
+	if profile != (browser.Profile{}) {
+		p = &profile
+	}
+
+	account, err := s.xiaohongshuService.SetAccountBrowser(c.Request.Context(), c.Param("name"), p)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "SET_ACCOUNT_BROWSER_FAILED",
+			"设置账号浏览器配置失败", err.Error())
+		return
+	}
+
+	respondSuccess(c, account, "设置账号浏览器配置成功，下次使用该账号时生效")
+}
+
+// removeAccountHandler 删除账号及其 cookies
+func (s *AppServer) removeAccountHandler(c *gin.Context) {
+	name := c.Param("name")
+	if err := s.xiaohongshuService.RemoveAccount(name); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "REMOVE_ACCOUNT_FAILED",
+			"删除账号失败", err.Error())
+		return
+	}
+
+	respondSuccess(c, map[string]any{"name": name}, "删除账号成功")
 }
 
 // myProfileHandler 我的信息
@@ -250,11 +463,68 @@ func (s *AppServer) myProfileHandler(c *gin.Context) {
 	// 获取当前登录用户信息
 	result, err := s.xiaohongshuService.GetMyProfile(c.Request.Context())
 	if err != nil {
-		respondError(c, http.StatusInternalServerError, "GET_MY_PROFILE_FAILED",
-			"获取我的主页失败", err.Error())
+		respondActionError(c, http.StatusInternalServerError, "GET_MY_PROFILE_FAILED",
+			"获取我的主页失败", err)
 		return
 	}
 
 	c.Set("account", "ai-report")
 	respondSuccess(c, map[string]any{"data": result}, "获取我的主页成功")
 }
+
+// getDiagnosticsHandler 获取操作失败时保存的诊断信息
+func (s *AppServer) getDiagnosticsHandler(c *gin.Context) {
+	store := s.xiaohongshuService.diagnostics
+	if store == nil {
+		respondError(c, http.StatusNotFound, "DIAGNOSTICS_DISABLED",
+			"未启用诊断信息", "set DIAGNOSTICS_MAX_ENTRIES > 0 to enable")
+		return
+	}
+
+	report, err := store.Get(c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, diagnostics.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "DIAGNOSTICS_NOT_FOUND",
+			"诊断信息不存在", err.Error())
+		return
+	}
+
+	respondSuccess(c, report, "获取诊断信息成功")
+}
+
+// getDiagnosticsFileHandler 下载诊断信息中的截图或 DOM 快照
+func (s *AppServer) getDiagnosticsFileHandler(c *gin.Context) {
+	store := s.xiaohongshuService.diagnostics
+	if store == nil {
+		respondError(c, http.StatusNotFound, "DIAGNOSTICS_DISABLED",
+			"未启用诊断信息", "set DIAGNOSTICS_MAX_ENTRIES > 0 to enable")
+		return
+	}
+
+	name := diagnostics.ScreenshotFile
+	if c.Param("file") == "dom" {
+		name = diagnostics.DOMFile
+	} else if c.Param("file") != "screenshot" {
+		respondError(c, http.StatusNotFound, "DIAGNOSTICS_NOT_FOUND",
+			"诊断文件不存在", "file must be screenshot or dom")
+		return
+	}
+
+	path, err := store.ArtefactPath(c.Param("id"), name)
+	if err != nil {
+		respondError(c, http.StatusNotFound, "DIAGNOSTICS_NOT_FOUND",
+			"诊断文件不存在", err.Error())
+		return
+	}
+
+	// DOM 快照来自第三方页面，按纯文本下载，避免在本服务的域名下被当作网页渲染
+	if name == diagnostics.DOMFile {
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="`+c.Param("id")+`-dom.html"`)
+		c.Header("X-Content-Type-Options", "nosniff")
+	}
+	c.File(path)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/diagnostics"
+)
+
+func TestGetDiagnosticsFileServesDOMAsText(t *testing.T) {
+	dir := t.TempDir()
+	store, err := diagnostics.NewStore(dir)
+	require.NoError(t, err)
+
+	id := "20250102T120000Z-abcdef"
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, id), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id, diagnostics.DOMFile), []byte(`<script>alert(1)</script>`), 0o644))
+
+	s := &AppServer{xiaohongshuService: &XiaohongshuService{diagnostics: store}}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/diagnostics/:id/:file", s.getDiagnosticsFileHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/diagnostics/"+id+"/dom", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+}
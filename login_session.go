@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/accounts"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+const (
+	// loginSessionTimeout 扫码登录的最长等待时间，期间二维码过期会自动刷新
+	loginSessionTimeout = 5 * time.Minute
+	// loginSessionRetention 登录会话结束后保留多久，供调用方查询结果
+	loginSessionRetention = 10 * time.Minute
+)
+
+// ErrLoginSessionNotFound 登录会话不存在或已被清理
+var ErrLoginSessionNotFound = errors.New("登录会话不存在或已过期")
+
+// LoginSessionStatus 登录会话状态
+type LoginSessionStatus string
+
+const (
+	LoginWaiting   LoginSessionStatus = "waiting"   // 等待扫码
+	LoginScanned   LoginSessionStatus = "scanned"   // 已扫码，等待在手机上确认
+	LoginConfirmed LoginSessionStatus = "confirmed" // 登录成功，cookies 已保存
+	LoginExpired   LoginSessionStatus = "expired"   // 超时未完成登录
+	LoginFailed    LoginSessionStatus = "failed"    // 登录过程出错
+)
+
+// LoginSession 一次扫码登录，可通过 ID 轮询状态，二维码过期后 Img 会更新为新的二维码
+type LoginSession struct {
+	ID        string             `json:"session_id"`
+	Account   string             `json:"account"`
+	Status    LoginSessionStatus `json:"status"`
+	Img       string             `json:"img,omitempty"`
+	Refreshes int                `json:"refreshes"` // 二维码刷新次数
+	Error     string             `json:"error,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	ExpiresAt time.Time          `json:"expires_at"`
+}
+
+// finished 登录会话是否已结束
+func (l *LoginSession) finished() bool {
+	return l.Status == LoginConfirmed || l.Status == LoginExpired || l.Status == LoginFailed
+}
+
+// loginSessions 登录会话表，每个账号同一时间只有一个进行中的会话
+type loginSessions struct {
+	mu       sync.Mutex
+	sessions map[string]*LoginSession
+	// starting 按账号串行化会话的创建，避免同一账号并发请求时打开多个二维码，不同账号互不等待
+	starting map[string]*startLock
+}
+
+// startLock 账号的会话创建锁，refs 为持有或等待的请求数，归零时删除
+type startLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newLoginSessions() *loginSessions {
+	return &loginSessions{
+		sessions: map[string]*LoginSession{},
+		starting: map[string]*startLock{},
+	}
+}
+
+// lockStart 锁住账号的会话创建，返回解锁函数
+func (ls *loginSessions) lockStart(account string) (unlock func()) {
+	ls.mu.Lock()
+	l, ok := ls.starting[account]
+	if !ok {
+		l = &startLock{}
+		ls.starting[account] = l
+	}
+	l.refs++
+	ls.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		ls.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(ls.starting, account)
+		}
+		ls.mu.Unlock()
+	}
+}
+
+// get 返回会话副本，顺便清理过期的会话
+func (ls *loginSessions) get(id string) (*LoginSession, bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.pruneLocked()
+	l, ok := ls.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *l
+	return &copied, true
+}
+
+// active 返回账号进行中的会话
+func (ls *loginSessions) active(account string) (*LoginSession, bool) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.pruneLocked()
+	for _, l := range ls.sessions {
+		if l.Account == account && !l.finished() {
+			copied := *l
+			return &copied, true
+		}
+	}
+	return nil, false
+}
+
+func (ls *loginSessions) add(l *LoginSession) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.sessions[l.ID] = l
+}
+
+// update 修改会话，已结束的会话不再变化
+func (ls *loginSessions) update(id string, fn func(l *LoginSession)) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	if l, ok := ls.sessions[id]; ok && !l.finished() {
+		fn(l)
+		l.UpdatedAt = time.Now()
+	}
+}
+
+func (ls *loginSessions) pruneLocked() {
+	for id, l := range ls.sessions {
+		if l.finished() && time.Since(l.UpdatedAt) > loginSessionRetention {
+			delete(ls.sessions, id)
+		}
+	}
+}
+
+// StartLoginSession 开始扫码登录，账号已有进行中的会话时直接返回该会话。
+// 已登录时返回状态为 confirmed 的会话。
+func (s *XiaohongshuService) StartLoginSession(ctx context.Context) (*LoginSession, error) {
+	account, err := s.accounts.Get(accounts.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := s.logins.lockStart(account.Name)
+	defer unlock()
+
+	if l, ok := s.logins.active(account.Name); ok {
+		return l, nil
+	}
+
+	// 请求返回后还要继续等待扫码，页面租期跟随登录超时而不是请求
+	loginCtx, cancel := context.WithTimeout(accounts.WithAccount(context.Background(), account.Name), loginSessionTimeout)
+	// 扫码等待长达数分钟，使用独立浏览器，不占用账号的操作队列和浏览器池
+	page, release, err := s.dedicatedPage(loginCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	done := func() {
+		release()
+		cancel()
+	}
+
+	loginAction := xiaohongshu.NewLogin(page)
+
+	img, loggedIn, err := loginAction.FetchQrcodeImage(ctx)
+	if err != nil || loggedIn {
+		defer done()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &LoginSession{
+		ID:        uuid.New().String(),
+		Account:   account.Name,
+		Status:    LoginWaiting,
+		Img:       img,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(loginSessionTimeout),
+	}
+	if loggedIn {
+		session.Status = LoginConfirmed
+		session.ExpiresAt = now
+		s.accounts.SetLoginState(account.Name, true)
+	}
+	s.logins.add(session)
+	result := *session
+
+	if !loggedIn {
+		go func() {
+			defer done()
+
+			err := loginAction.WaitForQrcodeLogin(loginCtx, func(status xiaohongshu.QrcodeStatus, img string) {
+				s.logins.update(session.ID, func(l *LoginSession) {
+					switch status {
+					case xiaohongshu.QrcodeScanned:
+						l.Status = LoginScanned
+					case xiaohongshu.QrcodeWaiting:
+						l.Status = LoginWaiting
+						if img != "" {
+							l.Img = img
+							l.Refreshes++
+						}
+					}
+				})
+			})
+
+			switch {
+			case err == nil:
+				if er := saveCookies(page, account.Cookies()); er != nil {
+					logrus.Errorf("failed to save cookies: %v", er)
+					s.failLoginSession(session.ID, er)
+					return
+				}
+				// 新 cookies 生效，池中的浏览器需要重建
+				s.refreshPool(account.Name)
+				s.accounts.SetLoginState(account.Name, true)
+				s.logins.update(session.ID, func(l *LoginSession) { l.Status = LoginConfirmed })
+				logrus.Infof("账号 %s 扫码登录成功", account.Name)
+			case errors.Is(err, context.DeadlineExceeded):
+				s.logins.update(session.ID, func(l *LoginSession) { l.Status = LoginExpired })
+			default:
+				s.failLoginSession(session.ID, err)
+			}
+		}()
+	}
+
+	return &result, nil
+}
+
+func (s *XiaohongshuService) failLoginSession(id string, err error) {
+	s.logins.update(id, func(l *LoginSession) {
+		l.Status = LoginFailed
+		l.Error = err.Error()
+	})
+}
+
+// GetLoginSession 查询扫码登录会话的状态
+func (s *XiaohongshuService) GetLoginSession(id string) (*LoginSession, error) {
+	l, ok := s.logins.get(id)
+	if !ok {
+		return nil, ErrLoginSessionNotFound
+	}
+	return l, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginSessionsLockStartPerAccount(t *testing.T) {
+	ls := newLoginSessions()
+
+	unlockA := ls.lockStart("a")
+
+	// 其他账号不等待
+	done := make(chan struct{})
+	go func() {
+		ls.lockStart("b")()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("starting a session for b waited for a")
+	}
+
+	// 同一账号等待前一个请求完成
+	started := make(chan struct{})
+	go func() {
+		ls.lockStart("a")()
+		close(started)
+	}()
+	select {
+	case <-started:
+		t.Fatal("second session for a started while the first was starting")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlockA()
+	<-started
+	assert.Empty(t, ls.starting)
+}
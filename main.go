@@ -1,11 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/accounts"
+	"github.com/xpzouying/xiaohongshu-mcp/browser"
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/actionqueue"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/diagnostics"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/imaging"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/processor"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/publishers"
 	facebookPublisher "github.com/xpzouying/xiaohongshu-mcp/pkg/publishers/facebook"
@@ -13,8 +27,10 @@ import (
 	twitterPublisher "github.com/xpzouying/xiaohongshu-mcp/pkg/publishers/twitter"
 	youtubePublisher "github.com/xpzouying/xiaohongshu-mcp/pkg/publishers/youtube"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/scheduler"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/session"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/translator"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 )
 
 func main() {
@@ -37,8 +53,77 @@ func main() {
 	configs.InitHeadless(headless)
 	configs.SetBinPath(binPath)
 
+	// บัญชี Xiaohongshu: แต่ละบัญชีมี cookies และ browser pool ของตัวเอง
+	// บัญชี default ใช้ cookies ตำแหน่งเดิม บัญชีอื่นเก็บไว้ใน ACCOUNTS_DIR/<name>/cookies.json
+	// cookies เข้ารหัสด้วย AES-GCM เมื่อตั้ง COOKIES_ENCRYPTION_KEY หรือ COOKIES_KEY_FILE
+	// หรือเก็บใน secret store ภายนอกเมื่อ COOKIES_BACKEND=secret
+	accountsDir := envString("ACCOUNTS_DIR", "data/accounts")
+	cookieBackend, err := cookies.NewBackendFromEnv(accountsDir, accounts.DefaultAccount)
+	if err != nil {
+		logrus.Fatalf("ตั้งค่าที่เก็บ cookies ล้มเหลว: %v", err)
+	}
+	registry, err := accounts.NewRegistry(accountsDir, cookieBackend)
+	if err != nil {
+		logrus.Fatalf("โหลดรายชื่อบัญชีล้มเหลว: %v", err)
+	}
+
+	// fingerprint และ proxy ของเบราว์เซอร์ (BROWSER_PROXY, BROWSER_USER_AGENT ฯลฯ)
+	// ใช้กับทุกบัญชี บัญชีที่ตั้งค่าไว้ใน registry จะแทนที่เฉพาะค่าที่ตั้ง
+	browserProfile, err := browser.ProfileFromEnv()
+	if err != nil {
+		logrus.Fatalf("ตั้งค่าเบราว์เซอร์ไม่ถูกต้อง: %v", err)
+	}
+
+	// selector ของหน้าเว็บ Xiaohongshu: ตั้ง SELECTORS_FILE (JSON หรือ YAML) เพื่อแก้ selector เมื่อหน้าเว็บเปลี่ยน
+	// โดยไม่ต้อง build ใหม่ แก้ไฟล์แล้วเรียก POST /api/v1/selectors/reload
+	if path := os.Getenv("SELECTORS_FILE"); path != "" {
+		if err := xiaohongshu.LoadSelectors(path); err != nil {
+			logrus.Fatalf("โหลด selector ล้มเหลว: %v", err)
+		}
+	}
+
+	// Browser pool: เปิดเบราว์เซอร์ค้างไว้ใช้ซ้ำแทนการเปิด Chrome ใหม่ทุก request
+	// คิวงานทำงานต่อบัญชีทีละงานอยู่แล้ว จึงใช้ 1 เบราว์เซอร์ต่อบัญชีเป็นค่าเริ่มต้น
+	poolConfig := browser.PoolConfig{
+		Size:                envInt("BROWSER_POOL_SIZE", 1),
+		MaxUses:             envInt("BROWSER_POOL_MAX_USES", 50),
+		HealthCheckInterval: time.Minute,
+		Headless:            headless,
+		BinPath:             binPath,
+		Profile:             browserProfile,
+	}
+
+	// คิวงานต่อบัญชี: ทำงานบนเบราว์เซอร์ทีละงาน (publish > interaction > read)
+	// และเว้นระยะห่างขั้นต่ำระหว่างงาน เพื่อไม่ให้ถูกมองว่าเป็นบอท
+	queues := actionqueue.NewManager(actionqueue.Config{
+		MinSpacing: envDuration("ACTION_MIN_SPACING", 3*time.Second),
+	})
+
 	// เริ่มต้นบริการ
-	xiaohongshuService := NewXiaohongshuService()
+	xiaohongshuService := NewXiaohongshuService(registry, poolConfig, queues)
+	defer xiaohongshuService.Close()
+
+	// เก็บ screenshot, DOM และ console log ของหน้าเว็บเมื่อการทำงานบนเบราว์เซอร์ล้มเหลว เพื่อใช้ debug
+	// เก็บไว้ไม่เกิน DIAGNOSTICS_MAX_ENTRIES รายการและไม่เกิน DIAGNOSTICS_MAX_AGE ตั้ง DIAGNOSTICS_MAX_ENTRIES=0 เพื่อปิด
+	if maxEntries := envInt("DIAGNOSTICS_MAX_ENTRIES", diagnostics.DefaultMaxEntries); maxEntries > 0 {
+		store, err := diagnostics.NewStore(envString("DIAGNOSTICS_DIR", "data/diagnostics"),
+			diagnostics.WithMaxEntries(maxEntries),
+			diagnostics.WithMaxAge(envDuration("DIAGNOSTICS_MAX_AGE", diagnostics.DefaultMaxAge)),
+			// การบันทึก console ต้องเปิด Runtime domain ของ CDP ซึ่งบางเว็บตรวจจับได้
+			diagnostics.WithConsole(os.Getenv("DIAGNOSTICS_CONSOLE") != "false"),
+		)
+		if err != nil {
+			logrus.Warnf("สร้างที่เก็บข้อมูลวินิจฉัยล้มเหลว: %v", err)
+		} else {
+			xiaohongshuService.diagnostics = store
+		}
+	}
+	go func() {
+		// อุ่นเครื่องเฉพาะบัญชี default บัญชีอื่นเปิดเบราว์เซอร์เมื่อใช้งานครั้งแรก
+		if err := xiaohongshuService.WarmPool(context.Background(), accounts.DefaultAccount); err != nil {
+			logrus.Warnf("อุ่นเครื่อง browser pool ล้มเหลว: %v", err)
+		}
+	}()
 
 	// โหลดการตั้งค่าแพลตฟอร์ม
 	publishersConfig, err := configs.LoadPublishersConfig(configPath)
@@ -48,35 +133,52 @@ func main() {
 	}
 
 	// เริ่มต้น translator - รองรับ AI หลายตัว (ChatGPT, Claude, Gemini) และ Google Translate
-	var trans translator.Translator
+	trans := newTranslator()
 
-	// ตรวจสอบว่าจะใช้ AI provider ไหน
-	aiProvider := os.Getenv("AI_TRANSLATOR_PROVIDER") // openai, anthropic, google, google-translate
-	aiAPIKey := os.Getenv("AI_TRANSLATOR_API_KEY")
-	aiModel := os.Getenv("AI_TRANSLATOR_MODEL") // ไม่บังคับ จะใช้ค่าเริ่มต้น
-
-	if aiProvider != "" && aiProvider != "google-translate" && aiAPIKey != "" {
-		// ใช้ AI Translator
-		trans = translator.NewAITranslator(aiProvider, aiAPIKey, aiModel)
-		logrus.Infof("✅ ใช้ AI Translator: %s (model: %s)", aiProvider, aiModel)
-	} else {
-		// ใช้ Google Translate (เดิม)
-		googleAPIKey := os.Getenv("GOOGLE_TRANSLATE_API_KEY")
-		trans = translator.NewGoogleTranslator(googleAPIKey)
-		if googleAPIKey == "" {
-			logrus.Info("⚠️ ใช้ Google Translate ฟรี (มีข้อจำกัด rate limit)")
+	// โหลด glossary (คำเฉพาะ / คำที่ห้ามแปล) แยกตามคู่ภาษา เช่น glossary/zh-en.json
+	glossaryDir := os.Getenv("TRANSLATION_GLOSSARY_DIR")
+	if glossaryDir == "" && configPath != "" {
+		glossaryDir = filepath.Join(configPath, "glossary")
+	}
+	if glossaryDir != "" {
+		glossary, err := translator.LoadGlossary(glossaryDir)
+		if err != nil {
+			logrus.Warnf("โหลด glossary ล้มเหลว: %v", err)
 		} else {
-			logrus.Info("✅ ใช้ Google Translate API")
+			translator.ApplyGlossary(trans, glossary)
+			logrus.Infof("✅ โหลด glossary แล้ว %d คำ", glossary.Len())
 		}
 	}
 
 	// เริ่มต้นตัวประมวลผลเนื้อหา
-	proc := processor.NewProcessor(trans)
+	// โหมด "localize and adapt": ให้ AI เขียนแคปชันใหม่ให้เหมาะกับแต่ละแพลตฟอร์มแทนการแปลตรงตัว
+	var procOptions []processor.Option
+	if os.Getenv("LOCALIZE_ENABLED") == "true" {
+		procOptions = append(procOptions, processor.WithLocalization(processor.LocalizeConfig{
+			Enabled:    true,
+			Tone:       os.Getenv("LOCALIZE_TONE"),
+			Audience:   os.Getenv("LOCALIZE_AUDIENCE"),
+			TargetLang: os.Getenv("LOCALIZE_TARGET_LANG"),
+		}))
+		logrus.Info("✅ เปิดใช้โหมด localize and adapt")
+	}
+	// โพสต์ Live Photo เป็นวิดีโอสั้นบนแพลตฟอร์มที่รองรับวิดีโอ (TikTok, YouTube, Facebook)
+	if os.Getenv("LIVE_PHOTO_AS_VIDEO") == "true" {
+		procOptions = append(procOptions, processor.WithLivePhotoVideos())
+	}
+	proc := processor.NewProcessor(trans, procOptions...)
+
+	// แคชไฟล์มีเดียที่ดาวน์โหลด (ใช้ร่วมกันทุก publisher) และล้างไฟล์เก่าทุกชั่วโมง
+	fetcher, err := media.Default()
+	if err != nil {
+		logrus.Fatalf("สร้างแคชมีเดียล้มเหลว: %v", err)
+	}
+	fetcher.StartCleanup(context.Background(), time.Hour)
 
 	// เริ่มต้น publisher แต่ละแพลตฟอร์ม
 	publishersMap := make(map[types.Platform]publishers.Publisher)
 
-	twitterPub := twitterPublisher.NewPublisher(publishersConfig.Twitter)
+	twitterPub := twitterPublisher.NewPublisher(publishersConfig.Twitter, fetcher)
 	if twitterPub.IsEnabled() {
 		publishersMap[types.PlatformTwitter] = twitterPub
 		logrus.Info("✅ Twitter publisher เปิดใช้งานแล้ว")
@@ -84,7 +186,7 @@ func main() {
 		logrus.Info("⚠️ Twitter publisher ไม่ได้เปิดใช้งาน")
 	}
 
-	tiktokPub := tiktokPublisher.NewPublisher(publishersConfig.TikTok)
+	tiktokPub := tiktokPublisher.NewPublisher(publishersConfig.TikTok, fetcher)
 	if tiktokPub.IsEnabled() {
 		publishersMap[types.PlatformTikTok] = tiktokPub
 		logrus.Info("✅ TikTok publisher เปิดใช้งานแล้ว")
@@ -92,7 +194,7 @@ func main() {
 		logrus.Info("⚠️ TikTok publisher ไม่ได้เปิดใช้งาน")
 	}
 
-	facebookPub := facebookPublisher.NewPublisher(publishersConfig.Facebook)
+	facebookPub := facebookPublisher.NewPublisher(publishersConfig.Facebook, fetcher)
 	if facebookPub.IsEnabled() {
 		publishersMap[types.PlatformFacebook] = facebookPub
 		logrus.Info("✅ Facebook publisher เปิดใช้งานแล้ว")
@@ -100,7 +202,7 @@ func main() {
 		logrus.Info("⚠️ Facebook publisher ไม่ได้เปิดใช้งาน")
 	}
 
-	youtubePub := youtubePublisher.NewPublisher(publishersConfig.YouTube)
+	youtubePub := youtubePublisher.NewPublisher(publishersConfig.YouTube, fetcher)
 	if youtubePub.IsEnabled() {
 		publishersMap[types.PlatformYouTube] = youtubePub
 		logrus.Info("✅ YouTube publisher เปิดใช้งานแล้ว")
@@ -109,18 +211,219 @@ func main() {
 	}
 
 	// เริ่มต้น scheduler
-	sched := scheduler.NewScheduler(proc, publishersMap)
+	// ปรับรูปภาพ (ฟอร์แมต ขนาด อัตราส่วน) ให้ตรงข้อกำหนดของแต่ละแพลตฟอร์มก่อนโพสต์
+	transformer, err := imaging.NewTransformer(filepath.Join(fetcher.Dir(), "transformed"))
+	if err != nil {
+		logrus.Fatalf("สร้างตัวแปลงรูปภาพล้มเหลว: %v", err)
+	}
+	sched := scheduler.NewScheduler(proc, publishersMap, scheduler.WithImagePipeline(imaging.NewPipeline(fetcher, transformer)))
 	sched.Start()
 	defer sched.Stop()
 
 	logrus.Infof("🚀 ระบบเผยแพร่หลายแพลตฟอร์มเริ่มต้นแล้ว เปิดใช้งาน %d แพลตฟอร์ม", len(publishersMap))
 
+	// ตรวจสอบ session การล็อกอินเป็นระยะ (ใช้หน้าเว็บจาก browser pool) และแจ้งเตือนเมื่อต้องล็อกอินใหม่
+	// ก่อนถึงเวลางานที่กำหนดไว้ ตั้ง SESSION_CHECK_INTERVAL=0 เพื่อปิด
+	var sessions *session.Monitor
+	if interval := envDuration("SESSION_CHECK_INTERVAL", 30*time.Minute); interval > 0 {
+		sessions = newSessionMonitor(interval, registry, xiaohongshuService, sched)
+		sessions.Start()
+		defer sessions.Stop()
+	}
+
 	// สร้างและเริ่มต้น app server
 	appServer := NewAppServer(xiaohongshuService)
 	appServer.scheduler = sched
 	appServer.publishers = publishersMap
+	appServer.sessions = sessions
 
 	if err := appServer.Start(port); err != nil {
 		logrus.Fatalf("failed to run server: %v", err)
 	}
 }
+
+// newSessionMonitor สร้างตัวตรวจสอบ session ของทุกบัญชี แจ้งเตือนผ่าน log และ SESSION_WEBHOOK_URL (ถ้าตั้งค่า)
+func newSessionMonitor(interval time.Duration, registry *accounts.Registry, service *XiaohongshuService, sched *scheduler.Scheduler) *session.Monitor {
+	notifiers := []session.Notifier{session.LogNotifier{}}
+	if url := os.Getenv("SESSION_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, session.NewWebhookNotifier(url))
+	}
+
+	return session.NewMonitor(session.Config{
+		Interval:      interval,
+		ExpiryWarning: envDuration("SESSION_EXPIRY_WARNING", 72*time.Hour),
+		JobLead:       envDuration("SESSION_JOB_LEAD", 30*time.Minute),
+		Accounts: func() []string {
+			infos := registry.List()
+			names := make([]string, 0, len(infos))
+			for _, info := range infos {
+				names = append(names, info.Name)
+			}
+			return names
+		},
+		Check: func(ctx context.Context, account string) (bool, error) {
+			ctx, cancel := context.WithTimeout(accounts.WithAccount(ctx, account), 2*time.Minute)
+			defer cancel()
+			loggedIn, err := service.ValidateLogin(ctx)
+			if errors.Is(err, ErrQueueBusy) {
+				// บัญชีกำลังทำงานอื่นอยู่ ข้ามรอบนี้แล้วตรวจใหม่รอบถัดไป
+				return false, fmt.Errorf("%w: %v", session.ErrCheckSkipped, err)
+			}
+			return loggedIn, err
+		},
+		LoadCookies: func(account string) ([]byte, error) {
+			a, err := registry.Get(account)
+			if err != nil {
+				return nil, err
+			}
+			return a.Cookies().LoadCookies()
+		},
+		UpcomingJobs: func(account string) []time.Time {
+			var times []time.Time
+			for _, job := range sched.ListJobs() {
+				if job.Status == types.JobStatusPending && job.Account == account {
+					times = append(times, job.ScheduledAt)
+				}
+			}
+			return times
+		},
+		Notifiers: notifiers,
+	})
+}
+
+// newTranslator สร้าง translator จาก environment variables
+//
+// AI_TRANSLATOR_PROVIDER รับได้ทั้งชื่อเดียว (openai, anthropic, google, google-translate, local, libretranslate)
+// หรือหลายชื่อคั่นด้วยจุลภาค เช่น "anthropic,openai,google-translate"
+// ซึ่งจะสร้าง fallback chain ที่ลองตามลำดับและมี circuit breaker ต่อ provider
+func newTranslator() translator.Translator {
+	var names []string
+	for _, name := range strings.Split(os.Getenv("AI_TRANSLATOR_PROVIDER"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) <= 1 {
+		name := ""
+		if len(names) == 1 {
+			name = names[0]
+		}
+		trans, err := newTranslatorForProvider(name, true)
+		if err != nil {
+			// ไม่มี API key หรือ provider ไม่ถูกต้อง ใช้ Google Translate แทน (เดิม)
+			logrus.Warnf("⚠️ %v, ใช้ Google Translate แทน", err)
+			trans, _ = newTranslatorForProvider("google-translate", true)
+		}
+		return trans
+	}
+
+	var chain []translator.ProviderTranslator
+	for i, name := range names {
+		trans, err := newTranslatorForProvider(name, i == 0)
+		if err != nil {
+			logrus.Warnf("⚠️ ข้าม translator %s: %v", name, err)
+			continue
+		}
+		chain = append(chain, translator.ProviderTranslator{Name: name, Translator: trans})
+	}
+
+	if len(chain) == 0 {
+		logrus.Warn("⚠️ ไม่มี translator ใน chain ที่ใช้งานได้, ใช้ Google Translate แทน")
+		trans, _ := newTranslatorForProvider("google-translate", true)
+		return trans
+	}
+
+	logrus.Infof("✅ ใช้ Translator fallback chain: %d provider", len(chain))
+	return translator.NewChainTranslator(chain, translator.DefaultBreakerConfig())
+}
+
+// newTranslatorForProvider สร้าง translator สำหรับ provider เดียว
+//
+// API key และ model อ่านจาก AI_TRANSLATOR_<PROVIDER>_API_KEY / AI_TRANSLATOR_<PROVIDER>_MODEL
+// (เช่น AI_TRANSLATOR_OPENAI_API_KEY) ถ้าไม่มีและเป็น provider หลัก จะใช้ AI_TRANSLATOR_API_KEY / AI_TRANSLATOR_MODEL
+func newTranslatorForProvider(name string, primary bool) (translator.Translator, error) {
+	envPrefix := "AI_TRANSLATOR_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	lookup := func(key string) string {
+		if v := os.Getenv(envPrefix + key); v != "" {
+			return v
+		}
+		if primary {
+			return os.Getenv("AI_TRANSLATOR_" + key)
+		}
+		return ""
+	}
+
+	switch name {
+	case "", "google-translate":
+		googleAPIKey := os.Getenv("GOOGLE_TRANSLATE_API_KEY")
+		if googleAPIKey == "" {
+			logrus.Info("⚠️ ใช้ Google Translate ฟรี (มีข้อจำกัด rate limit)")
+		} else {
+			logrus.Info("✅ ใช้ Google Translate API")
+		}
+		return translator.NewGoogleTranslator(googleAPIKey), nil
+	case "openai", "anthropic", "google":
+		apiKey := lookup("API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ไม่พบ API key สำหรับ %s", name)
+		}
+		model := lookup("MODEL") // ไม่บังคับ จะใช้ค่าเริ่มต้น
+		logrus.Infof("✅ ใช้ AI Translator: %s (model: %s)", name, model)
+		return translator.NewAITranslator(name, apiKey, model), nil
+	case "local":
+		// endpoint ในเครื่องที่เข้ากันได้กับ OpenAI (Ollama, vLLM) - ไม่ส่งข้อมูลออก cloud
+		baseURL := lookup("BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434/v1" // Ollama
+		}
+		model := lookup("MODEL")
+		logrus.Infof("✅ ใช้ Local AI Translator: %s (model: %s)", baseURL, model)
+		return translator.NewLocalAITranslator(baseURL, lookup("API_KEY"), model), nil
+	case "libretranslate":
+		baseURL := lookup("BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:5000"
+		}
+		logrus.Infof("✅ ใช้ LibreTranslate: %s", baseURL)
+		return translator.NewLibreTranslator(baseURL, lookup("API_KEY")), nil
+	default:
+		return nil, fmt.Errorf("ไม่รองรับ translator provider: %s", name)
+	}
+}
+
+// envString อ่านค่าจาก environment variable ใช้ค่า def เมื่อไม่ได้ตั้งค่า
+func envString(name, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// envInt อ่านค่าตัวเลขจาก environment variable ใช้ค่า def เมื่อไม่ได้ตั้งค่าหรือค่าไม่ถูกต้อง
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.Warnf("%s ไม่ถูกต้อง (%q) ใช้ค่าเริ่มต้น %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// envDuration อ่านระยะเวลาจาก environment variable (เช่น 3s, 500ms) ใช้ค่า def เมื่อไม่ได้ตั้งค่าหรือค่าไม่ถูกต้อง
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.Warnf("%s ไม่ถูกต้อง (%q) ใช้ค่าเริ่มต้น %s", name, value, def)
+		return def
+	}
+	return d
+}
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/browser"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 	"strings"
 	"time"
@@ -31,7 +31,7 @@ func (s *AppServer) handleCheckLoginStatus(ctx context.Context) *MCPToolResult {
 	// 根据 IsLoggedIn 判断并返回友好的提示
 	var resultText string
 	if status.IsLoggedIn {
-		resultText = fmt.Sprintf("✅ 已登录\n用户名: %s\n\n你可以使用其他功能了。", status.Username)
+		resultText = fmt.Sprintf("✅ 已登录\n账号: %s\n\n你可以使用其他功能了。", status.Username)
 	} else {
 		resultText = fmt.Sprintf("❌ 未登录\n\n请使用 get_login_qrcode 工具获取二维码进行登录。")
 	}
@@ -74,7 +74,7 @@ func (s *AppServer) handleGetLoginQrcode(ctx context.Context) *MCPToolResult {
 
 	// 已登录：文本 + 图片
 	contents := []MCPContent{
-		{Type: "text", Text: "请用小红书 App 在 " + deadline + " 前扫码登录 👇"},
+		{Type: "text", Text: "请用小红书 App 在 " + deadline + " 前扫码登录 👇\n登录会话 ID: " + result.SessionID + "，扫码后可用 get_login_session 查询登录结果"},
 		{
 			Type:     "image",
 			MimeType: "image/png",
@@ -84,11 +84,50 @@ func (s *AppServer) handleGetLoginQrcode(ctx context.Context) *MCPToolResult {
 	return &MCPToolResult{Content: contents}
 }
 
+// handleGetLoginSession 处理查询扫码登录会话，二维码刷新过时附带新的二维码
+func (s *AppServer) handleGetLoginSession(ctx context.Context, sessionID string) *MCPToolResult {
+	logrus.Infof("MCP: 查询登录会话 - %s", sessionID)
+
+	session, err := s.xiaohongshuService.GetLoginSession(sessionID)
+	if err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "查询登录会话失败: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	var text string
+	switch session.Status {
+	case LoginWaiting:
+		text = "等待扫码，请在 " + session.ExpiresAt.Format("2006-01-02 15:04:05") + " 前完成"
+	case LoginScanned:
+		text = "已扫码，请在手机上确认登录"
+	case LoginConfirmed:
+		text = "登录成功，账号: " + session.Account
+	case LoginExpired:
+		text = "登录超时，请重新调用 get_login_qrcode 获取二维码"
+	default:
+		text = "登录失败: " + session.Error
+	}
+
+	contents := []MCPContent{{Type: "text", Text: fmt.Sprintf("状态: %s\n%s", session.Status, text)}}
+	// 二维码已刷新，需要展示新的二维码
+	if session.Status == LoginWaiting && session.Refreshes > 0 {
+		contents[0].Text += fmt.Sprintf("\n二维码已过期并刷新 %d 次，请扫描新的二维码 👇", session.Refreshes)
+		contents = append(contents, MCPContent{
+			Type:     "image",
+			MimeType: "image/png",
+			Data:     strings.TrimPrefix(session.Img, "data:image/png;base64,"),
+		})
+	}
+	return &MCPToolResult{Content: contents}
+}
+
 // handleDeleteCookies 处理删除 cookies 请求，用于登录重置
 func (s *AppServer) handleDeleteCookies(ctx context.Context) *MCPToolResult {
 	logrus.Info("MCP: 删除 cookies，重置登录状态")
 
-	err := s.xiaohongshuService.DeleteCookies(ctx)
+	cookiePath, err := s.xiaohongshuService.DeleteCookies(ctx)
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{{Type: "text", Text: "删除 cookies 失败: " + err.Error()}},
@@ -96,7 +135,6 @@ func (s *AppServer) handleDeleteCookies(ctx context.Context) *MCPToolResult {
 		}
 	}
 
-	cookiePath := cookies.GetCookiesFilePath()
 	resultText := fmt.Sprintf("Cookies 已成功删除，登录状态已重置。\n\n删除的文件路径: %s\n\n下次操作时，需要重新登录。", cookiePath)
 	return &MCPToolResult{
 		Content: []MCPContent{{
@@ -115,6 +153,8 @@ func (s *AppServer) handlePublishContent(ctx context.Context, args map[string]in
 	content, _ := args["content"].(string)
 	imagePathsInterface, _ := args["images"].([]interface{})
 	tagsInterface, _ := args["tags"].([]interface{})
+	bestEffort, _ := args["best_effort"].(bool)
+	settings := parsePublishSettings(args)
 
 	var imagePaths []string
 	for _, path := range imagePathsInterface {
@@ -134,10 +174,12 @@ func (s *AppServer) handlePublishContent(ctx context.Context, args map[string]in
 
 	// 构建发布请求
 	req := &PublishRequest{
-		Title:   title,
-		Content: content,
-		Images:  imagePaths,
-		Tags:    tags,
+		Title:           title,
+		Content:         content,
+		Images:          imagePaths,
+		Tags:            tags,
+		BestEffort:      bestEffort,
+		PublishSettings: settings,
 	}
 
 	// 执行发布
@@ -169,6 +211,9 @@ func (s *AppServer) handlePublishVideo(ctx context.Context, args map[string]inte
 	content, _ := args["content"].(string)
 	videoPath, _ := args["video"].(string)
 	tagsInterface, _ := args["tags"].([]interface{})
+	cover, _ := args["cover"].(string)
+	coverTime := parseCoverTime(args)
+	settings := parsePublishSettings(args)
 
 	var tags []string
 	for _, tag := range tagsInterface {
@@ -181,7 +226,7 @@ func (s *AppServer) handlePublishVideo(ctx context.Context, args map[string]inte
 		return &MCPToolResult{
 			Content: []MCPContent{{
 				Type: "text",
-				Text: "发布失败: 缺少本地视频文件路径",
+				Text: "发布失败: 缺少视频文件",
 			}},
 			IsError: true,
 		}
@@ -191,10 +236,13 @@ func (s *AppServer) handlePublishVideo(ctx context.Context, args map[string]inte
 
 	// 构建发布请求
 	req := &PublishVideoRequest{
-		Title:   title,
-		Content: content,
-		Video:   videoPath,
-		Tags:    tags,
+		Title:           title,
+		Content:         content,
+		Video:           videoPath,
+		Tags:            tags,
+		Cover:           cover,
+		CoverTime:       coverTime,
+		PublishSettings: settings,
 	}
 
 	// 执行发布
@@ -218,6 +266,29 @@ func (s *AppServer) handlePublishVideo(ctx context.Context, args map[string]inte
 	}
 }
 
+// parseCoverTime 解析 cover_time，未传入时返回 nil，0 表示第一帧
+func parseCoverTime(args map[string]interface{}) *float64 {
+	switch v := args["cover_time"].(type) {
+	case float64:
+		return &v
+	case *float64:
+		return v
+	}
+	return nil
+}
+
+// parsePublishSettings 解析定时发布、可见范围和原创声明参数
+func parsePublishSettings(args map[string]interface{}) PublishSettings {
+	scheduleAt, _ := args["schedule_at"].(string)
+	visibility, _ := args["visibility"].(string)
+	original, _ := args["original"].(bool)
+	return PublishSettings{
+		ScheduleAt: scheduleAt,
+		Visibility: visibility,
+		Original:   original,
+	}
+}
+
 // handleListFeeds 处理获取Feeds列表
 func (s *AppServer) handleListFeeds(ctx context.Context) *MCPToolResult {
 	logrus.Info("MCP: 获取Feeds列表")
@@ -562,3 +633,130 @@ func (s *AppServer) handlePostComment(ctx context.Context, args map[string]inter
 		}},
 	}
 }
+
+// handleListAccounts 处理账号列表
+func (s *AppServer) handleListAccounts(ctx context.Context) *MCPToolResult {
+	logrus.Info("MCP: 获取账号列表")
+
+	jsonData, err := json.MarshalIndent(s.xiaohongshuService.ListAccounts(), "", "  ")
+	if err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: fmt.Sprintf("获取账号列表成功，但序列化失败: %v", err),
+			}},
+			IsError: true,
+		}
+	}
+
+	return &MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: string(jsonData),
+		}},
+	}
+}
+
+// handleAddAccount 处理添加账号
+func (s *AppServer) handleAddAccount(ctx context.Context, args AddAccountArgs) *MCPToolResult {
+	logrus.Infof("MCP: 添加账号 - %s", args.Name)
+
+	account, err := s.xiaohongshuService.AddAccount(ctx, args.Name, args.DisplayName, nil)
+	if err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: "添加账号失败: " + err.Error(),
+			}},
+			IsError: true,
+		}
+	}
+
+	resultText := fmt.Sprintf("账号 %s 添加成功，cookies 文件: %s\n\n请使用 get_login_qrcode 工具并指定 account=%s 扫码登录。",
+		account.Name, account.CookiesPath, account.Name)
+	return &MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: resultText,
+		}},
+	}
+}
+
+// handleSetAccountBrowser 处理设置账号的浏览器指纹和代理
+func (s *AppServer) handleSetAccountBrowser(ctx context.Context, args SetAccountBrowserArgs) *MCPToolResult {
+	logrus.Infof("MCP: 设置账号浏览器配置 - %s", args.Name)
+
+	profile := browser.Profile{
+		Proxy:     args.Proxy,
+		UserAgent: args.UserAgent,
+		Locale:    args.Locale,
+		Timezone:  args.Timezone,
+		Stealth:   args.Stealth,
+	}
+	if args.Viewport != "" {
+		viewport, err := browser.ParseViewport(args.Viewport)
+		if err != nil {
+			return &MCPToolResult{
+				Content: []MCPContent{{Type: "text", Text: "设置账号浏览器配置失败: " + err.Error()}},
+				IsError: true,
+			}
+		}
+		profile.Viewport = viewport
+	}
+
+	// 所有参数为空时恢复使用全局配置
+	var p *browser.Profile
+	if profile != (browser.Profile{}) {
+		p = &profile
+	}
+
+	account, err := s.xiaohongshuService.SetAccountBrowser(ctx, args.Name, p)
+	if err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "设置账号浏览器配置失败: " + err.Error()}},
+			IsError: true,
+		}
+	}
+
+	if account.Browser == nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: fmt.Sprintf("账号 %s 已恢复使用全局浏览器配置", account.Name)}},
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(account.Browser, "", "  ")
+	if err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{Type: "text", Text: "序列化结果失败: " + err.Error()}},
+			IsError: true,
+		}
+	}
+	return &MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: fmt.Sprintf("账号 %s 的浏览器配置已更新，下次使用时生效:\n%s", account.Name, jsonData),
+		}},
+	}
+}
+
+// handleRemoveAccount 处理删除账号
+func (s *AppServer) handleRemoveAccount(ctx context.Context, name string) *MCPToolResult {
+	logrus.Infof("MCP: 删除账号 - %s", name)
+
+	if err := s.xiaohongshuService.RemoveAccount(name); err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{{
+				Type: "text",
+				Text: "删除账号失败: " + err.Error(),
+			}},
+			IsError: true,
+		}
+	}
+
+	return &MCPToolResult{
+		Content: []MCPContent{{
+			Type: "text",
+			Text: fmt.Sprintf("账号 %s 已删除", name),
+		}},
+	}
+}
@@ -65,7 +65,7 @@ func (s *AppServer) handlePublishToPlatform(ctx context.Context, feedID, xsecTok
 	}
 
 	// Publish immediately
-	results, err := s.scheduler.PublishNow(feedDetail, []types.Platform{platform})
+	results, err := s.scheduler.PublishNow(ctx, feedDetail, []types.Platform{platform})
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{
@@ -174,7 +174,7 @@ func (s *AppServer) handlePublishToAllPlatforms(ctx context.Context, args Publis
 	}
 
 	// Publish to all platforms
-	results, err := s.scheduler.PublishNow(feedDetail, platforms)
+	results, err := s.scheduler.PublishNow(ctx, feedDetail, platforms)
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{
@@ -253,8 +253,19 @@ func (s *AppServer) handleSchedulePublish(ctx context.Context, args SchedulePubl
 		}
 	}
 
+	// Resolve the account now so the job and its session checks use it
+	account, err := s.xiaohongshuService.accounts.Get(args.Account)
+	if err != nil {
+		return &MCPToolResult{
+			Content: []MCPContent{
+				{Type: "text", Text: fmt.Sprintf("❌ 账号不可用: %v", err)},
+			},
+			IsError: true,
+		}
+	}
+
 	// Schedule the job
-	jobID, err := s.scheduler.ScheduleJob(args.FeedID, platforms, scheduledAt)
+	jobID, err := s.scheduler.ScheduleJob(args.FeedID, account.Name, platforms, scheduledAt)
 	if err != nil {
 		return &MCPToolResult{
 			Content: []MCPContent{
@@ -266,8 +277,8 @@ func (s *AppServer) handleSchedulePublish(ctx context.Context, args SchedulePubl
 
 	return &MCPToolResult{
 		Content: []MCPContent{
-			{Type: "text", Text: fmt.Sprintf("✅ 定时任务创建成功\n\n🆔 任务ID: %s\n📅 发布时间: %s\n📱 平台: %v",
-				jobID, scheduledAt.Format("2006-01-02 15:04:05"), args.Platforms)},
+			{Type: "text", Text: fmt.Sprintf("✅ 定时任务创建成功\n\n🆔 任务ID: %s\n📅 发布时间: %s\n📱 平台: %v\n👤 账号: %s",
+				jobID, scheduledAt.Format("2006-01-02 15:04:05"), args.Platforms, account.Name)},
 		},
 		IsError: false,
 	}
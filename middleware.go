@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/accounts"
 )
 
 // corsMiddleware CORS 中间件
@@ -32,3 +33,24 @@ func errorHandlingMiddleware() gin.HandlerFunc {
 			"服务器内部错误", recovered)
 	})
 }
+
+// accountMiddleware 读取 account 查询参数，指定本次请求操作的账号，未指定时使用默认账号
+func accountMiddleware(registry *accounts.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Query("account")
+		if name == "" {
+			c.Next()
+			return
+		}
+
+		if _, err := registry.Get(name); err != nil {
+			respondError(c, http.StatusNotFound, "ACCOUNT_NOT_FOUND",
+				"账号不存在", name)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(accounts.WithAccount(c.Request.Context(), name))
+		c.Next()
+	}
+}
@@ -0,0 +1,262 @@
+// Package actionqueue serializes browser actions per account, so that one account never
+// runs two actions at the same time and consecutive actions are spaced out.
+package actionqueue
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Priority decides which waiting action runs next, higher values first
+type Priority int
+
+const (
+	PriorityRead        Priority = iota // feeds, search, detail, profile
+	PriorityInteraction                 // like, favorite, comment
+	PriorityPublish                     // publish image or video notes
+)
+
+// String returns the name of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityPublish:
+		return "publish"
+	case PriorityInteraction:
+		return "interaction"
+	default:
+		return "read"
+	}
+}
+
+// Config configures the queues of a Manager
+type Config struct {
+	// MinSpacing is the minimum gap between the end of one action and the start of the next
+	MinSpacing time.Duration
+}
+
+// Stats describes the state of one account queue
+type Stats struct {
+	Account    string         `json:"account"`
+	Running    *ActionInfo    `json:"running,omitempty"`
+	Waiting    []ActionInfo   `json:"waiting"`
+	Depth      map[string]int `json:"depth"` // waiting actions per priority
+	Processed  int64          `json:"processed"`
+	MinSpacing string         `json:"min_spacing"`
+}
+
+// ActionInfo describes a queued or running action
+type ActionInfo struct {
+	Name     string    `json:"name"`
+	Priority string    `json:"priority"`
+	Since    time.Time `json:"since"` // enqueue time for waiting actions, start time for the running one
+}
+
+// waiter is an action waiting for its turn
+type waiter struct {
+	name     string
+	priority Priority
+	seq      uint64
+	enqueued time.Time
+	ready    chan struct{}
+	index    int
+}
+
+// waiters is a max-heap by priority, FIFO within the same priority
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+func (w waiters) Less(i, j int) bool {
+	if w[i].priority != w[j].priority {
+		return w[i].priority > w[j].priority
+	}
+	return w[i].seq < w[j].seq
+}
+func (w waiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+func (w *waiters) Push(x any) {
+	item := x.(*waiter)
+	item.index = len(*w)
+	*w = append(*w, item)
+}
+func (w *waiters) Pop() any {
+	old := *w
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*w = old[:len(old)-1]
+	return item
+}
+
+// Queue runs the actions of one account one at a time
+type Queue struct {
+	account    string
+	minSpacing time.Duration
+
+	mu        sync.Mutex
+	waiting   waiters
+	seq       uint64
+	running   *ActionInfo
+	lastDone  time.Time
+	timer     *time.Timer
+	processed int64
+}
+
+// NewQueue creates a queue for an account
+func NewQueue(account string, cfg Config) *Queue {
+	return &Queue{account: account, minSpacing: cfg.MinSpacing}
+}
+
+// Acquire waits until the action may run and returns a function that must be called
+// when the action is done. It returns ctx.Err() if ctx is done before the action's turn.
+func (q *Queue) Acquire(ctx context.Context, priority Priority, name string) (func(), error) {
+	w := &waiter{
+		name:     name,
+		priority: priority,
+		enqueued: time.Now(),
+		ready:    make(chan struct{}),
+	}
+
+	q.mu.Lock()
+	q.seq++
+	w.seq = q.seq
+	heap.Push(&q.waiting, w)
+	q.dispatchLocked()
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		q.mu.Lock()
+		select {
+		case <-w.ready:
+			// Dispatched while being cancelled, give the turn to the next action
+			q.mu.Unlock()
+			q.done()
+		default:
+			heap.Remove(&q.waiting, w.index)
+			q.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() { once.Do(q.done) }, nil
+}
+
+// Do runs fn when it is the action's turn
+func (q *Queue) Do(ctx context.Context, priority Priority, name string, fn func(ctx context.Context) error) error {
+	release, err := q.Acquire(ctx, priority, name)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+// done marks the running action as finished and schedules the next one
+func (q *Queue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running = nil
+	q.lastDone = time.Now()
+	q.processed++
+	q.dispatchLocked()
+}
+
+// dispatchLocked starts the next waiting action once the spacing has elapsed
+func (q *Queue) dispatchLocked() {
+	if q.running != nil || q.timer != nil || len(q.waiting) == 0 {
+		return
+	}
+
+	if wait := q.minSpacing - time.Since(q.lastDone); !q.lastDone.IsZero() && wait > 0 {
+		q.timer = time.AfterFunc(wait, func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.timer = nil
+			q.dispatchLocked()
+		})
+		return
+	}
+
+	w := heap.Pop(&q.waiting).(*waiter)
+	q.running = &ActionInfo{Name: w.name, Priority: w.priority.String(), Since: time.Now()}
+	close(w.ready)
+}
+
+// Stats returns the current state of the queue
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := Stats{
+		Account:    q.account,
+		Waiting:    make([]ActionInfo, 0, len(q.waiting)),
+		Depth:      map[string]int{},
+		Processed:  q.processed,
+		MinSpacing: q.minSpacing.String(),
+	}
+	if q.running != nil {
+		running := *q.running
+		stats.Running = &running
+	}
+
+	ordered := make(waiters, len(q.waiting))
+	copy(ordered, q.waiting)
+	sort.Slice(ordered, func(i, j int) bool { return ordered.Less(i, j) })
+	for _, w := range ordered {
+		stats.Waiting = append(stats.Waiting, ActionInfo{Name: w.name, Priority: w.priority.String(), Since: w.enqueued})
+		stats.Depth[w.priority.String()]++
+	}
+	return stats
+}
+
+// Manager holds one queue per account
+type Manager struct {
+	cfg Config
+
+	mu     sync.Mutex
+	queues map[string]*Queue
+}
+
+// NewManager creates a manager whose queues share cfg
+func NewManager(cfg Config) *Manager {
+	return &Manager{cfg: cfg, queues: map[string]*Queue{}}
+}
+
+// Queue returns the queue of an account, creating it on first use
+func (m *Manager) Queue(account string) *Queue {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.queues[account]
+	if !ok {
+		q = NewQueue(account, m.cfg)
+		m.queues[account] = q
+	}
+	return q
+}
+
+// Stats returns the state of every account queue, sorted by account
+func (m *Manager) Stats() []Stats {
+	m.mu.Lock()
+	queues := make([]*Queue, 0, len(m.queues))
+	for _, q := range m.queues {
+		queues = append(queues, q)
+	}
+	m.mu.Unlock()
+
+	stats := make([]Stats, 0, len(queues))
+	for _, q := range queues {
+		stats = append(stats, q.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Account < stats[j].Account })
+	return stats
+}
@@ -0,0 +1,109 @@
+package actionqueue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueRunsByPriority(t *testing.T) {
+	q := NewQueue("default", Config{})
+
+	// Hold the queue so the following actions pile up
+	release, err := q.Acquire(context.Background(), PriorityRead, "hold")
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(p Priority, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = q.Do(context.Background(), p, name, func(ctx context.Context) error {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return nil
+			})
+		}()
+		// Keep the enqueue order deterministic
+		require.Eventually(t, func() bool {
+			for _, w := range q.Stats().Waiting {
+				if w.Name == name {
+					return true
+				}
+			}
+			return false
+		}, time.Second, time.Millisecond)
+	}
+
+	enqueue(PriorityRead, "search")
+	enqueue(PriorityInteraction, "like")
+	enqueue(PriorityPublish, "publish")
+	enqueue(PriorityRead, "detail")
+
+	stats := q.Stats()
+	assert.Equal(t, "hold", stats.Running.Name)
+	assert.Equal(t, map[string]int{"read": 2, "interaction": 1, "publish": 1}, stats.Depth)
+	assert.Equal(t, "publish", stats.Waiting[0].Name)
+
+	release()
+	wg.Wait()
+
+	assert.Equal(t, []string{"publish", "like", "search", "detail"}, order)
+	assert.Equal(t, int64(5), q.Stats().Processed)
+}
+
+func TestQueueMinSpacing(t *testing.T) {
+	q := NewQueue("default", Config{MinSpacing: 50 * time.Millisecond})
+
+	var starts []time.Time
+	for i := 0; i < 3; i++ {
+		err := q.Do(context.Background(), PriorityRead, "read", func(ctx context.Context) error {
+			starts = append(starts, time.Now())
+			return nil
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i < len(starts); i++ {
+		assert.GreaterOrEqual(t, starts[i].Sub(starts[i-1]), 50*time.Millisecond)
+	}
+}
+
+func TestQueueCancelWhileWaiting(t *testing.T) {
+	q := NewQueue("default", Config{})
+
+	release, err := q.Acquire(context.Background(), PriorityRead, "hold")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = q.Acquire(ctx, PriorityPublish, "publish")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, q.Stats().Waiting)
+
+	release()
+	release() // releasing twice is a no-op
+
+	next, err := q.Acquire(context.Background(), PriorityRead, "next")
+	require.NoError(t, err)
+	next()
+}
+
+func TestManagerQueuePerAccount(t *testing.T) {
+	m := NewManager(Config{MinSpacing: time.Second})
+
+	assert.Same(t, m.Queue("a"), m.Queue("a"))
+	assert.NotSame(t, m.Queue("a"), m.Queue("b"))
+
+	stats := m.Stats()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "a", stats[0].Account)
+	assert.Equal(t, "1s", stats[0].MinSpacing)
+}
@@ -0,0 +1,108 @@
+package diagnostics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const (
+	maxConsoleEntries = 200
+	maxConsoleText    = 2000
+)
+
+// ConsoleEntry is a console message, uncaught exception or browser log entry
+type ConsoleEntry struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Text   string    `json:"text"`
+	Source string    `json:"source,omitempty"`
+}
+
+// consoleLog keeps the most recent console entries of a page
+type consoleLog struct {
+	mu   sync.Mutex
+	list []ConsoleEntry
+}
+
+func (l *consoleLog) add(level, text, source string) {
+	if len(text) > maxConsoleText {
+		text = strings.ToValidUTF8(text[:maxConsoleText], "") + "..."
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.list = append(l.list, ConsoleEntry{Time: time.Now(), Level: level, Text: text, Source: source})
+	if len(l.list) > maxConsoleEntries {
+		l.list = l.list[len(l.list)-maxConsoleEntries:]
+	}
+}
+
+func (l *consoleLog) entries() []ConsoleEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ConsoleEntry(nil), l.list...)
+}
+
+// Watch records the console output of page until the returned stop function is
+// called, so a later Capture of the same page includes it. It does nothing when
+// console recording is disabled.
+func (s *Store) Watch(page *rod.Page) (stop func()) {
+	if !s.console {
+		return func() {}
+	}
+
+	log := &consoleLog{}
+	s.mu.Lock()
+	s.watchers[page.TargetID] = log
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := page.Context(ctx).EachEvent(
+		func(e *proto.RuntimeConsoleAPICalled) {
+			args := make([]string, 0, len(e.Args))
+			for _, arg := range e.Args {
+				args = append(args, remoteObjectText(arg))
+			}
+			log.add(string(e.Type), strings.Join(args, " "), "console")
+		},
+		func(e *proto.RuntimeExceptionThrown) {
+			text := e.ExceptionDetails.Text
+			if ex := e.ExceptionDetails.Exception; ex != nil && ex.Description != "" {
+				text += " " + ex.Description
+			}
+			log.add("error", text, "exception")
+		},
+		func(e *proto.LogEntryAdded) {
+			log.add(string(e.Entry.Level), e.Entry.Text, string(e.Entry.Source))
+		},
+	)
+	go wait()
+
+	return func() {
+		cancel()
+		s.mu.Lock()
+		if s.watchers[page.TargetID] == log {
+			delete(s.watchers, page.TargetID)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// remoteObjectText formats a console argument the way DevTools shows it in one line
+func remoteObjectText(obj *proto.RuntimeRemoteObject) string {
+	if obj == nil {
+		return ""
+	}
+	if !obj.Value.Nil() {
+		return obj.Value.String()
+	}
+	if obj.Description != "" {
+		return obj.Description
+	}
+	return string(obj.Type)
+}
@@ -0,0 +1,326 @@
+// Package diagnostics captures a screenshot, a trimmed DOM snapshot and the console
+// log of a browser page when an action on it fails, and keeps them on disk with
+// retention limits so production failures can be debugged after the fact.
+package diagnostics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxEntries is how many reports are kept by default
+	DefaultMaxEntries = 50
+	// DefaultMaxAge is how long reports are kept by default
+	DefaultMaxAge = 7 * 24 * time.Hour
+
+	// ScreenshotFile and DOMFile are the artefact names served next to a report
+	ScreenshotFile = "screenshot.png"
+	DOMFile        = "dom.html"
+
+	reportFile     = "report.json"
+	idTimeLayout   = "20060102T150405Z"
+	captureTimeout = 15 * time.Second
+	maxDOMBytes    = 512 << 10
+)
+
+// ErrNotFound is returned when a report or artefact does not exist
+var ErrNotFound = errors.New("diagnostics report not found")
+
+var idRe = regexp.MustCompile(`^[0-9TZ]+-[0-9a-f]{6}$`)
+
+// trimDOMScript returns the page HTML without scripts, styles and inline SVG
+const trimDOMScript = `() => {
+	const root = document.documentElement.cloneNode(true);
+	root.querySelectorAll('script, style, noscript, svg, link[rel="stylesheet"]').forEach(el => el.remove());
+	return root.outerHTML;
+}`
+
+// Meta describes the failed action
+type Meta struct {
+	Action  string `json:"action"`
+	Account string `json:"account,omitempty"`
+}
+
+// Report is the stored record of a failed action. Artefact fields hold file names
+// relative to the report and are empty when that part could not be captured.
+type Report struct {
+	ID string `json:"id"`
+	Meta
+	Error         string         `json:"error"`
+	URL           string         `json:"url,omitempty"`
+	Title         string         `json:"title,omitempty"`
+	Screenshot    string         `json:"screenshot,omitempty"`
+	DOM           string         `json:"dom,omitempty"`
+	DOMTruncated  bool           `json:"dom_truncated,omitempty"`
+	Console       []ConsoleEntry `json:"console,omitempty"`
+	CaptureErrors []string       `json:"capture_errors,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// Error wraps an action error with the ID of the report captured for it
+type Error struct {
+	ReportID string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v (diagnostics report %s)", e.Err, e.ReportID)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ReportID returns the ID of the report attached to err, or "" if there is none
+func ReportID(err error) string {
+	var diagErr *Error
+	if errors.As(err, &diagErr) {
+		return diagErr.ReportID
+	}
+	return ""
+}
+
+// Store saves reports under a directory, one subdirectory per report
+type Store struct {
+	dir        string
+	maxEntries int
+	maxAge     time.Duration
+	console    bool
+
+	mu       sync.Mutex
+	watchers map[proto.TargetTargetID]*consoleLog
+}
+
+// Option configures a Store
+type Option func(*Store)
+
+// WithMaxEntries sets how many reports are kept; older ones are removed first
+func WithMaxEntries(n int) Option {
+	return func(s *Store) {
+		s.maxEntries = n
+	}
+}
+
+// WithMaxAge sets how long reports are kept, zero keeps them regardless of age
+func WithMaxAge(d time.Duration) Option {
+	return func(s *Store) {
+		s.maxAge = d
+	}
+}
+
+// WithConsole enables or disables console recording (enabled by default). Recording
+// enables the CDP Runtime domain on watched pages, which some sites can detect.
+func WithConsole(enable bool) Option {
+	return func(s *Store) {
+		s.console = enable
+	}
+}
+
+// NewStore creates a store under dir and removes reports past the retention limits
+func NewStore(dir string, options ...Option) (*Store, error) {
+	s := &Store{
+		dir:        dir,
+		maxEntries: DefaultMaxEntries,
+		maxAge:     DefaultMaxAge,
+		console:    true,
+		watchers:   map[proto.TargetTargetID]*consoleLog{},
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+	if _, err := s.Cleanup(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Dir returns the directory reports are stored in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Capture records the state of page after cause and stores it as a new report.
+// Parts that cannot be captured are listed in the report's CaptureErrors; an error
+// is only returned when the report itself cannot be saved.
+func (s *Store) Capture(page *rod.Page, meta Meta, cause error) (*Report, error) {
+	report := &Report{
+		ID:        newID(),
+		Meta:      meta,
+		Error:     cause.Error(),
+		CreatedAt: time.Now(),
+	}
+	failed := func(part string, err error) {
+		report.CaptureErrors = append(report.CaptureErrors, fmt.Sprintf("%s: %v", part, err))
+	}
+
+	// The page is usually bound to the action's context, which may already be done.
+	// Timeout derives from the page's context, so detach it first and then set a fresh timeout.
+	pp := page.Context(context.Background()).Timeout(captureTimeout)
+
+	if info, err := pp.Info(); err != nil {
+		failed("page info", err)
+	} else {
+		report.URL = info.URL
+		report.Title = info.Title
+	}
+
+	screenshot, err := pp.Screenshot(false, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err != nil {
+		failed("screenshot", err)
+	}
+
+	var dom string
+	if res, err := pp.Eval(trimDOMScript); err != nil {
+		failed("dom", err)
+	} else {
+		dom = res.Value.String()
+		if len(dom) > maxDOMBytes {
+			dom = strings.ToValidUTF8(dom[:maxDOMBytes], "")
+			report.DOMTruncated = true
+		}
+	}
+
+	s.mu.Lock()
+	if log, ok := s.watchers[page.TargetID]; ok {
+		report.Console = log.entries()
+	}
+	s.mu.Unlock()
+
+	if err := s.save(report, screenshot, dom); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// save writes the report and its artefacts, then applies the retention limits
+func (s *Store) save(report *Report, screenshot []byte, dom string) error {
+	dir := filepath.Join(s.dir, report.ID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create diagnostics report: %w", err)
+	}
+
+	if len(screenshot) > 0 {
+		if err := os.WriteFile(filepath.Join(dir, ScreenshotFile), screenshot, 0600); err != nil {
+			return fmt.Errorf("failed to save screenshot: %w", err)
+		}
+		report.Screenshot = ScreenshotFile
+	}
+	if dom != "" {
+		if err := os.WriteFile(filepath.Join(dir, DOMFile), []byte(dom), 0600); err != nil {
+			return fmt.Errorf("failed to save dom snapshot: %w", err)
+		}
+		report.DOM = DOMFile
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, reportFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to save diagnostics report: %w", err)
+	}
+
+	if n, err := s.Cleanup(); err != nil {
+		logrus.Warnf("diagnostics cleanup failed: %v", err)
+	} else if n > 0 {
+		logrus.Debugf("diagnostics cleanup removed %d reports", n)
+	}
+	return nil
+}
+
+// Get loads a report by ID
+func (s *Store) Get(id string) (*Report, error) {
+	if !idRe.MatchString(id) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id, reportFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse diagnostics report %s: %w", id, err)
+	}
+	return &report, nil
+}
+
+// ArtefactPath returns the path of a report's screenshot or DOM snapshot
+func (s *Store) ArtefactPath(id, name string) (string, error) {
+	if !idRe.MatchString(id) || (name != ScreenshotFile && name != DOMFile) {
+		return "", ErrNotFound
+	}
+
+	path := filepath.Join(s.dir, id, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// Cleanup removes reports older than the max age and the oldest reports beyond the
+// max entry count. It returns the number of removed reports.
+func (s *Store) Cleanup() (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read diagnostics directory: %w", err)
+	}
+
+	// IDs start with the UTC capture time, so name order is age order
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() && idRe.MatchString(entry.Name()) {
+			ids = append(ids, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	cutoff := time.Now().Add(-s.maxAge)
+	removed := 0
+	for i, id := range ids {
+		expired := s.maxAge > 0 && idTime(id).Before(cutoff)
+		if i < s.maxEntries && !expired {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.dir, id)); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func newID() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return time.Now().UTC().Format(idTimeLayout) + "-" + hex.EncodeToString(b)
+}
+
+func idTime(id string) time.Time {
+	t, err := time.Parse(idTimeLayout, strings.SplitN(id, "-", 2)[0])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
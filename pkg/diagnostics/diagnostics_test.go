@@ -0,0 +1,71 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSaveAndGet(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	report := &Report{ID: newID(), Meta: Meta{Action: "search_feeds", Account: "default"}, Error: "boom", CreatedAt: time.Now()}
+	require.NoError(t, store.save(report, []byte("png"), "<html></html>"))
+
+	got, err := store.Get(report.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "search_feeds", got.Action)
+	assert.Equal(t, ScreenshotFile, got.Screenshot)
+	assert.Equal(t, DOMFile, got.DOM)
+
+	path, err := store.ArtefactPath(report.ID, DOMFile)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "<html></html>", string(data))
+
+	// IDs and artefact names are validated before touching the filesystem
+	_, err = store.Get("../" + report.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.ArtefactPath(report.ID, reportFile)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreRetention(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir, WithMaxEntries(2), WithMaxAge(time.Hour))
+	require.NoError(t, err)
+
+	// An expired report is removed regardless of the entry count
+	old := fmt.Sprintf("%s-aaaaaa", time.Now().Add(-2*time.Hour).UTC().Format(idTimeLayout))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, old), 0700))
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		report := &Report{ID: fmt.Sprintf("%s-%06x", time.Now().UTC().Format(idTimeLayout), i), CreatedAt: time.Now()}
+		require.NoError(t, store.save(report, nil, ""))
+		ids = append(ids, report.ID)
+	}
+
+	assert.NoDirExists(t, filepath.Join(dir, old))
+	assert.NoDirExists(t, filepath.Join(dir, ids[0]))
+	assert.DirExists(t, filepath.Join(dir, ids[1]))
+	assert.DirExists(t, filepath.Join(dir, ids[2]))
+}
+
+func TestReportID(t *testing.T) {
+	cause := errors.New("element not found")
+	err := fmt.Errorf("发布失败: %w", &Error{ReportID: "20260101T000000Z-abcdef", Err: cause})
+
+	assert.Equal(t, "20260101T000000Z-abcdef", ReportID(err))
+	assert.ErrorIs(t, err, cause)
+	assert.Contains(t, err.Error(), "diagnostics report 20260101T000000Z-abcdef")
+	assert.Empty(t, ReportID(cause))
+}
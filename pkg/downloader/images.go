@@ -1,78 +1,82 @@
 package downloader
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
+	"sync"
 
-	"github.com/h2non/filetype"
 	"github.com/pkg/errors"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
+)
+
+const (
+	// maxImageSize 单张图片的大小上限
+	maxImageSize = media.MaxImageSize
+
+	defaultConcurrency = 4
+)
+
+var (
+	errInvalidURL = errors.New("invalid image URL format")
+	errNotImage   = errors.New("downloaded file is not a valid image")
 )
 
 // ImageDownloader 图片下载器
+// 下载失败的重试由 media.Fetcher 负责（断点续传），这里不再重复重试
 type ImageDownloader struct {
-	savePath   string
-	httpClient *http.Client
+	savePath    string
+	fetcher     *media.Fetcher
+	concurrency int
 }
 
-// NewImageDownloader 创建图片下载器
+// NewImageDownloader 创建图片下载器，使用共享的媒体缓存
 func NewImageDownloader(savePath string) *ImageDownloader {
+	fetcher, err := media.Default()
+	if err != nil {
+		panic(fmt.Sprintf("failed to create media fetcher: %v", err))
+	}
+	return newImageDownloader(savePath, fetcher)
+}
+
+func newImageDownloader(savePath string, fetcher *media.Fetcher) *ImageDownloader {
 	// 确保保存目录存在
 	if err := os.MkdirAll(savePath, 0755); err != nil {
 		panic(fmt.Sprintf("failed to create save path: %v", err))
 	}
 
 	return &ImageDownloader{
-		savePath: savePath,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		savePath:    savePath,
+		fetcher:     fetcher,
+		concurrency: defaultConcurrency,
 	}
 }
 
-// DownloadImage 下载图片
+// DownloadImage 下载图片，ctx 结束时停止下载
 // 返回本地文件路径
-func (d *ImageDownloader) DownloadImage(imageURL string) (string, error) {
+func (d *ImageDownloader) DownloadImage(ctx context.Context, imageURL string) (string, error) {
 	// 验证URL格式
 	if !d.isValidImageURL(imageURL) {
-		return "", errors.New("invalid image URL format")
+		return "", errInvalidURL
 	}
 
-	// 下载图片数据
-	resp, err := d.httpClient.Get(imageURL)
+	// 通过共享的媒体缓存流式下载
+	file, err := d.fetcher.Fetch(ctx, imageURL, maxImageSize)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to download image")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("download failed with status: %d", resp.StatusCode)
-	}
-
-	// 读取图片数据
-	imageData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to read image data")
-	}
-
-	// 检测图片格式
-	kind, err := filetype.Match(imageData)
-	if err != nil {
-		return "", errors.Wrap(err, "failed to detect file type")
-	}
+	defer file.Release()
 
-	if !filetype.IsImage(imageData) {
-		return "", errors.New("downloaded file is not a valid image")
+	if !file.IsImage() {
+		return "", errNotImage
 	}
 
 	// 生成唯一文件名
-	fileName := d.generateFileName(imageURL, kind.Extension)
+	fileName := d.generateFileName(imageURL, file.Extension)
 	filePath := filepath.Join(d.savePath, fileName)
 
 	// 如果文件已存在，直接返回路径
@@ -80,26 +84,77 @@ func (d *ImageDownloader) DownloadImage(imageURL string) (string, error) {
 		return filePath, nil
 	}
 
-	// 保存到文件
-	if err := os.WriteFile(filePath, imageData, 0644); err != nil {
+	// 从缓存链接到保存目录
+	if err := file.LinkTo(filePath); err != nil {
 		return "", errors.Wrap(err, "failed to save image")
 	}
 
 	return filePath, nil
 }
 
-// DownloadImages 批量下载图片
-func (d *ImageDownloader) DownloadImages(imageURLs []string) ([]string, error) {
+// DownloadResult 单个图片的下载结果
+type DownloadResult struct {
+	URL  string `json:"url"`
+	Path string `json:"path,omitempty"`
+	Err  error  `json:"-"`
+}
+
+// DownloadAll 并发下载图片，结果顺序与输入一致，每个URL单独返回错误
+// 重复的URL只下载一次，避免并发写同一个文件；ctx 结束后未完成的图片返回 ctx 的错误
+func (d *ImageDownloader) DownloadAll(ctx context.Context, imageURLs []string) []DownloadResult {
+	var unique []string
+	seen := make(map[string]bool, len(imageURLs))
+	for _, imageURL := range imageURLs {
+		if !seen[imageURL] {
+			seen[imageURL] = true
+			unique = append(unique, imageURL)
+		}
+	}
+
+	downloaded := make([]DownloadResult, len(unique))
+	sem := make(chan struct{}, d.concurrency)
+	var wg sync.WaitGroup
+
+	for i, imageURL := range unique {
+		wg.Add(1)
+		go func(i int, imageURL string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				downloaded[i] = DownloadResult{URL: imageURL, Err: ctx.Err()}
+				return
+			}
+			defer func() { <-sem }()
+
+			path, err := d.DownloadImage(ctx, imageURL)
+			downloaded[i] = DownloadResult{URL: imageURL, Path: path, Err: err}
+		}(i, imageURL)
+	}
+	wg.Wait()
+
+	byURL := make(map[string]DownloadResult, len(downloaded))
+	for _, r := range downloaded {
+		byURL[r.URL] = r
+	}
+	results := make([]DownloadResult, len(imageURLs))
+	for i, imageURL := range imageURLs {
+		results[i] = byURL[imageURL]
+	}
+	return results
+}
+
+// DownloadImages 批量下载图片，返回成功下载的路径（保持输入顺序）
+func (d *ImageDownloader) DownloadImages(ctx context.Context, imageURLs []string) ([]string, error) {
 	var localPaths []string
 	var errs []error
 
-	for _, imageURL := range imageURLs {
-		localPath, err := d.DownloadImage(imageURL)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to download %s: %w", imageURL, err))
+	for _, result := range d.DownloadAll(ctx, imageURLs) {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to download %s: %w", result.URL, result.Err))
 			continue
 		}
-		localPaths = append(localPaths, localPath)
+		localPaths = append(localPaths, result.Path)
 	}
 
 	if len(errs) > 0 {
@@ -126,7 +181,8 @@ func (d *ImageDownloader) isValidImageURL(rawURL string) bool {
 	return parsedURL.Scheme != "" && parsedURL.Host != ""
 }
 
-// generateFileName 生成唯一的文件名
+// generateFileName 生成文件名
+// 同一URL总是生成相同的文件名，已下载的文件可以直接复用
 func (d *ImageDownloader) generateFileName(imageURL, extension string) string {
 	// 使用URL的SHA256哈希作为文件名，确保唯一性
 	hash := sha256.Sum256([]byte(imageURL))
@@ -135,14 +191,16 @@ func (d *ImageDownloader) generateFileName(imageURL, extension string) string {
 	// 取前16位哈希值作为文件名
 	shortHash := hashStr[:16]
 
-	// 添加时间戳确保更好的唯一性
-	timestamp := time.Now().Unix()
-
-	return fmt.Sprintf("img_%s_%d.%s", shortHash, timestamp, extension)
+	return fmt.Sprintf("img_%s.%s", shortHash, extension)
 }
 
 // IsImageURL 判断字符串是否为图片URL
 func IsImageURL(path string) bool {
+	return IsHTTPURL(path)
+}
+
+// IsHTTPURL 判断字符串是否为 HTTP/HTTPS 链接，不关心链接指向的媒体类型
+func IsHTTPURL(path string) bool {
 	return strings.HasPrefix(strings.ToLower(path), "http://") ||
 		strings.HasPrefix(strings.ToLower(path), "https://")
 }
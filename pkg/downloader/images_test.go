@@ -1,10 +1,17 @@
 package downloader
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 )
 
 func TestIsImageURL(t *testing.T) {
@@ -30,6 +37,19 @@ func TestIsImageURL(t *testing.T) {
 	}
 }
 
+func TestIsHTTPURL(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"https://example.com/video.mp4": true,
+		"HTTP://example.com/watch?v=1":  true,
+		"/local/path/video.mp4":         false,
+		"media://abc.mp4":               false,
+	} {
+		if IsHTTPURL(input) != expected {
+			t.Errorf("IsHTTPURL(%q) = %v, expected %v", input, !expected, expected)
+		}
+	}
+}
+
 func TestNewImageDownloader(t *testing.T) {
 	tempDir := os.TempDir()
 	testPath := filepath.Join(tempDir, "test_downloader")
@@ -100,3 +120,83 @@ func TestImageDownloader_generateFileName(t *testing.T) {
 		t.Errorf("different URLs should generate different file names")
 	}
 }
+
+func TestImageDownloader_generateFileNameDeterministic(t *testing.T) {
+	downloader := newTestDownloader(t)
+
+	url := "https://example.com/image.jpg"
+	if downloader.generateFileName(url, "jpg") != downloader.generateFileName(url, "jpg") {
+		t.Errorf("same URL should generate the same file name")
+	}
+}
+
+func TestImageDownloader_DownloadAll(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0, 0, 0, 0x0d, 'I', 'H', 'D', 'R'}
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(append(png, []byte(r.URL.Path)...))
+	}))
+	defer srv.Close()
+
+	downloader := newTestDownloader(t)
+
+	urls := []string{srv.URL + "/a.png", srv.URL + "/missing.png", srv.URL + "/b.png", srv.URL + "/a.png"}
+	results := downloader.DownloadAll(context.Background(), urls)
+
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, expected %d", len(results), len(urls))
+	}
+	for i, result := range results {
+		if result.URL != urls[i] {
+			t.Errorf("result %d is for %s, expected %s", i, result.URL, urls[i])
+		}
+	}
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("unexpected errors: %v, %v", results[0].Err, results[2].Err)
+	}
+	if results[1].Err == nil {
+		t.Errorf("expected error for missing image")
+	}
+	if results[0].Path == results[2].Path {
+		t.Errorf("different images should be saved to different paths")
+	}
+	if results[3].Err != nil || results[3].Path != results[0].Path {
+		t.Errorf("duplicate URL should share the first result, got %+v", results[3])
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("got %d requests, duplicate URLs should be downloaded once", n)
+	}
+}
+
+// newTestDownloader 使用测试专用的媒体缓存，不影响全局缓存
+func newTestDownloader(t *testing.T) *ImageDownloader {
+	t.Helper()
+
+	fetcher, err := media.NewFetcher(filepath.Join(t.TempDir(), "media"))
+	if err != nil {
+		t.Fatalf("failed to create fetcher: %v", err)
+	}
+	return newImageDownloader(filepath.Join(t.TempDir(), "images"), fetcher)
+}
+
+func TestImageDownloader_DownloadAllCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request after cancel: %s", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := newTestDownloader(t).DownloadAll(ctx, []string{srv.URL + "/a.png", srv.URL + "/b.png"})
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", result.URL, result.Err)
+		}
+	}
+}
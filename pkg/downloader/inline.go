@@ -0,0 +1,40 @@
+package downloader
+
+import (
+	"os"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
+)
+
+// IsInlineMedia 判断输入是否为内联媒体：媒体句柄 (media://)、data URI 或 base64 字符串
+func IsInlineMedia(input string) bool {
+	if media.IsHandle(input) || media.IsDataURI(input) {
+		return true
+	}
+	if !media.LooksLikeBase64(input) {
+		return false
+	}
+	// 存在同名文件时按本地路径处理
+	_, err := os.Stat(input)
+	return err != nil
+}
+
+// ResolveInline 将内联媒体保存到服务端并返回上传信息
+// 输入不是内联媒体（URL 或本地路径）时 ok 为 false
+func ResolveInline(input string, maxSize int64) (upload *media.Upload, ok bool, err error) {
+	if !IsInlineMedia(input) {
+		return nil, false, nil
+	}
+
+	uploads, err := media.DefaultUploads()
+	if err != nil {
+		return nil, true, err
+	}
+
+	if media.IsHandle(input) {
+		upload, err = uploads.Resolve(input)
+	} else {
+		upload, err = uploads.SaveBase64(input, maxSize)
+	}
+	return upload, true, err
+}
@@ -1,53 +1,134 @@
 package downloader
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/sirupsen/logrus"
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
 )
 
 // ImageProcessor 图片处理器
 type ImageProcessor struct {
 	downloader *ImageDownloader
+	bestEffort bool
+}
+
+// ProcessorOption 图片处理器选项
+type ProcessorOption func(*ImageProcessor)
+
+// WithBestEffort 部分图片下载失败时，仍使用下载成功的图片继续发布
+func WithBestEffort(bestEffort bool) ProcessorOption {
+	return func(p *ImageProcessor) {
+		p.bestEffort = bestEffort
+	}
 }
 
 // NewImageProcessor 创建图片处理器
-func NewImageProcessor() *ImageProcessor {
-	return &ImageProcessor{
+func NewImageProcessor(options ...ProcessorOption) *ImageProcessor {
+	p := &ImageProcessor{
 		downloader: NewImageDownloader(configs.GetImagesPath()),
 	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// ProcessResult 图片处理结果
+type ProcessResult struct {
+	Paths  []string         // 本地文件路径，与输入顺序一致（跳过失败的图片）
+	Failed []DownloadResult // 下载失败的图片
 }
 
 // ProcessImages 处理图片列表，返回本地文件路径
-// 支持两种输入格式：
+// 支持以下输入格式：
 // 1. URL格式 (http/https开头) - 自动下载到本地
-// 2. 本地文件路径 - 直接使用
-func (p *ImageProcessor) ProcessImages(images []string) ([]string, error) {
-	var localPaths []string
-	var urlsToDownload []string
+// 2. 媒体句柄 (media://，上传接口返回)、data URI 或 base64 - 保存到服务端
+// 3. 本地文件路径 - 直接使用
+func (p *ImageProcessor) ProcessImages(ctx context.Context, images []string) ([]string, error) {
+	result, err := p.Process(ctx, images)
+	if err != nil {
+		return nil, err
+	}
+	return result.Paths, nil
+}
 
-	// 分离URL和本地路径
+// Process 处理图片列表，返回每张图片的处理结果
+// 默认任意图片下载失败即返回错误；best-effort 模式下只要有图片成功就继续
+// ctx 结束时停止下载并返回 ctx 的错误
+func (p *ImageProcessor) Process(ctx context.Context, images []string) (*ProcessResult, error) {
+	// 找出需要下载的URL
+	var urlsToDownload []string
 	for _, image := range images {
 		if IsImageURL(image) {
 			urlsToDownload = append(urlsToDownload, image)
-		} else {
+		}
+	}
+
+	// 并发下载URL图片
+	downloaded := make(map[string]DownloadResult, len(urlsToDownload))
+	for _, r := range p.downloader.DownloadAll(ctx, urlsToDownload) {
+		downloaded[r.URL] = r
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 按输入顺序组装结果
+	result := &ProcessResult{}
+	for _, image := range images {
+		if upload, ok, err := ResolveInline(image, maxImageSize); ok {
+			// 媒体句柄、data URI 或 base64 图片
+			if err == nil && !upload.IsImage() {
+				err = errNotImage
+			}
+			if err != nil {
+				result.Failed = append(result.Failed, DownloadResult{URL: inlineLabel(image), Err: err})
+				continue
+			}
+			result.Paths = append(result.Paths, upload.Path)
+			continue
+		}
+
+		if !IsImageURL(image) {
 			// 本地路径直接添加
-			localPaths = append(localPaths, image)
+			result.Paths = append(result.Paths, image)
+			continue
 		}
+
+		r := downloaded[image]
+		if r.Err != nil {
+			result.Failed = append(result.Failed, r)
+			continue
+		}
+		result.Paths = append(result.Paths, r.Path)
 	}
 
-	// 批量下载URL图片
-	if len(urlsToDownload) > 0 {
-		downloadedPaths, err := p.downloader.DownloadImages(urlsToDownload)
-		if err != nil {
-			return nil, fmt.Errorf("failed to download images: %w", err)
+	if len(result.Failed) > 0 {
+		msgs := make([]string, 0, len(result.Failed))
+		for _, f := range result.Failed {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", f.URL, f.Err))
+		}
+
+		if !p.bestEffort {
+			return nil, fmt.Errorf("failed to download images: %s", strings.Join(msgs, "; "))
 		}
-		localPaths = append(localPaths, downloadedPaths...)
+		logrus.Warnf("部分图片下载失败，继续使用 %d 张成功的图片: %s", len(result.Paths), strings.Join(msgs, "; "))
 	}
 
-	if len(localPaths) == 0 {
+	if len(result.Paths) == 0 {
 		return nil, fmt.Errorf("no valid images found")
 	}
 
-	return localPaths, nil
+	return result, nil
+}
+
+// inlineLabel 生成内联图片在错误信息中的简短标识，避免输出整段 base64
+func inlineLabel(input string) string {
+	if len(input) > 48 {
+		return input[:48] + "..."
+	}
+	return input
 }
@@ -0,0 +1,74 @@
+package imaging
+
+import "github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+
+// Format is an image encoding
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatGIF  Format = "gif"
+	FormatWebP Format = "webp"
+)
+
+// FitMode decides how an image outside the allowed aspect ratios is corrected
+type FitMode string
+
+const (
+	// FitPad letterboxes the image onto a background so nothing is lost
+	FitPad FitMode = "pad"
+	// FitCrop center-crops the image to the nearest allowed ratio
+	FitCrop FitMode = "crop"
+)
+
+// Capabilities declares what images a platform accepts
+type Capabilities struct {
+	Formats   []Format // accepted formats, the first one is used when converting
+	MinAspect float64  // minimum width/height ratio, 0 means no limit
+	MaxAspect float64  // maximum width/height ratio, 0 means no limit
+	MaxWidth  int      // 0 means no limit
+	MaxHeight int      // 0 means no limit
+	MaxBytes  int64    // 0 means no limit
+	Fit       FitMode
+}
+
+// Accepts reports whether format is accepted as-is
+func (c Capabilities) Accepts(format Format) bool {
+	for _, f := range c.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// PlatformCapabilities declares the image requirements of each platform
+var PlatformCapabilities = map[types.Platform]Capabilities{
+	// Twitter: JPEG/PNG/GIF/WebP up to 5 MB, very wide or tall images are cropped in the timeline
+	types.PlatformTwitter: {
+		Formats:   []Format{FormatJPEG, FormatPNG, FormatGIF, FormatWebP},
+		MinAspect: 1.0 / 3.0,
+		MaxAspect: 3.0,
+		MaxWidth:  4096,
+		MaxHeight: 4096,
+		MaxBytes:  5 << 20,
+		Fit:       FitPad,
+	},
+	// Facebook: JPEG/PNG/GIF up to 4 MB for page photos, feed shows 4:5 to 1.91:1 without cropping
+	types.PlatformFacebook: {
+		Formats:   []Format{FormatJPEG, FormatPNG, FormatGIF},
+		MinAspect: 4.0 / 5.0,
+		MaxAspect: 1.91,
+		MaxWidth:  2048,
+		MaxHeight: 2048,
+		MaxBytes:  4 << 20,
+		Fit:       FitPad,
+	},
+}
+
+// CapabilitiesFor returns the image capabilities of a platform
+func CapabilitiesFor(platform types.Platform) (Capabilities, bool) {
+	caps, ok := PlatformCapabilities[platform]
+	return caps, ok
+}
@@ -0,0 +1,18 @@
+package imaging
+
+import (
+	"image"
+
+	"github.com/gen2brain/heic"
+)
+
+// FormatHEIC is the name HEIC/HEIF images are decoded as. They are never uploaded
+// as-is: every platform gets them converted to its preferred format.
+const FormatHEIC Format = "heic"
+
+// The decoder only registers the "heic" brand; cameras also write these
+func init() {
+	for _, brand := range []string{"heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1"} {
+		image.RegisterFormat(string(FormatHEIC), "????ftyp"+brand, heic.Decode, heic.DecodeConfig)
+	}
+}
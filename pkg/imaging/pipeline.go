@@ -0,0 +1,132 @@
+package imaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+)
+
+// Pipeline downloads the images of processed content and transforms them to meet the
+// target platform's capabilities. It runs between the processor and the publishers.
+type Pipeline struct {
+	fetcher     *media.Fetcher
+	transformer *Transformer
+}
+
+// NewPipeline creates an image pipeline
+func NewPipeline(fetcher *media.Fetcher, transformer *Transformer) *Pipeline {
+	return &Pipeline{
+		fetcher:     fetcher,
+		transformer: transformer,
+	}
+}
+
+// Prepare fills content.MediaFiles with local images that satisfy the platform's
+// requirements. Content without images, or for platforms without declared image
+// capabilities, is left untouched. The files are kept out of the cache cleanup until
+// release is called, which the caller does once the content has been published.
+func (p *Pipeline) Prepare(ctx context.Context, content *types.ProcessedContent) (release func(), err error) {
+	release = func() {}
+	if content.Type != types.ContentTypeImage || len(content.MediaURLs) == 0 {
+		return release, nil
+	}
+
+	caps, ok := CapabilitiesFor(content.Platform)
+	if !ok {
+		return release, nil
+	}
+
+	var releases []func()
+	release = func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+
+	files := make([]string, 0, len(content.MediaURLs))
+	for i, imageURL := range content.MediaURLs {
+		candidates := []string{imageURL}
+		if i < len(content.MediaFallbackURLs) {
+			candidates = append(candidates, content.MediaFallbackURLs[i]...)
+		}
+
+		path, usedURL, releaseImage, err := p.prepareImage(ctx, candidates, caps, content.Platform)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		releases = append(releases, releaseImage)
+		content.MediaURLs[i] = usedURL
+		files = append(files, path)
+	}
+
+	content.MediaFiles = files
+	return release, nil
+}
+
+// prepareImage downloads and transforms the first candidate URL that works, moving on
+// to the next one when a download fails or the image cannot be decoded. When no
+// candidate can be decoded, the first downloaded original is uploaded as-is and the
+// platform decides.
+func (p *Pipeline) prepareImage(ctx context.Context, candidates []string, caps Capabilities, platform types.Platform) (path, usedURL string, release func(), err error) {
+	var original *media.File
+	var originalURL string
+	var lastErr error
+
+	for _, imageURL := range candidates {
+		file, err := p.fetcher.Fetch(ctx, imageURL, 0)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			logrus.Warnf("Failed to download image %s for %s: %v", imageURL, platform, err)
+			lastErr = fmt.Errorf("failed to download image %s: %w", imageURL, err)
+			continue
+		}
+
+		path, err := p.transformer.Transform(file.Path, caps)
+		if errors.Is(err, ErrUnsupportedFormat) {
+			logrus.Warnf("Cannot decode image %s for %s: %v", imageURL, platform, err)
+			if original == nil {
+				original, originalURL = file, imageURL
+			} else {
+				file.Release()
+			}
+			continue
+		}
+		if err != nil {
+			file.Release()
+			if original != nil {
+				original.Release()
+			}
+			return "", "", nil, fmt.Errorf("failed to transform image %s for %s: %w", imageURL, platform, err)
+		}
+
+		if original != nil {
+			original.Release()
+		}
+		release := file.Release
+		if path != file.Path {
+			logrus.Infof("Transformed image %s for %s", imageURL, platform)
+			holdTransformed := p.fetcher.Hold(path)
+			release = func() {
+				file.Release()
+				holdTransformed()
+			}
+		}
+		return path, imageURL, release, nil
+	}
+
+	if original != nil {
+		logrus.Warnf("Uploading original image %s to %s without transformation", originalURL, platform)
+		return original.Path, originalURL, original.Release, nil
+	}
+	if ctx.Err() != nil {
+		return "", "", nil, ctx.Err()
+	}
+	return "", "", nil, lastErr
+}
@@ -0,0 +1,85 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+)
+
+func TestPipelineKeepsUndecodableOriginal(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("an image in a format nobody decodes"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	fetcher, err := media.NewFetcher(dir)
+	require.NoError(t, err)
+	transformer, err := NewTransformer(filepath.Join(dir, "transformed"))
+	require.NoError(t, err)
+
+	content := &types.ProcessedContent{
+		Platform:  types.PlatformTwitter,
+		Type:      types.ContentTypeImage,
+		MediaURLs: []string{srv.URL + "/image.avif"},
+	}
+	release, err := NewPipeline(fetcher, transformer).Prepare(context.Background(), content)
+	require.NoError(t, err)
+	defer release()
+
+	require.Len(t, content.MediaFiles, 1)
+	data, err := os.ReadFile(content.MediaFiles[0])
+	require.NoError(t, err)
+	assert.Equal(t, "an image in a format nobody decodes", string(data))
+}
+
+func TestPipelineFallsBackToCapturedURL(t *testing.T) {
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 400, 400))))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			http.Error(w, "forbidden", http.StatusForbidden)
+		case "/html":
+			w.Write([]byte("<html>login required</html>"))
+		default:
+			w.Write(append(pngData.Bytes(), r.URL.Path...))
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	fetcher, err := media.NewFetcher(dir)
+	require.NoError(t, err)
+	transformer, err := NewTransformer(filepath.Join(dir, "transformed"))
+	require.NoError(t, err)
+
+	content := &types.ProcessedContent{
+		Platform:          types.PlatformTwitter,
+		Type:              types.ContentTypeImage,
+		MediaURLs:         []string{srv.URL + "/forbidden", srv.URL + "/html"},
+		MediaFallbackURLs: [][]string{{srv.URL + "/dft1"}, {srv.URL + "/dft2"}},
+	}
+	release, err := NewPipeline(fetcher, transformer).Prepare(context.Background(), content)
+	require.NoError(t, err)
+	defer release()
+
+	assert.Equal(t, []string{srv.URL + "/dft1", srv.URL + "/dft2"}, content.MediaURLs)
+	require.Len(t, content.MediaFiles, 2)
+	for _, path := range content.MediaFiles {
+		_, format := decodeConfig(t, path)
+		assert.Equal(t, "png", format)
+	}
+}
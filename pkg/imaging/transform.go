@@ -0,0 +1,302 @@
+// Package imaging converts, resizes, pads or crops and recompresses images so they
+// meet each platform's requirements. It only uses pure-Go codecs so it runs anywhere;
+// HEIC/HEIF is decoded by libheif compiled to WebAssembly.
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	startQuality = 90
+	minQuality   = 50
+	qualityStep  = 10
+	scaleStep    = 0.85
+
+	// MaxPixels is the largest image Transform decodes, decoding allocates about 4
+	// bytes per pixel so a tiny file declaring huge dimensions could exhaust memory
+	MaxPixels = 100_000_000
+)
+
+// ErrUnsupportedFormat is returned when an image cannot be decoded. Callers should
+// upload the original file, the platform may still accept it.
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// ErrTooManyPixels is returned for images larger than MaxPixels
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// Transformer writes transformed images to a directory
+type Transformer struct {
+	dir string
+}
+
+// NewTransformer creates a transformer that writes its output under dir
+func NewTransformer(dir string) (*Transformer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create image output directory: %w", err)
+	}
+	return &Transformer{dir: dir}, nil
+}
+
+// Transform returns a path to an image that satisfies caps. When the source already
+// complies it is returned unchanged; otherwise a converted copy is written.
+func (t *Transformer) Transform(src string, caps Capabilities) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	format := Format(name)
+
+	if compliant(cfg, format, int64(len(data)), caps) {
+		return src, nil
+	}
+
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return "", fmt.Errorf("%w: %dx%d exceeds %d", ErrTooManyPixels, cfg.Width, cfg.Height, MaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%w: failed to decode %s: %v", ErrUnsupportedFormat, format, err)
+	}
+
+	target := targetFormat(format, caps)
+	img = fitAspect(img, caps)
+
+	out, err := encodeWithinLimits(img, target, caps)
+	if err != nil {
+		return "", err
+	}
+
+	dst := filepath.Join(t.dir, outputName(data, caps, target))
+	if err := writeFileAtomic(dst, out); err != nil {
+		return "", fmt.Errorf("failed to write transformed image: %w", err)
+	}
+
+	return dst, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it, so
+// concurrent publishes sharing the same output name never read a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// compliant reports whether an image can be used without transformation
+func compliant(cfg image.Config, format Format, size int64, caps Capabilities) bool {
+	if len(caps.Formats) > 0 && !caps.Accepts(format) {
+		return false
+	}
+	if caps.MaxBytes > 0 && size > caps.MaxBytes {
+		return false
+	}
+	if caps.MaxWidth > 0 && cfg.Width > caps.MaxWidth {
+		return false
+	}
+	if caps.MaxHeight > 0 && cfg.Height > caps.MaxHeight {
+		return false
+	}
+	return aspectOK(cfg.Width, cfg.Height, caps)
+}
+
+func aspectOK(width, height int, caps Capabilities) bool {
+	if width == 0 || height == 0 {
+		return false
+	}
+	ratio := float64(width) / float64(height)
+	if caps.MinAspect > 0 && ratio < caps.MinAspect-0.01 {
+		return false
+	}
+	if caps.MaxAspect > 0 && ratio > caps.MaxAspect+0.01 {
+		return false
+	}
+	return true
+}
+
+// targetFormat keeps the source format when accepted (except animated formats, which
+// lose their animation on re-encode anyway), otherwise uses the platform's preferred one
+func targetFormat(format Format, caps Capabilities) Format {
+	if (format == FormatJPEG || format == FormatPNG) && caps.Accepts(format) {
+		return format
+	}
+	if caps.Accepts(FormatJPEG) || len(caps.Formats) == 0 {
+		return FormatJPEG
+	}
+	return caps.Formats[0]
+}
+
+// fitAspect pads or crops img to the nearest allowed aspect ratio
+func fitAspect(img image.Image, caps Capabilities) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if aspectOK(w, h, caps) {
+		return img
+	}
+
+	ratio := float64(w) / float64(h)
+	want := caps.MinAspect
+	if caps.MaxAspect > 0 && ratio > caps.MaxAspect {
+		want = caps.MaxAspect
+	}
+
+	if caps.Fit == FitCrop {
+		cw, ch := w, h
+		if ratio > want {
+			cw = int(float64(h) * want)
+		} else {
+			ch = int(float64(w) / want)
+		}
+		x0 := b.Min.X + (w-cw)/2
+		y0 := b.Min.Y + (h-ch)/2
+		dst := image.NewRGBA(image.Rect(0, 0, cw, ch))
+		draw.Draw(dst, dst.Bounds(), img, image.Pt(x0, y0), draw.Src)
+		return dst
+	}
+
+	pw, ph := w, h
+	if ratio > want {
+		ph = int(float64(w) / want)
+	} else {
+		pw = int(float64(h) * want)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, pw, ph))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	offset := image.Pt((pw-w)/2, (ph-h)/2)
+	draw.Draw(dst, image.Rectangle{Min: offset, Max: offset.Add(b.Size())}, img, b.Min, draw.Over)
+	return dst
+}
+
+// encodeWithinLimits resizes to the maximum dimensions and lowers quality, then scale,
+// until the encoded image fits within caps.MaxBytes
+func encodeWithinLimits(img image.Image, format Format, caps Capabilities) ([]byte, error) {
+	img = resizeToFit(img, caps.MaxWidth, caps.MaxHeight)
+
+	for {
+		for quality := startQuality; quality >= minQuality; quality -= qualityStep {
+			out, err := encode(img, format, quality)
+			if err != nil {
+				return nil, err
+			}
+			if caps.MaxBytes <= 0 || int64(len(out)) <= caps.MaxBytes {
+				return out, nil
+			}
+			if format != FormatJPEG {
+				// Quality only affects JPEG, go straight to scaling
+				break
+			}
+		}
+
+		b := img.Bounds()
+		w, h := int(float64(b.Dx())*scaleStep), int(float64(b.Dy())*scaleStep)
+		if w < 1 || h < 1 {
+			return nil, fmt.Errorf("image cannot be compressed below %d bytes", caps.MaxBytes)
+		}
+		img = resize(img, w, h)
+	}
+}
+
+func encode(img image.Image, format Format, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case FormatPNG:
+		err = png.Encode(&buf, img)
+	case FormatGIF:
+		err = gif.Encode(&buf, img, nil)
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality})
+	default:
+		return nil, fmt.Errorf("encoding to %s is not supported", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten composites transparent images onto white before JPEG encoding
+func flatten(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+func resizeToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := 1.0
+	if maxWidth > 0 && w > maxWidth {
+		scale = float64(maxWidth) / float64(w)
+	}
+	if maxHeight > 0 && float64(h)*scale > float64(maxHeight) {
+		scale = float64(maxHeight) / float64(h)
+	}
+	if scale >= 1 {
+		return img
+	}
+	return resize(img, int(float64(w)*scale), int(float64(h)*scale))
+}
+
+func resize(img image.Image, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Over, nil)
+	return dst
+}
+
+// outputName derives a deterministic file name from the source content and capabilities
+func outputName(data []byte, caps Capabilities, format Format) string {
+	h := sha256.New()
+	h.Write(data)
+	fmt.Fprintf(h, "%v", caps)
+	ext := string(format)
+	if format == FormatJPEG {
+		ext = "jpg"
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32] + "." + ext
+}
@@ -0,0 +1,150 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePNG(t *testing.T, path string, width, height int, noisy bool) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 200, G: 80, B: 80, A: 255}
+			if noisy {
+				c = color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+}
+
+func decodeConfig(t *testing.T, path string) (image.Config, string) {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	return cfg, format
+}
+
+func TestTransformCompliantImageUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "ok.png")
+	writePNG(t, src, 400, 400, false)
+
+	tr, err := NewTransformer(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	got, err := tr.Transform(src, PlatformCapabilities["twitter"])
+	require.NoError(t, err)
+	assert.Equal(t, src, got)
+}
+
+func TestTransformPadsToAllowedAspect(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "wide.png")
+	writePNG(t, src, 400, 100, false)
+
+	tr, err := NewTransformer(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	caps := Capabilities{Formats: []Format{FormatJPEG}, MinAspect: 0.8, MaxAspect: 1.91, Fit: FitPad}
+	got, err := tr.Transform(src, caps)
+	require.NoError(t, err)
+
+	cfg, format := decodeConfig(t, got)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 400, cfg.Width)
+	assert.InDelta(t, 1.91, float64(cfg.Width)/float64(cfg.Height), 0.01)
+}
+
+func TestTransformCropsAndFitsSize(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "tall.png")
+	writePNG(t, src, 300, 900, true)
+
+	tr, err := NewTransformer(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	caps := Capabilities{Formats: []Format{FormatJPEG}, MinAspect: 0.8, MaxHeight: 500, MaxBytes: 40 << 10, Fit: FitCrop}
+	got, err := tr.Transform(src, caps)
+	require.NoError(t, err)
+
+	cfg, _ := decodeConfig(t, got)
+	assert.LessOrEqual(t, cfg.Height, 500)
+	assert.InDelta(t, 0.8, float64(cfg.Width)/float64(cfg.Height), 0.02)
+
+	info, err := os.Stat(got)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(40<<10))
+}
+
+func TestTransformConvertsHEIC(t *testing.T) {
+	tr, err := NewTransformer(t.TempDir())
+	require.NoError(t, err)
+
+	got, err := tr.Transform(filepath.Join("testdata", "sample.heic"), PlatformCapabilities["twitter"])
+	require.NoError(t, err)
+
+	_, format := decodeConfig(t, got)
+	assert.Equal(t, "jpeg", format)
+}
+
+func TestTransformUnsupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "image.bin")
+	require.NoError(t, os.WriteFile(src, []byte("not an image"), 0644))
+
+	tr, err := NewTransformer(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	_, err = tr.Transform(src, PlatformCapabilities["twitter"])
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestTransformRejectsTooManyPixels(t *testing.T) {
+	// A PNG header declaring 50000x50000 pixels without any image data
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 50000)
+	binary.BigEndian.PutUint32(ihdr[4:], 50000)
+	ihdr[8], ihdr[9] = 8, 6 // 8-bit RGBA
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a})
+	binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "huge.png")
+	require.NoError(t, os.WriteFile(src, buf.Bytes(), 0644))
+
+	tr, err := NewTransformer(filepath.Join(dir, "out"))
+	require.NoError(t, err)
+
+	_, err = tr.Transform(src, PlatformCapabilities["twitter"])
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+}
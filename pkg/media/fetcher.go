@@ -0,0 +1,731 @@
+// Package media streams remote media to disk and keeps it in a content-addressed cache
+// shared by the Xiaohongshu publisher and the cross-platform publishers.
+package media
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/h2non/filetype"
+	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+)
+
+const (
+	// DefaultMaxSize is the default download size limit
+	DefaultMaxSize = 1 << 30 // 1 GB
+	// DefaultMaxAge is how long cached files are kept by Cleanup
+	DefaultMaxAge = 24 * time.Hour
+	// DefaultIdleTimeout is how long a download may receive no data before the attempt
+	// is aborted and resumed
+	DefaultIdleTimeout = time.Minute
+
+	defaultAttempts       = 3
+	retryBackoff          = 500 * time.Millisecond
+	maxRetryAfter         = time.Minute
+	responseHeaderTimeout = 30 * time.Second
+	sniffLen              = 512
+
+	objectsDir = "objects"
+	indexDir   = "index"
+	partialDir = "partial"
+)
+
+// errStalled cancels a download attempt that received no data for the idle timeout
+var errStalled = errors.New("download stalled")
+
+// ErrTooLarge is returned when a download exceeds its size limit
+type ErrTooLarge struct {
+	URL   string
+	Limit int64
+}
+
+func (e *ErrTooLarge) Error() string {
+	return fmt.Sprintf("media %s exceeds size limit of %d bytes", e.URL, e.Limit)
+}
+
+// File is a downloaded media file in the cache
+type File struct {
+	Path        string `json:"path"`
+	SourceURL   string `json:"source_url"`
+	SHA256      string `json:"sha256"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	Extension   string `json:"extension"`
+
+	release func()
+}
+
+// Release tells the fetcher the caller no longer uses the file, so Cleanup may
+// remove it once it expires. It is safe to call more than once.
+func (f *File) Release() {
+	if f.release != nil {
+		f.release()
+	}
+}
+
+// IsImage reports whether the file was sniffed as an image
+func (f *File) IsImage() bool {
+	return strings.HasPrefix(f.ContentType, "image/")
+}
+
+// IsVideo reports whether the file was sniffed as a video
+func (f *File) IsVideo() bool {
+	return strings.HasPrefix(f.ContentType, "video/")
+}
+
+// Open opens the cached file for reading
+func (f *File) Open() (*os.File, error) {
+	return os.Open(f.Path)
+}
+
+// LinkTo makes the file available at path, hard-linking it when possible and
+// falling back to a streamed copy (e.g. across filesystems). The copy is written to a
+// temporary file and renamed, so readers of path never see a partial file.
+func (f *File) LinkTo(path string) error {
+	err := os.Link(f.Path, path)
+	if err == nil || errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := dst.Chmod(0644); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	if err := os.Rename(dst.Name(), path); err != nil {
+		os.Remove(dst.Name())
+		return err
+	}
+	return nil
+}
+
+// Fetcher downloads media by streaming it to disk. Downloads are resumed with range
+// requests after a failure, and files are stored by the SHA-256 of their content so the
+// same media fetched from different URLs is only kept once.
+type Fetcher struct {
+	dir         string
+	client      *http.Client
+	maxSize     int64
+	maxAge      time.Duration
+	idleTimeout time.Duration
+	attempts    int
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+	inUse map[string]int // reference count of paths held by callers
+}
+
+// keyLock serializes downloads of one URL and is dropped once nobody waits for it
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// Option configures a Fetcher
+type Option func(*Fetcher)
+
+// WithHTTPClient sets the HTTP client used for downloads
+func WithHTTPClient(client *http.Client) Option {
+	return func(f *Fetcher) {
+		f.client = client
+	}
+}
+
+// WithMaxSize sets the default download size limit
+func WithMaxSize(n int64) Option {
+	return func(f *Fetcher) {
+		f.maxSize = n
+	}
+}
+
+// WithMaxAge sets how long cached files are kept by Cleanup
+func WithMaxAge(d time.Duration) Option {
+	return func(f *Fetcher) {
+		f.maxAge = d
+	}
+}
+
+// WithIdleTimeout sets how long a download may receive no data before the attempt is
+// aborted and resumed
+func WithIdleTimeout(d time.Duration) Option {
+	return func(f *Fetcher) {
+		f.idleTimeout = d
+	}
+}
+
+// WithAttempts sets how many times a download is attempted (resuming each time). This
+// is the only retry layer: callers of Fetch should not retry on top of it.
+func WithAttempts(n int) Option {
+	return func(f *Fetcher) {
+		f.attempts = n
+	}
+}
+
+// NewFetcher creates a fetcher that caches files under dir
+func NewFetcher(dir string, options ...Option) (*Fetcher, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = responseHeaderTimeout
+
+	f := &Fetcher{
+		dir: dir,
+		client: &http.Client{
+			// No overall timeout: large videos may take a long time. Stalled connections
+			// are caught by the header timeout here and the idle timeout in download.
+			Transport: transport,
+		},
+		maxSize:     DefaultMaxSize,
+		maxAge:      DefaultMaxAge,
+		idleTimeout: DefaultIdleTimeout,
+		attempts:    defaultAttempts,
+		locks:       make(map[string]*keyLock),
+		inUse:       make(map[string]int),
+	}
+	for _, opt := range options {
+		opt(f)
+	}
+
+	for _, sub := range []string{objectsDir, indexDir, partialDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create media cache directory: %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+// Dir returns the cache directory
+func (f *Fetcher) Dir() string {
+	return f.dir
+}
+
+// Fetch downloads rawURL into the cache, or returns the cached file if it was already
+// downloaded. maxSize overrides the fetcher's size limit when greater than zero.
+// The file is held until the caller calls Release, Cleanup never removes it before.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, maxSize int64) (*File, error) {
+	if maxSize <= 0 {
+		maxSize = f.maxSize
+	}
+
+	key := hashString(rawURL)
+	unlock := f.lock(key)
+	defer unlock()
+
+	if file, ok := f.lookupAndHold(key); ok {
+		if file.Size > maxSize {
+			file.Release()
+			return nil, &ErrTooLarge{URL: rawURL, Limit: maxSize}
+		}
+		return file, nil
+	}
+
+	partPath := filepath.Join(f.dir, partialDir, key+".part")
+	releasePart := f.Hold(partPath)
+	defer releasePart()
+
+	var err error
+	var responseType string
+	for attempt := 1; attempt <= f.attempts; attempt++ {
+		var contentType string
+		contentType, err = f.download(ctx, rawURL, partPath, maxSize)
+		if contentType != "" {
+			responseType = contentType
+		}
+		if err == nil {
+			break
+		}
+
+		var tooLarge *ErrTooLarge
+		var permanent *permanentError
+		if ctx.Err() != nil || errors.As(err, &tooLarge) || errors.As(err, &permanent) {
+			os.Remove(partPath)
+			return nil, err
+		}
+
+		delay := time.Duration(attempt) * retryBackoff
+		var throttled *throttledError
+		if errors.As(err, &throttled) {
+			if throttled.retryAfter > maxRetryAfter {
+				return nil, fmt.Errorf("failed to download %s: %w (retry after %s)", rawURL, err, throttled.retryAfter)
+			}
+			delay = max(delay, throttled.retryAfter)
+		}
+		logrus.Warnf("media download attempt %d/%d failed for %s: %v", attempt, f.attempts, rawURL, err)
+
+		if attempt < f.attempts {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", rawURL, err)
+	}
+
+	return f.store(key, rawURL, partPath, responseType)
+}
+
+// download streams rawURL to partPath, resuming from the existing partial file if the
+// server supports range requests. It returns the Content-Type of the response.
+func (f *Fetcher) download(ctx context.Context, rawURL, partPath string, maxSize int64) (string, error) {
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	// Cancelled with errStalled when no data arrives for the idle timeout
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", &permanentError{err: err}
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		// Appending a range that does not start where the partial file ends corrupts it
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			os.Remove(partPath)
+			return contentType, fmt.Errorf("unexpected Content-Range %q for offset %d, restarting download", resp.Header.Get("Content-Range"), offset)
+		}
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range header, start over
+		offset = 0
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file is stale or already complete, start over
+		os.Remove(partPath)
+		return contentType, fmt.Errorf("range not satisfiable, restarting download")
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return contentType, &throttledError{
+			err:        fmt.Errorf("download failed with status: %d", resp.StatusCode),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return contentType, &permanentError{err: fmt.Errorf("download failed with status: %d", resp.StatusCode)}
+	default:
+		return contentType, fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > 0 && offset+resp.ContentLength > maxSize {
+		return contentType, &ErrTooLarge{URL: rawURL, Limit: maxSize}
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return contentType, &permanentError{err: fmt.Errorf("failed to create partial file: %w", err)}
+	}
+
+	idle := time.AfterFunc(f.idleTimeout, func() { cancel(errStalled) })
+	defer idle.Stop()
+	body := &idleReader{r: resp.Body, timer: idle, timeout: f.idleTimeout}
+
+	// Read one byte past the limit to detect oversized bodies without a Content-Length
+	n, copyErr := io.Copy(out, io.LimitReader(body, maxSize-offset+1))
+	if err := out.Close(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil && errors.Is(context.Cause(ctx), errStalled) {
+		copyErr = fmt.Errorf("%w: no data for %s", errStalled, f.idleTimeout)
+	}
+	if offset+n > maxSize {
+		return contentType, &ErrTooLarge{URL: rawURL, Limit: maxSize}
+	}
+	if copyErr != nil {
+		return contentType, fmt.Errorf("failed to read media data: %w", copyErr)
+	}
+	if resp.ContentLength > 0 && n != resp.ContentLength {
+		return contentType, fmt.Errorf("short read: got %d of %d bytes", n, resp.ContentLength)
+	}
+
+	return contentType, nil
+}
+
+// store hashes and sniffs the completed partial file and moves it into the object store.
+// responseType is used when sniffing cannot tell the content type.
+func (f *Fetcher) store(key, rawURL, partPath, responseType string) (*File, error) {
+	sum, size, head, err := hashFile(partPath)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, ext := sniff(head)
+	if ext == "bin" {
+		if mediaType, _, err := mime.ParseMediaType(responseType); err == nil && mediaType != "application/octet-stream" {
+			contentType = mediaType
+		}
+	}
+	objectPath := filepath.Join(f.dir, objectsDir, sum+"."+ext)
+
+	release := f.Hold(objectPath)
+	if _, err := os.Stat(objectPath); err == nil {
+		// Same content already cached from another URL
+		os.Remove(partPath)
+		touch(objectPath)
+	} else if err := os.Rename(partPath, objectPath); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to store media: %w", err)
+	}
+
+	file := &File{
+		Path:        objectPath,
+		SourceURL:   rawURL,
+		SHA256:      sum,
+		Size:        size,
+		ContentType: contentType,
+		Extension:   ext,
+		release:     release,
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(f.dir, indexDir, key+".json"), data, 0644); err != nil {
+		release()
+		return nil, fmt.Errorf("failed to write media index: %w", err)
+	}
+
+	return file, nil
+}
+
+// lookupAndHold returns the cached file for a URL key if both the index entry and
+// object exist. The object is held before Cleanup can see it expire.
+func (f *Fetcher) lookupAndHold(key string) (*File, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, ok := f.lookup(key)
+	if !ok {
+		return nil, false
+	}
+	f.inUse[file.Path]++
+	file.release = f.releaseFunc(file.Path)
+	return file, true
+}
+
+// lookup returns the cached file for a URL key if both the index entry and object exist
+func (f *Fetcher) lookup(key string) (*File, bool) {
+	indexPath := filepath.Join(f.dir, indexDir, key+".json")
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, false
+	}
+
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, false
+	}
+	if _, err := os.Stat(file.Path); err != nil {
+		os.Remove(indexPath)
+		return nil, false
+	}
+
+	touch(indexPath)
+	touch(file.Path)
+	return &file, true
+}
+
+// Cleanup removes cached objects, index entries and partial downloads that have not
+// been used for longer than the fetcher's max age. Files held by a caller are kept.
+// It returns the number of removed files.
+func (f *Fetcher) Cleanup() (int, error) {
+	cutoff := time.Now().Add(-f.maxAge)
+	removed := 0
+
+	// Index entries first so a removed object is never left referenced; other
+	// subdirectories (e.g. transformed images) are cleaned the same way
+	subdirs := []string{indexDir, objectsDir, partialDir}
+	if entries, err := os.ReadDir(f.dir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != indexDir && entry.Name() != objectsDir && entry.Name() != partialDir {
+				subdirs = append(subdirs, entry.Name())
+			}
+		}
+	}
+
+	for _, sub := range subdirs {
+		entries, err := os.ReadDir(filepath.Join(f.dir, sub))
+		if err != nil {
+			return removed, fmt.Errorf("failed to read media cache: %w", err)
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() || info.ModTime().After(cutoff) {
+				continue
+			}
+			if f.removeUnheld(filepath.Join(f.dir, sub, entry.Name())) {
+				removed++
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+// Hold marks paths in the cache as in use so that Cleanup keeps them, e.g. while a
+// publish uploads them. The returned function releases the hold; calling it more
+// than once has no further effect.
+func (f *Fetcher) Hold(paths ...string) (release func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	releases := make([]func(), 0, len(paths))
+	for _, path := range paths {
+		f.inUse[path]++
+		releases = append(releases, f.releaseFunc(path))
+	}
+	return func() {
+		for _, r := range releases {
+			r()
+		}
+	}
+}
+
+// releaseFunc returns a function that drops one hold on path. f.mu must be held.
+func (f *Fetcher) releaseFunc(path string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			if f.inUse[path]--; f.inUse[path] <= 0 {
+				delete(f.inUse, path)
+			}
+		})
+	}
+}
+
+// removeUnheld removes path unless a caller holds it
+func (f *Fetcher) removeUnheld(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.inUse[path] > 0 {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// lock serializes work on one URL key. The lock entry is removed once the last
+// waiter unlocks, so the map only holds keys with downloads in progress.
+func (f *Fetcher) lock(key string) (unlock func()) {
+	f.mu.Lock()
+	l, ok := f.locks[key]
+	if !ok {
+		l = &keyLock{}
+		f.locks[key] = l
+	}
+	l.refs++
+	f.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(f.locks, key)
+		}
+	}
+}
+
+// contentRangeStart parses the first byte position of a "bytes start-end/size" header
+func contentRangeStart(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// hashFile returns the SHA-256, size and leading bytes of a file
+func hashFile(path string) (string, int64, []byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to open downloaded media: %w", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", 0, nil, fmt.Errorf("failed to read downloaded media: %w", err)
+	}
+	head = head[:n]
+
+	hasher := sha256.New()
+	hasher.Write(head)
+	rest, err := io.Copy(hasher, file)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to hash downloaded media: %w", err)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), int64(n) + rest, head, nil
+}
+
+// sniff detects the content type and extension from the leading bytes of a file
+func sniff(head []byte) (contentType, ext string) {
+	if kind, err := filetype.Match(head); err == nil && kind != filetype.Unknown {
+		return kind.MIME.Value, kind.Extension
+	}
+
+	contentType = http.DetectContentType(head)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType, "bin"
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// idleReader pushes back the idle timer every time data arrives
+type idleReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// It returns zero when the header is missing or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// throttledError marks a rate-limited response, retried after the server's Retry-After
+type throttledError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *throttledError) Error() string { return e.err.Error() }
+func (e *throttledError) Unwrap() error { return e.err }
+
+// permanentError marks errors that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+var (
+	defaultOnce    sync.Once
+	defaultFetcher *Fetcher
+	defaultErr     error
+)
+
+// Default returns the process-wide fetcher, caching under configs.GetMediaPath()
+func Default() (*Fetcher, error) {
+	defaultOnce.Do(func() {
+		defaultFetcher, defaultErr = NewFetcher(configs.GetMediaPath())
+	})
+	return defaultFetcher, defaultErr
+}
+
+// StartCleanup runs Cleanup every interval until ctx is cancelled
+func (f *Fetcher) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := f.Cleanup(); err != nil {
+					logrus.Warnf("media cache cleanup failed: %v", err)
+				} else if n > 0 {
+					logrus.Infof("media cache cleanup removed %d files", n)
+				}
+			}
+		}
+	}()
+}
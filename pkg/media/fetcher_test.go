@@ -0,0 +1,230 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader makes the payload sniff as an image
+var pngHeader = []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+
+func testPayload() []byte {
+	return append(append([]byte{}, pngHeader...), bytes.Repeat([]byte("xiaohongshu"), 1000)...)
+}
+
+func TestFetcherCachesByContent(t *testing.T) {
+	payload := testPayload()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.ServeContent(w, r, "image", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewFetcher(t.TempDir())
+	require.NoError(t, err)
+
+	first, err := fetcher.Fetch(context.Background(), srv.URL+"/a.png", 0)
+	require.NoError(t, err)
+	assert.True(t, first.IsImage())
+	assert.Equal(t, "png", first.Extension)
+	assert.Equal(t, int64(len(payload)), first.Size)
+
+	again, err := fetcher.Fetch(context.Background(), srv.URL+"/a.png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, first.Path, again.Path)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// Same content from another URL shares the cached object
+	other, err := fetcher.Fetch(context.Background(), srv.URL+"/b.png", 0)
+	require.NoError(t, err)
+	assert.Equal(t, first.Path, other.Path)
+}
+
+func TestFetcherResumesPartialDownload(t *testing.T) {
+	payload := testPayload()
+	var ranged int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			atomic.AddInt32(&ranged, 1)
+		}
+		http.ServeContent(w, r, "image", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	fetcher, err := NewFetcher(dir)
+	require.NoError(t, err)
+
+	rawURL := srv.URL + "/partial.png"
+	partPath := filepath.Join(dir, partialDir, hashString(rawURL)+".part")
+	require.NoError(t, os.WriteFile(partPath, payload[:100], 0644))
+
+	file, err := fetcher.Fetch(context.Background(), rawURL, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ranged))
+
+	data, err := os.ReadFile(file.Path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+}
+
+func TestFetcherSizeLimit(t *testing.T) {
+	payload := testPayload()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewFetcher(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = fetcher.Fetch(context.Background(), srv.URL, 100)
+	var tooLarge *ErrTooLarge
+	assert.ErrorAs(t, err, &tooLarge)
+}
+
+func TestFetcherRestartsOnMismatchedRange(t *testing.T) {
+	payload := testPayload()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			// a broken server that answers every range request from the start
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(payload)-1, len(payload)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(payload)
+			return
+		}
+		w.Write(payload)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	fetcher, err := NewFetcher(dir)
+	require.NoError(t, err)
+
+	rawURL := srv.URL + "/partial.png"
+	partPath := filepath.Join(dir, partialDir, hashString(rawURL)+".part")
+	require.NoError(t, os.WriteFile(partPath, payload[:100], 0644))
+
+	file, err := fetcher.Fetch(context.Background(), rawURL, 0)
+	require.NoError(t, err)
+	data, err := os.ReadFile(file.Path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+}
+
+func TestFetcherCleanupKeepsHeldFiles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(append(testPayload(), r.URL.Path...))
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewFetcher(t.TempDir(), WithMaxAge(time.Nanosecond))
+	require.NoError(t, err)
+
+	held, err := fetcher.Fetch(context.Background(), srv.URL+"/held.png", 0)
+	require.NoError(t, err)
+	released, err := fetcher.Fetch(context.Background(), srv.URL+"/released.png", 0)
+	require.NoError(t, err)
+	released.Release()
+
+	time.Sleep(10 * time.Millisecond)
+	_, err = fetcher.Cleanup()
+	require.NoError(t, err)
+	assert.FileExists(t, held.Path)
+	assert.NoFileExists(t, released.Path)
+
+	held.Release()
+	held.Release()
+	_, err = fetcher.Cleanup()
+	require.NoError(t, err)
+	assert.NoFileExists(t, held.Path)
+
+	// per-URL locks are dropped once the downloads finish
+	assert.Empty(t, fetcher.locks)
+	assert.Empty(t, fetcher.inUse)
+}
+
+func TestFetcherContentTypeFromResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/webm; codecs=vp9")
+		w.Write(bytes.Repeat([]byte{0x01}, 64))
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewFetcher(t.TempDir())
+	require.NoError(t, err)
+
+	file, err := fetcher.Fetch(context.Background(), srv.URL, 0)
+	require.NoError(t, err)
+	defer file.Release()
+	assert.Equal(t, "video/webm", file.ContentType)
+}
+
+func TestFetcherResumesStalledDownload(t *testing.T) {
+	payload := testPayload()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") == "" {
+			// Send the first bytes, then hang until the client gives up
+			w.Header().Set("Content-Length", fmt.Sprint(len(payload)))
+			w.Write(payload[:100])
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		}
+		http.ServeContent(w, r, "image", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewFetcher(t.TempDir(), WithIdleTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+
+	file, err := fetcher.Fetch(context.Background(), srv.URL+"/stalled.png", 0)
+	require.NoError(t, err)
+	defer file.Release()
+
+	data, err := os.ReadFile(file.Path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+}
+
+func TestFetcherRetriesTooManyRequests(t *testing.T) {
+	payload := testPayload()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		http.ServeContent(w, r, "image", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer srv.Close()
+
+	fetcher, err := NewFetcher(t.TempDir())
+	require.NoError(t, err)
+
+	file, err := fetcher.Fetch(context.Background(), srv.URL+"/throttled.png", 0)
+	require.NoError(t, err)
+	defer file.Release()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+}
@@ -0,0 +1,316 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// VideoInfo describes a video file, read from its MP4/MOV headers
+type VideoInfo struct {
+	Container string        `json:"container"` // mp4 or mov
+	Codec     string        `json:"codec"`     // h264, h265, av1, vp9, mpeg4 or the raw sample entry type
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Duration  time.Duration `json:"duration"`
+	Size      int64         `json:"size"`
+}
+
+// ErrNotMP4 is returned when a file is not an MP4/MOV container
+var ErrNotMP4 = errors.New("not an MP4/MOV file")
+
+// codecNames maps sample entry types to codec names
+var codecNames = map[string]string{
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "h265",
+	"hev1": "h265",
+	"av01": "av1",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+}
+
+// containerBoxes are boxes whose payload is a list of child boxes
+var containerBoxes = map[string]bool{
+	"moov": true,
+	"trak": true,
+	"mdia": true,
+	"minf": true,
+	"stbl": true,
+}
+
+type trackInfo struct {
+	handler string
+	width   int
+	height  int
+	codec   string
+}
+
+type probeState struct {
+	info      VideoInfo
+	hasFtyp   bool
+	hasMoov   bool
+	timescale uint32
+	duration  uint64
+	tracks    []*trackInfo
+}
+
+// ProbeVideo reads the container, codec, resolution and duration of an MP4/MOV file
+// without decoding it. Only box headers are read, media data is skipped.
+func ProbeVideo(path string) (*VideoInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &probeState{}
+	if err := state.walk(file, 0, stat.Size(), 0); err != nil {
+		return nil, err
+	}
+	if !state.hasMoov {
+		if !state.hasFtyp {
+			return nil, ErrNotMP4
+		}
+		return nil, fmt.Errorf("video has no moov box, the file may be truncated")
+	}
+
+	info := state.info
+	info.Size = stat.Size()
+	if info.Container == "" {
+		info.Container = "mov"
+	}
+	if state.timescale > 0 {
+		info.Duration = time.Duration(float64(state.duration) / float64(state.timescale) * float64(time.Second))
+	}
+
+	for _, track := range state.tracks {
+		if track.handler == "vide" {
+			info.Width, info.Height = track.width, track.height
+			info.Codec = track.codec
+			if name, ok := codecNames[track.codec]; ok {
+				info.Codec = name
+			}
+			break
+		}
+	}
+	if info.Codec == "" {
+		return nil, fmt.Errorf("video has no video track")
+	}
+
+	return &info, nil
+}
+
+// walk parses the boxes in [start, end)
+func (s *probeState) walk(r io.ReadSeeker, start, end int64, depth int) error {
+	if depth > 8 {
+		return fmt.Errorf("MP4 boxes nested too deeply")
+	}
+
+	offset := start
+	for offset+8 <= end {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerLen := int64(8)
+
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			var large [8]byte
+			if _, err := io.ReadFull(r, large[:]); err != nil {
+				return err
+			}
+			size = int64(binary.BigEndian.Uint64(large[:]))
+			headerLen = 16
+		}
+		if size < headerLen || offset+size > end {
+			if depth == 0 && offset == 0 {
+				return ErrNotMP4
+			}
+			return fmt.Errorf("invalid MP4 box %q at offset %d", boxType, offset)
+		}
+
+		// The first box of an MP4/MOV file is ftyp, or one of the classic QuickTime atoms
+		if depth == 0 && offset == 0 && !isTopLevelBox(boxType) {
+			return ErrNotMP4
+		}
+
+		payload := size - headerLen
+		if err := s.box(r, boxType, offset+headerLen, payload, depth); err != nil {
+			return err
+		}
+		offset += size
+	}
+
+	return nil
+}
+
+func (s *probeState) box(r io.ReadSeeker, boxType string, start, size int64, depth int) error {
+	if containerBoxes[boxType] {
+		if boxType == "moov" {
+			s.hasMoov = true
+		}
+		if boxType == "trak" {
+			s.tracks = append(s.tracks, &trackInfo{})
+		}
+		return s.walk(r, start, start+size, depth+1)
+	}
+
+	// Only small header boxes are read; mdat and friends are skipped
+	if boxType != "ftyp" && boxType != "mvhd" && boxType != "tkhd" && boxType != "hdlr" && boxType != "stsd" {
+		return nil
+	}
+	if size > 4096 {
+		size = 4096
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return fmt.Errorf("failed to read %s box: %w", boxType, err)
+	}
+
+	switch boxType {
+	case "ftyp":
+		s.hasFtyp = true
+		if len(data) >= 4 {
+			s.info.Container = "mp4"
+			if string(data[:4]) == "qt  " {
+				s.info.Container = "mov"
+			}
+		}
+	case "mvhd":
+		s.parseMvhd(data)
+	case "tkhd":
+		if track := s.currentTrack(); track != nil {
+			parseTkhd(data, track)
+		}
+	case "hdlr":
+		if track := s.currentTrack(); track != nil && len(data) >= 12 {
+			track.handler = string(data[8:12])
+		}
+	case "stsd":
+		if track := s.currentTrack(); track != nil && len(data) >= 16 {
+			track.codec = strings.TrimSpace(string(data[12:16]))
+		}
+	}
+	return nil
+}
+
+func (s *probeState) currentTrack() *trackInfo {
+	if len(s.tracks) == 0 {
+		return nil
+	}
+	return s.tracks[len(s.tracks)-1]
+}
+
+func (s *probeState) parseMvhd(data []byte) {
+	if len(data) < 1 {
+		return
+	}
+	if data[0] == 1 {
+		if len(data) >= 32 {
+			s.timescale = binary.BigEndian.Uint32(data[20:24])
+			s.duration = binary.BigEndian.Uint64(data[24:32])
+		}
+		return
+	}
+	if len(data) >= 20 {
+		s.timescale = binary.BigEndian.Uint32(data[12:16])
+		s.duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+}
+
+func parseTkhd(data []byte, track *trackInfo) {
+	// width and height are the last 8 bytes, as 16.16 fixed point
+	want := 84
+	if len(data) > 0 && data[0] == 1 {
+		want = 96
+	}
+	if len(data) < want {
+		return
+	}
+	track.width = int(binary.BigEndian.Uint32(data[want-8:want-4]) >> 16)
+	track.height = int(binary.BigEndian.Uint32(data[want-4:want]) >> 16)
+}
+
+func isTopLevelBox(boxType string) bool {
+	switch boxType {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot":
+		return true
+	}
+	return false
+}
+
+// VideoLimits are the constraints a platform places on uploaded videos
+type VideoLimits struct {
+	Containers   []string
+	Codecs       []string
+	MinDuration  time.Duration
+	MaxDuration  time.Duration
+	MinShortSide int
+	MaxLongSide  int
+	MaxSize      int64
+}
+
+// Validate checks info against the limits and returns every violation at once
+func (l VideoLimits) Validate(info *VideoInfo) error {
+	var problems []string
+
+	if len(l.Containers) > 0 && !contains(l.Containers, info.Container) {
+		problems = append(problems, fmt.Sprintf("container %s is not supported (supported: %s)", info.Container, strings.Join(l.Containers, ", ")))
+	}
+	if len(l.Codecs) > 0 && !contains(l.Codecs, info.Codec) {
+		problems = append(problems, fmt.Sprintf("codec %s is not supported (supported: %s)", info.Codec, strings.Join(l.Codecs, ", ")))
+	}
+	if l.MinDuration > 0 && info.Duration < l.MinDuration {
+		problems = append(problems, fmt.Sprintf("duration %s is shorter than %s", info.Duration.Round(time.Millisecond), l.MinDuration))
+	}
+	if l.MaxDuration > 0 && info.Duration > l.MaxDuration {
+		problems = append(problems, fmt.Sprintf("duration %s is longer than %s", info.Duration.Round(time.Second), l.MaxDuration))
+	}
+
+	short, long := info.Width, info.Height
+	if short > long {
+		short, long = long, short
+	}
+	if l.MinShortSide > 0 && short < l.MinShortSide {
+		problems = append(problems, fmt.Sprintf("resolution %dx%d is below the minimum of %dp", info.Width, info.Height, l.MinShortSide))
+	}
+	if l.MaxLongSide > 0 && long > l.MaxLongSide {
+		problems = append(problems, fmt.Sprintf("resolution %dx%d exceeds the maximum of %d pixels", info.Width, info.Height, l.MaxLongSide))
+	}
+	if l.MaxSize > 0 && info.Size > l.MaxSize {
+		problems = append(problems, fmt.Sprintf("file size %d bytes exceeds the limit of %d bytes", info.Size, l.MaxSize))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid video: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package media
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func box(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	out := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(out, uint32(size))
+	copy(out[4:], boxType)
+	for _, p := range payload {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// testMP4 builds a minimal MP4 with one video track
+func testMP4(codec string, width, height int, seconds uint32) []byte {
+	mvhd := append(make([]byte, 12), append(u32(1000), u32(seconds*1000)...)...)
+	mvhd = append(mvhd, make([]byte, 80)...)
+
+	tkhd := make([]byte, 76)
+	tkhd = append(tkhd, u32(uint32(width)<<16)...)
+	tkhd = append(tkhd, u32(uint32(height)<<16)...)
+
+	hdlr := append(make([]byte, 8), []byte("vide")...)
+	hdlr = append(hdlr, make([]byte, 13)...)
+
+	stsd := append(make([]byte, 4), u32(1)...)
+	stsd = append(stsd, box(codec, make([]byte, 78))...)
+
+	return append(append(
+		box("ftyp", []byte("isom"), u32(512), []byte("isomavc1")),
+		box("moov",
+			box("mvhd", mvhd),
+			box("trak",
+				box("tkhd", tkhd),
+				box("mdia",
+					box("hdlr", hdlr),
+					box("minf", box("stbl", box("stsd", stsd))),
+				),
+			),
+		)...),
+		box("mdat", make([]byte, 1024))...)
+}
+
+func writeTemp(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "video.mp4")
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	return path
+}
+
+func TestProbeVideo(t *testing.T) {
+	info, err := ProbeVideo(writeTemp(t, testMP4("avc1", 1080, 1920, 30)))
+	require.NoError(t, err)
+	assert.Equal(t, "mp4", info.Container)
+	assert.Equal(t, "h264", info.Codec)
+	assert.Equal(t, 1080, info.Width)
+	assert.Equal(t, 1920, info.Height)
+	assert.Equal(t, 30*time.Second, info.Duration)
+
+	_, err = ProbeVideo(writeTemp(t, []byte("definitely not a video file at all")))
+	assert.ErrorIs(t, err, ErrNotMP4)
+}
+
+func TestVideoLimitsValidate(t *testing.T) {
+	limits := VideoLimits{
+		Containers:  []string{"mp4"},
+		Codecs:      []string{"h264"},
+		MaxDuration: time.Minute,
+		MaxSize:     1 << 20,
+	}
+
+	info, err := ProbeVideo(writeTemp(t, testMP4("av01", 1080, 1920, 120)))
+	require.NoError(t, err)
+
+	err = limits.Validate(info)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "codec av1")
+	assert.Contains(t, err.Error(), "longer than 1m0s")
+
+	info, err = ProbeVideo(writeTemp(t, testMP4("avc1", 1080, 1920, 30)))
+	require.NoError(t, err)
+	assert.NoError(t, limits.Validate(info))
+}
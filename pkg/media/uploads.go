@@ -0,0 +1,237 @@
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/xpzouying/xiaohongshu-mcp/configs"
+)
+
+const (
+	// HandlePrefix prefixes server-side media handles returned by uploads
+	HandlePrefix = "media://"
+
+	// MaxImageSize is the size limit for uploaded and downloaded images
+	MaxImageSize = 20 << 20 // 20 MB
+	// MaxVideoSize is the size limit for videos, the largest any publish target accepts
+	MaxVideoSize = 20 << 30 // 20 GB
+
+	uploadsDir = "uploads"
+)
+
+var handlePattern = regexp.MustCompile(`^media://([0-9a-f]{64})\.([a-z0-9]{1,8})$`)
+
+// Upload is media supplied by a client (multipart upload, base64 or data URI) and
+// stored server-side so it can be referenced by its handle in later requests
+type Upload struct {
+	Handle      string `json:"handle"`
+	Path        string `json:"-"`
+	SHA256      string `json:"sha256"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// IsImage reports whether the upload was sniffed as an image
+func (u *Upload) IsImage() bool {
+	return strings.HasPrefix(u.ContentType, "image/")
+}
+
+// IsVideo reports whether the upload was sniffed as a video
+func (u *Upload) IsVideo() bool {
+	return strings.HasPrefix(u.ContentType, "video/")
+}
+
+// Uploads stores client-supplied media by content hash
+type Uploads struct {
+	dir string
+}
+
+// NewUploads creates an upload store under dir
+func NewUploads(dir string) (*Uploads, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create uploads directory: %w", err)
+	}
+	return &Uploads{dir: dir}, nil
+}
+
+var (
+	defaultUploadsOnce sync.Once
+	defaultUploads     *Uploads
+	defaultUploadsErr  error
+)
+
+// DefaultUploads returns the process-wide upload store. It lives inside the media cache
+// so uploads expire together with cached downloads.
+func DefaultUploads() (*Uploads, error) {
+	defaultUploadsOnce.Do(func() {
+		defaultUploads, defaultUploadsErr = NewUploads(filepath.Join(configs.GetMediaPath(), uploadsDir))
+	})
+	return defaultUploads, defaultUploadsErr
+}
+
+// Save streams r into the store. It fails if the content exceeds maxSize bytes.
+func (u *Uploads) Save(r io.Reader, maxSize int64) (*Upload, error) {
+	tmp, err := os.CreateTemp(u.dir, "upload-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	head := &headWriter{limit: sniffLen}
+	n, err := io.Copy(io.MultiWriter(tmp, hasher, head), io.LimitReader(r, maxSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+	if n > maxSize {
+		return nil, &ErrTooLarge{URL: "upload", Limit: maxSize}
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("upload is empty")
+	}
+
+	sum := hex.EncodeToString(hasher.Sum(nil))
+	contentType, ext := sniff(head.buf)
+	path := filepath.Join(u.dir, sum+"."+ext)
+
+	if _, err := os.Stat(path); err == nil {
+		touch(path)
+	} else if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	return &Upload{
+		Handle:      HandlePrefix + sum + "." + ext,
+		Path:        path,
+		SHA256:      sum,
+		Size:        n,
+		ContentType: contentType,
+	}, nil
+}
+
+// SaveBase64 decodes a data URI (data:image/png;base64,...) or raw base64 string into
+// the store. Both the standard and the URL-safe alphabet are accepted.
+func (u *Uploads) SaveBase64(data string, maxSize int64) (*Upload, error) {
+	payload := strings.TrimSpace(data)
+	if IsDataURI(payload) {
+		comma := strings.Index(payload, ",")
+		if comma < 0 || !strings.HasSuffix(payload[:comma], ";base64") {
+			return nil, fmt.Errorf("only base64 data URIs are supported")
+		}
+		payload = payload[comma+1:]
+	}
+
+	// Tolerate line breaks and unpadded input from clients
+	payload = strings.NewReplacer("\n", "", "\r", "", " ", "").Replace(payload)
+	payload = strings.TrimRight(payload, "=")
+
+	// The alphabets only differ in '+/' and '-_', so the input tells which one it uses
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(payload, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+
+	upload, err := u.Save(base64.NewDecoder(encoding, strings.NewReader(payload)), maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 media: %w", err)
+	}
+	return upload, nil
+}
+
+// Resolve returns the upload referenced by a media handle
+func (u *Uploads) Resolve(handle string) (*Upload, error) {
+	m := handlePattern.FindStringSubmatch(handle)
+	if m == nil {
+		return nil, fmt.Errorf("invalid media handle: %s", handle)
+	}
+
+	path := filepath.Join(u.dir, m[1]+"."+m[2])
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("media handle %s not found or expired", handle)
+	}
+	touch(path)
+
+	head, err := readHead(path)
+	if err != nil {
+		return nil, err
+	}
+	contentType, _ := sniff(head)
+
+	return &Upload{
+		Handle:      handle,
+		Path:        path,
+		SHA256:      m[1],
+		Size:        info.Size(),
+		ContentType: contentType,
+	}, nil
+}
+
+// IsHandle reports whether s is a server-side media handle
+func IsHandle(s string) bool {
+	return strings.HasPrefix(s, HandlePrefix)
+}
+
+// IsDataURI reports whether s is a data URI
+func IsDataURI(s string) bool {
+	return strings.HasPrefix(strings.ToLower(s), "data:")
+}
+
+// LooksLikeBase64 reports whether s is plausibly raw base64 media rather than a path.
+// File paths are short and contain characters such as '.' that base64 never uses.
+func LooksLikeBase64(s string) bool {
+	if len(s) < 64 {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '+', c == '/', c == '-', c == '_', c == '=', c == '\n', c == '\r':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// headWriter keeps the first bytes written to it for content sniffing
+type headWriter struct {
+	buf   []byte
+	limit int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	if room := w.limit - len(w.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		w.buf = append(w.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+func readHead(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
@@ -0,0 +1,60 @@
+package media
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadsBase64AndResolve(t *testing.T) {
+	uploads, err := NewUploads(t.TempDir())
+	require.NoError(t, err)
+
+	payload := testPayload()
+	encoded := base64.StdEncoding.EncodeToString(payload)
+
+	fromDataURI, err := uploads.SaveBase64("data:image/png;base64,"+encoded, 1<<20)
+	require.NoError(t, err)
+	assert.True(t, fromDataURI.IsImage())
+	assert.Equal(t, int64(len(payload)), fromDataURI.Size)
+	assert.True(t, IsHandle(fromDataURI.Handle))
+
+	fromRaw, err := uploads.SaveBase64(encoded, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, fromDataURI.Handle, fromRaw.Handle)
+
+	resolved, err := uploads.Resolve(fromDataURI.Handle)
+	require.NoError(t, err)
+	assert.Equal(t, fromDataURI.Path, resolved.Path)
+
+	_, err = uploads.Resolve("media://../../etc/passwd")
+	assert.Error(t, err)
+
+	_, err = uploads.SaveBase64(encoded, 10)
+	assert.Error(t, err)
+}
+
+func TestUploadsURLSafeBase64(t *testing.T) {
+	uploads, err := NewUploads(t.TempDir())
+	require.NoError(t, err)
+
+	// bytes that encode to '-' and '_' in the URL-safe alphabet
+	payload := append(testPayload(), 0xfb, 0xff, 0xbf)
+	std, err := uploads.SaveBase64(base64.StdEncoding.EncodeToString(payload), 1<<20)
+	require.NoError(t, err)
+
+	for _, encoded := range []string{base64.URLEncoding.EncodeToString(payload), base64.RawURLEncoding.EncodeToString(payload)} {
+		require.True(t, LooksLikeBase64(encoded))
+		upload, err := uploads.SaveBase64(encoded, 1<<20)
+		require.NoError(t, err)
+		assert.Equal(t, std.Handle, upload.Handle)
+	}
+}
+
+func TestLooksLikeBase64(t *testing.T) {
+	assert.False(t, LooksLikeBase64("/Users/user/image.jpg"))
+	assert.False(t, LooksLikeBase64("aGVsbG8="))
+	assert.True(t, LooksLikeBase64(base64.StdEncoding.EncodeToString(testPayload())))
+}
@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// platformImageVariants lists the image variants a platform gets, best first. The first
+// available variant is published, the others are fallbacks for when it is rejected.
+var platformImageVariants = map[types.Platform][]xiaohongshu.ImageVariant{
+	// Images for these platforms go through the image stage, which recompresses
+	// oversized originals, so start from the watermark-free original
+	types.PlatformTwitter:  {xiaohongshu.ImageOriginal, xiaohongshu.ImageDefault, xiaohongshu.ImagePreview},
+	types.PlatformFacebook: {xiaohongshu.ImageOriginal, xiaohongshu.ImageDefault, xiaohongshu.ImagePreview},
+}
+
+// defaultImageVariants is used for platforms that fetch image URLs themselves (e.g. video
+// covers), where the undocumented original URL is most likely to be refused
+var defaultImageVariants = []xiaohongshu.ImageVariant{xiaohongshu.ImageDefault, xiaohongshu.ImagePreview}
+
+func imageVariantsFor(platform types.Platform) []xiaohongshu.ImageVariant {
+	if variants, ok := platformImageVariants[platform]; ok {
+		return variants
+	}
+	return defaultImageVariants
+}
+
+// imageURL returns the preferred URL of img for platform
+func imageURL(img *xiaohongshu.DetailImageInfo, platform types.Platform) string {
+	if urls := img.URLs(imageVariantsFor(platform)...); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/sirupsen/logrus"
+
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/translator"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
@@ -11,42 +13,103 @@ import (
 
 // Processor handles content processing and adaptation
 type Processor struct {
-	translator translator.Translator
+	translator        translator.Translator
+	localize          LocalizeConfig
+	livePhotosAsVideo bool
+}
+
+// LocalizeConfig configures the "localize and adapt" mode, in which an LLM rewrites
+// the post as a platform-native caption instead of translating and truncating it
+type LocalizeConfig struct {
+	Enabled    bool
+	Tone       string // a tone preset (casual, professional, playful, informative) or free text
+	Audience   string // e.g. "young professionals in Southeast Asia"
+	TargetLang string // defaults to "en"
+}
+
+// tonePresets expands preset names into instructions for the LLM
+var tonePresets = map[string]string{
+	"casual":       "casual, friendly and conversational",
+	"professional": "professional, clear and trustworthy",
+	"playful":      "playful, energetic and emoji-rich",
+	"informative":  "informative and concise, focused on useful details",
+}
+
+// Option configures a Processor
+type Option func(*Processor)
+
+// WithLocalization enables the "localize and adapt" mode for platforms that support it
+func WithLocalization(cfg LocalizeConfig) Option {
+	return func(p *Processor) {
+		if cfg.TargetLang == "" {
+			cfg.TargetLang = "en"
+		}
+		if preset, ok := tonePresets[strings.ToLower(cfg.Tone)]; ok {
+			cfg.Tone = preset
+		}
+		p.localize = cfg
+	}
+}
+
+// WithLivePhotoVideos publishes live photos as short videos on platforms that take video
+func WithLivePhotoVideos() Option {
+	return func(p *Processor) {
+		p.livePhotosAsVideo = true
+	}
 }
 
 // NewProcessor creates a new content processor
-func NewProcessor(trans translator.Translator) *Processor {
-	return &Processor{
+func NewProcessor(trans translator.Translator, options ...Option) *Processor {
+	p := &Processor{
 		translator: trans,
 	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
 }
 
 // Process processes Xiaohongshu content for a specific platform
 func (p *Processor) Process(feed *xiaohongshu.FeedDetail, platform types.Platform) (*types.ProcessedContent, error) {
-	// Translate content to English
-	translatedTitle, err := p.translator.Translate(feed.Title, "zh", "en")
+	// Translate title, description and tags to English in a single round trip
+	translatedTitle, translatedDesc, translatedTags, provider, err := p.translateFeed(feed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to translate title: %w", err)
-	}
-
-	translatedDesc, err := p.translator.Translate(feed.Desc, "zh", "en")
-	if err != nil {
-		return nil, fmt.Errorf("failed to translate description: %w", err)
+		return nil, err
 	}
 
 	// Determine content type
 	contentType := types.ContentTypeText
 	var mediaURLs []string
+	var mediaFallbacks [][]string
+	var coverURL string
+	var videoDuration int
 
 	if feed.Type == "video" {
 		contentType = types.ContentTypeVideo
-		// Note: Video URL needs to be extracted from browser or downloaded
-		// For now, we'll mark it as video type
+		if feed.Video == nil {
+			return nil, fmt.Errorf("video note %s has no video streams", feed.NoteID)
+		}
+
+		stream, err := selectVideoStream(feed.Video.Streams(), platform)
+		if err != nil {
+			return nil, err
+		}
+		mediaURLs = []string{stream.MasterURL}
+		coverURL = feed.Video.Cover
+		videoDuration = feed.Video.DurationSeconds()
+	} else if stream, img, ok := p.livePhotoVideo(feed, platform); ok {
+		// Publish the live photo as a short video
+		contentType = types.ContentTypeVideo
+		mediaURLs = []string{stream.MasterURL}
+		coverURL = imageURL(img, platform)
+		videoDuration = stream.Duration / 1000
 	} else if len(feed.ImageList) > 0 {
 		contentType = types.ContentTypeImage
+		variants := imageVariantsFor(platform)
 		for _, img := range feed.ImageList {
-			if img.URLDefault != "" {
-				mediaURLs = append(mediaURLs, img.URLDefault)
+			if urls := img.URLs(variants...); len(urls) > 0 {
+				mediaURLs = append(mediaURLs, urls[0])
+				mediaFallbacks = append(mediaFallbacks, urls[1:])
 			}
 		}
 	}
@@ -57,11 +120,15 @@ func (p *Processor) Process(feed *xiaohongshu.FeedDetail, platform types.Platfor
 		Description:         translatedDesc,
 		Type:                contentType,
 		MediaURLs:           mediaURLs,
-		Tags:                []string{},
+		MediaFallbackURLs:   mediaFallbacks,
+		CoverURL:            coverURL,
+		VideoDuration:       videoDuration,
+		Tags:                translatedTags,
 		OriginalTitle:       feed.Title,
 		OriginalDescription: feed.Desc,
 		SourceID:            feed.NoteID,
 		SourceURL:           fmt.Sprintf("https://www.xiaohongshu.com/explore/%s", feed.NoteID),
+		TranslationProvider: provider,
 	}
 
 	// Adapt content for specific platform
@@ -79,11 +146,51 @@ func (p *Processor) Process(feed *xiaohongshu.FeedDetail, platform types.Platfor
 	}
 }
 
+// translateFeed translates the title, description and tags of a feed with one batch call
+// and returns the name of the translation backend that produced them
+func (p *Processor) translateFeed(feed *xiaohongshu.FeedDetail) (title, desc string, tags []string, provider string, err error) {
+	texts := []string{feed.Title, feed.Desc}
+	for _, tag := range feed.TagList {
+		if tag.Name != "" {
+			texts = append(texts, tag.Name)
+		}
+	}
+
+	translated, provider, err := translator.TranslateBatchWithProvider(p.translator, texts, "zh", "en")
+	if err != nil {
+		return "", "", nil, "", fmt.Errorf("failed to translate content: %w", err)
+	}
+
+	if len(translated) != len(texts) {
+		return "", "", nil, "", fmt.Errorf("failed to translate content: got %d translations, expected %d", len(translated), len(texts))
+	}
+
+	tags = make([]string, 0, len(translated)-2)
+	for _, tag := range translated[2:] {
+		tag = strings.TrimSpace(strings.TrimLeft(tag, "#"))
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return translated[0], translated[1], tags, provider, nil
+}
+
 // adaptForTwitter adapts content for Twitter/X
 func (p *Processor) adaptForTwitter(content *types.ProcessedContent) (*types.ProcessedContent, error) {
 	// Twitter limit: 280 characters for text (4000 for Twitter Blue/Premium)
 	maxLength := 280
 
+	// Twitter supports up to 4 images or 1 video
+	if content.Type == types.ContentTypeImage && len(content.MediaURLs) > 4 {
+		content.MediaURLs = content.MediaURLs[:4]
+	}
+
+	// Twitter counts CJK characters and emojis as two
+	if p.localizeCaption(content, maxLength, true) {
+		return content, nil
+	}
+
 	// Create tweet text with title and description
 	tweetText := content.Title
 	if content.Description != "" {
@@ -109,11 +216,6 @@ func (p *Processor) adaptForTwitter(content *types.ProcessedContent) (*types.Pro
 
 	content.Description = tweetText
 
-	// Twitter supports up to 4 images or 1 video
-	if content.Type == types.ContentTypeImage && len(content.MediaURLs) > 4 {
-		content.MediaURLs = content.MediaURLs[:4]
-	}
-
 	return content, nil
 }
 
@@ -127,6 +229,10 @@ func (p *Processor) adaptForTikTok(content *types.ProcessedContent) (*types.Proc
 		return nil, fmt.Errorf("TikTok requires video content, got: %s", content.Type)
 	}
 
+	if p.localizeCaption(content, maxLength, false) {
+		return content, nil
+	}
+
 	// Create description
 	description := content.Title
 	if content.Description != "" {
@@ -199,6 +305,48 @@ func (p *Processor) adaptForYouTube(content *types.ProcessedContent) (*types.Pro
 	return content, nil
 }
 
+// localizeCaption rewrites content.Description as a platform-native caption when the
+// localize mode is enabled. It returns false when the caller should fall back to the
+// literal translation and truncation. weighted selects Twitter's length counting.
+func (p *Processor) localizeCaption(content *types.ProcessedContent, maxLength int, weighted bool) bool {
+	if !p.localize.Enabled {
+		return false
+	}
+
+	localizer, ok := p.translator.(translator.Localizer)
+	if !ok {
+		logrus.Warnf("Localize mode enabled but translator does not support it, falling back to translation")
+		return false
+	}
+
+	caption, err := localizer.Localize(translator.LocalizeRequest{
+		Platform:    string(content.Platform),
+		MaxLength:   maxLength,
+		Weighted:    weighted,
+		Tone:        p.localize.Tone,
+		Audience:    p.localize.Audience,
+		SourceLang:  "zh",
+		TargetLang:  p.localize.TargetLang,
+		Title:       content.OriginalTitle,
+		Description: content.OriginalDescription,
+		Tags:        content.Tags,
+	})
+	if err != nil {
+		logrus.Warnf("Failed to localize caption for %s, falling back to translation: %v", content.Platform, err)
+		return false
+	}
+
+	content.Description = caption.Caption
+	if len(caption.Hashtags) > 0 {
+		content.Tags = caption.Hashtags
+	}
+	if caption.Provider != "" {
+		content.TranslationProvider = caption.Provider
+	}
+
+	return true
+}
+
 // truncateText truncates text to the specified length at word boundary
 func (p *Processor) truncateText(text string, maxLength int) string {
 	if len(text) <= maxLength {
@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/translator"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// fakeLocalizer translates by prefixing texts and returns a fixed caption
+type fakeLocalizer struct {
+	caption *translator.LocalizedCaption
+	err     error
+	req     translator.LocalizeRequest
+}
+
+func (f *fakeLocalizer) Translate(text, sourceLang, targetLang string) (string, error) {
+	return "en:" + text, nil
+}
+
+func (f *fakeLocalizer) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = "en:" + text
+	}
+	return out, nil
+}
+
+func (f *fakeLocalizer) Localize(req translator.LocalizeRequest) (*translator.LocalizedCaption, error) {
+	f.req = req
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.caption, nil
+}
+
+func TestProcessLocalize(t *testing.T) {
+	feed := &xiaohongshu.FeedDetail{
+		NoteID:    "abc",
+		Title:     "周末咖啡",
+		Desc:      "推荐一家咖啡店",
+		Type:      "normal",
+		ImageList: []xiaohongshu.DetailImageInfo{{URLDefault: "https://cdn/1.jpg"}},
+		TagList:   []xiaohongshu.NoteTag{{Name: "咖啡"}},
+	}
+
+	t.Run("localized", func(t *testing.T) {
+		tr := &fakeLocalizer{caption: &translator.LocalizedCaption{
+			Caption:  "Weekend coffee run ☕ #coffee",
+			Hashtags: []string{"coffee"},
+			Provider: "anthropic",
+		}}
+		p := NewProcessor(tr, WithLocalization(LocalizeConfig{Enabled: true, Tone: "casual"}))
+
+		got, err := p.Process(feed, types.PlatformTwitter)
+		require.NoError(t, err)
+		assert.Equal(t, "Weekend coffee run ☕ #coffee", got.Description)
+		assert.Equal(t, []string{"coffee"}, got.Tags)
+		assert.Equal(t, "anthropic", got.TranslationProvider)
+
+		assert.Equal(t, "周末咖啡", tr.req.Title)
+		assert.Equal(t, 280, tr.req.MaxLength)
+		assert.True(t, tr.req.Weighted)
+		assert.Equal(t, "en", tr.req.TargetLang)
+		assert.Equal(t, tonePresets["casual"], tr.req.Tone)
+	})
+
+	t.Run("falls back to translation", func(t *testing.T) {
+		tr := &fakeLocalizer{err: errors.New("localized caption is 300 characters, limit is 280")}
+		p := NewProcessor(tr, WithLocalization(LocalizeConfig{Enabled: true}))
+
+		got, err := p.Process(feed, types.PlatformTwitter)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(got.Description, "en:周末咖啡\n\nen:推荐一家咖啡店"))
+		assert.Equal(t, []string{"en:咖啡"}, got.Tags)
+	})
+}
+
+func TestProcessImageVariantsPerPlatform(t *testing.T) {
+	feed := &xiaohongshu.FeedDetail{
+		NoteID: "abc",
+		Type:   "normal",
+		ImageList: []xiaohongshu.DetailImageInfo{{
+			URLDefault:  "https://sns-webpic/dft",
+			URLPre:      "https://sns-webpic/prv",
+			OriginalURL: "https://ci.xiaohongshu.com/token",
+		}},
+	}
+	p := NewProcessor(&fakeLocalizer{})
+
+	got, err := p.Process(feed, types.PlatformTwitter)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://ci.xiaohongshu.com/token"}, got.MediaURLs)
+	assert.Equal(t, [][]string{{"https://sns-webpic/dft", "https://sns-webpic/prv"}}, got.MediaFallbackURLs)
+
+	assert.Equal(t, []xiaohongshu.ImageVariant{xiaohongshu.ImageDefault, xiaohongshu.ImagePreview}, imageVariantsFor(types.PlatformTikTok))
+}
@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+// videoRequirements describes which video streams a platform accepts
+type videoRequirements struct {
+	Codecs       []string // accepted codecs in order of preference
+	MaxLongSide  int      // 0 means no limit
+	MaxShortSide int      // 0 means no limit
+	MaxSize      int64    // bytes, 0 means no limit
+}
+
+var platformVideoRequirements = map[types.Platform]videoRequirements{
+	// Twitter only accepts H.264, up to 1920x1200 and 512 MB
+	types.PlatformTwitter: {Codecs: []string{"h264"}, MaxLongSide: 1920, MaxShortSide: 1200, MaxSize: 512 << 20},
+	// TikTok accepts H.264 and H.265, 1080p is the recommended maximum
+	types.PlatformTikTok: {Codecs: []string{"h264", "h265"}, MaxLongSide: 1920, MaxShortSide: 1080, MaxSize: 4 << 30},
+	// Facebook recommends H.264, up to 4 GB
+	types.PlatformFacebook: {Codecs: []string{"h264"}, MaxSize: 4 << 30},
+	// YouTube transcodes everything, so prefer the highest quality source
+	types.PlatformYouTube: {Codecs: []string{"h264", "h265", "av1"}},
+}
+
+var defaultVideoRequirements = videoRequirements{Codecs: []string{"h264"}}
+
+// selectVideoStream picks the best video stream for a platform: the highest resolution
+// stream within the platform limits, preferring codecs earlier in the list on ties.
+// If no stream fits the resolution limits, the smallest compatible stream is returned;
+// streams larger than the platform's MaxSize are never returned.
+func selectVideoStream(streams []xiaohongshu.VideoStream, platform types.Platform) (*xiaohongshu.VideoStream, error) {
+	req, ok := platformVideoRequirements[platform]
+	if !ok {
+		req = defaultVideoRequirements
+	}
+
+	var best, smallest, oversized *xiaohongshu.VideoStream
+	bestRank := 0
+	for _, stream := range streams {
+		stream := stream
+		rank := codecRank(req.Codecs, stream.VideoCodec)
+		if rank < 0 {
+			continue
+		}
+
+		// the upload would be rejected anyway, so a too large file is not a fallback
+		if req.MaxSize > 0 && stream.Size > req.MaxSize {
+			if oversized == nil || stream.Size < oversized.Size {
+				oversized = &stream
+			}
+			continue
+		}
+
+		if smallest == nil || pixels(&stream) < pixels(smallest) {
+			smallest = &stream
+		}
+
+		if !req.fits(&stream) {
+			continue
+		}
+
+		if best == nil || pixels(&stream) > pixels(best) ||
+			(pixels(&stream) == pixels(best) && rank < bestRank) {
+			best = &stream
+			bestRank = rank
+		}
+	}
+
+	if best != nil {
+		return best, nil
+	}
+	if smallest != nil {
+		return smallest, nil
+	}
+	if oversized != nil {
+		return nil, fmt.Errorf("every video stream compatible with %s exceeds max size of %d MB (smallest is %d MB)",
+			platform, req.MaxSize>>20, oversized.Size>>20)
+	}
+	return nil, fmt.Errorf("no video stream compatible with %s (accepted codecs: %s)", platform, strings.Join(req.Codecs, ", "))
+}
+
+// platformVideoOnly lists platforms that only accept video posts
+var platformVideoOnly = map[types.Platform]bool{
+	types.PlatformTikTok:  true,
+	types.PlatformYouTube: true,
+}
+
+// platformAcceptsVideo lists platforms whose publisher can post video
+var platformAcceptsVideo = map[types.Platform]bool{
+	types.PlatformTikTok:   true,
+	types.PlatformYouTube:  true,
+	types.PlatformFacebook: true,
+}
+
+// livePhotoVideo returns the live photo stream to publish as a short video, if live photo
+// conversion is enabled and the platform takes video. Video-only platforms use the first
+// live photo of any note; other platforms only convert notes made of a single live photo.
+func (p *Processor) livePhotoVideo(feed *xiaohongshu.FeedDetail, platform types.Platform) (*xiaohongshu.VideoStream, *xiaohongshu.DetailImageInfo, bool) {
+	if !p.livePhotosAsVideo || !platformAcceptsVideo[platform] {
+		return nil, nil, false
+	}
+	if !platformVideoOnly[platform] && len(feed.ImageList) != 1 {
+		return nil, nil, false
+	}
+
+	for i := range feed.ImageList {
+		img := &feed.ImageList[i]
+		streams := img.LiveStreams()
+		if len(streams) == 0 {
+			continue
+		}
+		stream, err := selectVideoStream(streams, platform)
+		if err != nil {
+			continue
+		}
+		return stream, img, true
+	}
+	return nil, nil, false
+}
+
+func (r videoRequirements) fits(stream *xiaohongshu.VideoStream) bool {
+	long, short := stream.Width, stream.Height
+	if short > long {
+		long, short = short, long
+	}
+
+	if r.MaxLongSide > 0 && long > r.MaxLongSide {
+		return false
+	}
+	if r.MaxShortSide > 0 && short > r.MaxShortSide {
+		return false
+	}
+	if r.MaxSize > 0 && stream.Size > r.MaxSize {
+		return false
+	}
+	return true
+}
+
+func codecRank(codecs []string, codec string) int {
+	codec = strings.ToLower(codec)
+	if codec == "hevc" {
+		codec = "h265"
+	}
+	for i, c := range codecs {
+		if c == codec {
+			return i
+		}
+	}
+	return -1
+}
+
+func pixels(stream *xiaohongshu.VideoStream) int {
+	return stream.Width * stream.Height
+}
@@ -0,0 +1,54 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
+
+func TestSelectVideoStream(t *testing.T) {
+	video := &xiaohongshu.DetailVideo{
+		Media: xiaohongshu.VideoMedia{
+			Stream: xiaohongshu.VideoStreams{
+				H264: []xiaohongshu.VideoStream{
+					{MasterURL: "https://cdn/h264-720.mp4", VideoCodec: "h264", Width: 720, Height: 1280},
+					{MasterURL: "https://cdn/h264-1080.mp4", VideoCodec: "h264", Width: 1080, Height: 1920},
+				},
+				H265: []xiaohongshu.VideoStream{
+					{MasterURL: "https://cdn/h265-1440.mp4", VideoCodec: "h265", Width: 1440, Height: 2560},
+				},
+			},
+		},
+	}
+
+	stream, err := selectVideoStream(video.Streams(), types.PlatformTwitter)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn/h264-1080.mp4", stream.MasterURL)
+
+	stream, err = selectVideoStream(video.Streams(), types.PlatformYouTube)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn/h265-1440.mp4", stream.MasterURL)
+
+	_, err = selectVideoStream(nil, types.PlatformTikTok)
+	assert.Error(t, err)
+}
+
+func TestSelectVideoStreamMaxSize(t *testing.T) {
+	streams := []xiaohongshu.VideoStream{
+		{MasterURL: "https://cdn/h264-720.mp4", VideoCodec: "h264", Width: 720, Height: 1280, Size: 600 << 20},
+		{MasterURL: "https://cdn/h264-1080.mp4", VideoCodec: "h264", Width: 1080, Height: 1920, Size: 900 << 20},
+	}
+
+	_, err := selectVideoStream(streams, types.PlatformTwitter)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds max size of 512 MB")
+
+	// a stream within the size limit is still the fallback when none fits the resolution limits
+	streams = append(streams, xiaohongshu.VideoStream{MasterURL: "https://cdn/h264-4k.mp4", VideoCodec: "h264", Width: 2160, Height: 3840, Size: 400 << 20})
+	stream, err := selectVideoStream(streams, types.PlatformTwitter)
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn/h264-4k.mp4", stream.MasterURL)
+}
@@ -2,15 +2,19 @@ package facebook
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 )
 
@@ -18,13 +22,18 @@ import (
 type Publisher struct {
 	config     *configs.FacebookConfig
 	httpClient *http.Client
+	fetcher    *media.Fetcher
 	enabled    bool
 }
 
+// maxVideoSize is Facebook's upload limit for videos
+const maxVideoSize = 4 << 30
+
 // NewPublisher creates a new Facebook publisher
-func NewPublisher(cfg *configs.FacebookConfig) *Publisher {
+func NewPublisher(cfg *configs.FacebookConfig, fetcher *media.Fetcher) *Publisher {
 	return &Publisher{
-		config: cfg,
+		fetcher: fetcher,
+		config:  cfg,
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 		},
@@ -43,7 +52,7 @@ func (p *Publisher) IsEnabled() bool {
 }
 
 // Publish publishes content to Facebook
-func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResult, error) {
+func (p *Publisher) Publish(ctx context.Context, content *types.ProcessedContent) (*types.PublishResult, error) {
 	result := &types.PublishResult{
 		Platform:  types.PlatformFacebook,
 		Timestamp: time.Now(),
@@ -58,17 +67,17 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 	// Handle different content types
 	switch content.Type {
 	case types.ContentTypeText:
-		return p.publishText(content, result)
+		return p.publishText(ctx, content, result)
 	case types.ContentTypeImage:
-		return p.publishWithImages(content, result)
+		return p.publishWithImages(ctx, content, result)
 	case types.ContentTypeVideo:
-		return p.publishVideo(content, result)
+		return p.publishVideo(ctx, content, result)
 	case types.ContentTypeMixed:
 		// Facebook supports multiple images
 		if len(content.MediaURLs) > 0 {
-			return p.publishWithImages(content, result)
+			return p.publishWithImages(ctx, content, result)
 		}
-		return p.publishText(content, result)
+		return p.publishText(ctx, content, result)
 	default:
 		result.Success = false
 		result.Error = "unsupported content type"
@@ -77,7 +86,7 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 }
 
 // publishText publishes text-only post to Facebook
-func (p *Publisher) publishText(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishText(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	// Facebook Graph API endpoint for page feed
 	apiURL := fmt.Sprintf("https://graph.facebook.com/v18.0/%s/feed", p.config.PageID)
 
@@ -85,7 +94,7 @@ func (p *Publisher) publishText(content *types.ProcessedContent, result *types.P
 	params.Set("message", content.Description)
 	params.Set("access_token", p.config.AccessToken)
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBufferString(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBufferString(params.Encode()))
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
@@ -128,38 +137,35 @@ func (p *Publisher) publishText(content *types.ProcessedContent, result *types.P
 }
 
 // publishWithImages publishes post with images to Facebook
-func (p *Publisher) publishWithImages(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishWithImages(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	if len(content.MediaURLs) == 0 {
-		return p.publishText(content, result)
+		return p.publishText(ctx, content, result)
 	}
 
 	// For single image, use simple photo post
 	if len(content.MediaURLs) == 1 {
-		return p.publishSinglePhoto(content, result)
+		return p.publishSinglePhoto(ctx, content, result)
 	}
 
 	// For multiple images, create album/carousel post
-	return p.publishMultiplePhotos(content, result)
+	return p.publishMultiplePhotos(ctx, content, result)
 }
 
 // publishSinglePhoto publishes single photo to Facebook
-func (p *Publisher) publishSinglePhoto(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishSinglePhoto(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	apiURL := fmt.Sprintf("https://graph.facebook.com/v18.0/%s/photos", p.config.PageID)
 
 	params := url.Values{}
-	params.Set("url", content.MediaURLs[0])
 	params.Set("caption", content.Description)
 	params.Set("access_token", p.config.AccessToken)
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBufferString(params.Encode()))
+	req, err := newPhotoRequest(ctx, apiURL, params, content.MediaURLs[0], mediaFile(content, 0))
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
 		return result, err
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		result.Success = false
@@ -199,11 +205,11 @@ func (p *Publisher) publishSinglePhoto(content *types.ProcessedContent, result *
 }
 
 // publishMultiplePhotos publishes multiple photos to Facebook
-func (p *Publisher) publishMultiplePhotos(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishMultiplePhotos(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	// Step 1: Upload all photos unpublished
 	var photoIDs []string
-	for _, imageURL := range content.MediaURLs {
-		photoID, err := p.uploadUnpublishedPhoto(imageURL)
+	for i, imageURL := range content.MediaURLs {
+		photoID, err := p.uploadUnpublishedPhoto(ctx, imageURL, mediaFile(content, i))
 		if err != nil {
 			result.Success = false
 			result.Error = fmt.Sprintf("failed to upload photo: %v", err)
@@ -224,7 +230,7 @@ func (p *Publisher) publishMultiplePhotos(content *types.ProcessedContent, resul
 		params.Add("attached_media[]", fmt.Sprintf(`{"media_fbid":"%s"}`, photoID))
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBufferString(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBufferString(params.Encode()))
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
@@ -267,21 +273,18 @@ func (p *Publisher) publishMultiplePhotos(content *types.ProcessedContent, resul
 }
 
 // uploadUnpublishedPhoto uploads photo without publishing
-func (p *Publisher) uploadUnpublishedPhoto(imageURL string) (string, error) {
+func (p *Publisher) uploadUnpublishedPhoto(ctx context.Context, imageURL, imageFile string) (string, error) {
 	apiURL := fmt.Sprintf("https://graph.facebook.com/v18.0/%s/photos", p.config.PageID)
 
 	params := url.Values{}
-	params.Set("url", imageURL)
 	params.Set("published", "false")
 	params.Set("access_token", p.config.AccessToken)
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBufferString(params.Encode()))
+	req, err := newPhotoRequest(ctx, apiURL, params, imageURL, imageFile)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
@@ -306,7 +309,7 @@ func (p *Publisher) uploadUnpublishedPhoto(imageURL string) (string, error) {
 }
 
 // publishVideo publishes video to Facebook
-func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishVideo(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	if len(content.MediaURLs) == 0 {
 		result.Success = false
 		result.Error = "no video URL provided"
@@ -316,43 +319,52 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	videoURL := content.MediaURLs[0]
 
 	// Download video
-	videoData, err := p.downloadMedia(videoURL)
+	video, err := p.fetcher.Fetch(ctx, videoURL, maxVideoSize)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to download video: %v", err)
 		return result, err
 	}
+	defer video.Release()
 
-	// Upload video to Facebook
-	apiURL := fmt.Sprintf("https://graph-video.facebook.com/v18.0/%s/videos", p.config.PageID)
-
-	// Create multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Add video file
-	part, err := writer.CreateFormFile("source", "video.mp4")
+	file, err := video.Open()
 	if err != nil {
 		result.Success = false
-		result.Error = fmt.Sprintf("failed to create form: %v", err)
+		result.Error = fmt.Sprintf("failed to open video: %v", err)
 		return result, err
 	}
-	part.Write(videoData)
+	defer file.Close()
+
+	// Upload video to Facebook
+	apiURL := fmt.Sprintf("https://graph-video.facebook.com/v18.0/%s/videos", p.config.PageID)
 
-	// Add description
-	writer.WriteField("description", content.Description)
-	writer.WriteField("access_token", p.config.AccessToken)
+	// Stream the multipart form so the video is never held in memory
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
 
-	writer.Close()
+	go func() {
+		form.WriteField("description", content.Description)
+		form.WriteField("access_token", p.config.AccessToken)
 
-	req, err := http.NewRequest("POST", apiURL, body)
+		part, err := form.CreateFormFile("source", "video."+video.Extension)
+		if err == nil {
+			_, err = io.Copy(part, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, body)
 	if err != nil {
+		body.Close()
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
 		return result, err
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", form.FormDataContentType())
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -387,22 +399,56 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	return result, nil
 }
 
-// downloadMedia downloads media from URL
-func (p *Publisher) downloadMedia(url string) ([]byte, error) {
-	resp, err := p.httpClient.Get(url)
+// mediaFile returns the prepared local file for the i-th media URL, if any
+func mediaFile(content *types.ProcessedContent, i int) string {
+	if i < len(content.MediaFiles) {
+		return content.MediaFiles[i]
+	}
+	return ""
+}
+
+// newPhotoRequest builds a photo upload request. Prepared local files are uploaded as
+// multipart "source"; otherwise Facebook fetches the image from its URL.
+func newPhotoRequest(ctx context.Context, apiURL string, params url.Values, imageURL, imageFile string) (*http.Request, error) {
+	if imageFile == "" {
+		params.Set("url", imageURL)
+		req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBufferString(params.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}
+
+	file, err := os.Open(imageFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
+		return nil, fmt.Errorf("failed to open image: %w", err)
 	}
-	defer resp.Body.Close()
+	defer file.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, values := range params {
+		for _, value := range values {
+			writer.WriteField(key, value)
+		}
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	part, err := writer.CreateFormFile("source", filepath.Base(imageFile))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read media data: %w", err)
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
 	}
 
-	return data, nil
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req, nil
 }
@@ -1,13 +1,15 @@
 package publishers
 
 import (
+	"context"
+
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 )
 
 // Publisher interface for all social media publishers
 type Publisher interface {
-	// Publish publishes content to the platform
-	Publish(content *types.ProcessedContent) (*types.PublishResult, error)
+	// Publish publishes content to the platform, downloads and API calls stop when ctx is done
+	Publish(ctx context.Context, content *types.ProcessedContent) (*types.PublishResult, error)
 
 	// GetName returns the publisher name
 	GetName() string
@@ -2,6 +2,7 @@ package tiktok
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 )
 
@@ -16,13 +18,18 @@ import (
 type Publisher struct {
 	config     *configs.TikTokConfig
 	httpClient *http.Client
+	fetcher    *media.Fetcher
 	enabled    bool
 }
 
+// maxVideoSize is TikTok's upload limit for videos
+const maxVideoSize = 4 << 30
+
 // NewPublisher creates a new TikTok publisher
-func NewPublisher(cfg *configs.TikTokConfig) *Publisher {
+func NewPublisher(cfg *configs.TikTokConfig, fetcher *media.Fetcher) *Publisher {
 	return &Publisher{
-		config: cfg,
+		fetcher: fetcher,
+		config:  cfg,
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second, // Longer timeout for video uploads
 		},
@@ -41,7 +48,7 @@ func (p *Publisher) IsEnabled() bool {
 }
 
 // Publish publishes content to TikTok
-func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResult, error) {
+func (p *Publisher) Publish(ctx context.Context, content *types.ProcessedContent) (*types.PublishResult, error) {
 	result := &types.PublishResult{
 		Platform:  types.PlatformTikTok,
 		Timestamp: time.Now(),
@@ -56,7 +63,7 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 	// TikTok primarily supports video content
 	switch content.Type {
 	case types.ContentTypeVideo:
-		return p.publishVideo(content, result)
+		return p.publishVideo(ctx, content, result)
 	case types.ContentTypeImage:
 		result.Success = false
 		result.Error = "TikTok primarily supports video content. Image publishing not supported."
@@ -73,7 +80,7 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 }
 
 // publishVideo publishes video to TikTok using Content Posting API
-func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishVideo(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	// TikTok Content Posting API workflow:
 	// 1. Initialize video upload
 	// 2. Upload video chunks
@@ -88,15 +95,22 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	videoURL := content.MediaURLs[0]
 
 	// Step 1: Download video
-	videoData, err := p.downloadVideo(videoURL)
+	video, err := p.fetcher.Fetch(ctx, videoURL, maxVideoSize)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to download video: %v", err)
 		return result, err
 	}
+	defer video.Release()
+
+	if !video.IsVideo() {
+		result.Success = false
+		result.Error = fmt.Sprintf("not a video: %s", video.ContentType)
+		return result, fmt.Errorf("not a video: %s", video.ContentType)
+	}
 
 	// Step 2: Initialize upload
-	uploadURL, uploadID, err := p.initializeUpload()
+	uploadURL, uploadID, err := p.initializeUpload(ctx)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to initialize upload: %v", err)
@@ -104,14 +118,14 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	}
 
 	// Step 3: Upload video
-	if err := p.uploadVideo(uploadURL, videoData); err != nil {
+	if err := p.uploadVideo(ctx, uploadURL, video); err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to upload video: %v", err)
 		return result, err
 	}
 
 	// Step 4: Create post
-	postID, postURL, err := p.createPost(uploadID, content)
+	postID, postURL, err := p.createPost(ctx, uploadID, content)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create post: %v", err)
@@ -125,28 +139,8 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	return result, nil
 }
 
-// downloadVideo downloads video from URL
-func (p *Publisher) downloadVideo(url string) ([]byte, error) {
-	resp, err := p.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status: %d", resp.StatusCode)
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read video data: %w", err)
-	}
-
-	return data, nil
-}
-
 // initializeUpload initializes video upload to TikTok
-func (p *Publisher) initializeUpload() (uploadURL string, uploadID string, err error) {
+func (p *Publisher) initializeUpload(ctx context.Context) (uploadURL string, uploadID string, err error) {
 	// TikTok Content Posting API v2 endpoint
 	apiURL := "https://open.tiktokapis.com/v2/post/publish/video/init/"
 
@@ -164,7 +158,7 @@ func (p *Publisher) initializeUpload() (uploadURL string, uploadID string, err e
 		return "", "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -198,15 +192,21 @@ func (p *Publisher) initializeUpload() (uploadURL string, uploadID string, err e
 	return result.Data.UploadURL, result.Data.PublishID, nil
 }
 
-// uploadVideo uploads video data to TikTok
-func (p *Publisher) uploadVideo(uploadURL string, videoData []byte) error {
-	req, err := http.NewRequest("PUT", uploadURL, bytes.NewBuffer(videoData))
+// uploadVideo streams the downloaded video file to TikTok
+func (p *Publisher) uploadVideo(ctx context.Context, uploadURL string, video *media.File) error {
+	file, err := video.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open video: %w", err)
+	}
+	defer file.Close()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", uploadURL, file)
 	if err != nil {
 		return fmt.Errorf("failed to create upload request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "video/mp4")
-	req.ContentLength = int64(len(videoData))
+	req.Header.Set("Content-Type", video.ContentType)
+	req.ContentLength = video.Size
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
@@ -223,7 +223,7 @@ func (p *Publisher) uploadVideo(uploadURL string, videoData []byte) error {
 }
 
 // createPost creates TikTok post with uploaded video
-func (p *Publisher) createPost(publishID string, content *types.ProcessedContent) (string, string, error) {
+func (p *Publisher) createPost(ctx context.Context, publishID string, content *types.ProcessedContent) (string, string, error) {
 	apiURL := "https://open.tiktokapis.com/v2/post/publish/status/fetch/"
 
 	reqBody := map[string]interface{}{
@@ -247,7 +247,7 @@ func (p *Publisher) createPost(publishID string, content *types.ProcessedContent
 		return "", "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -2,13 +2,16 @@ package twitter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 )
 
@@ -16,13 +19,18 @@ import (
 type Publisher struct {
 	config     *configs.TwitterConfig
 	httpClient *http.Client
+	fetcher    *media.Fetcher
 	enabled    bool
 }
 
+// maxImageSize is Twitter's upload limit for images
+const maxImageSize = 5 << 20
+
 // NewPublisher creates a new Twitter publisher
-func NewPublisher(cfg *configs.TwitterConfig) *Publisher {
+func NewPublisher(cfg *configs.TwitterConfig, fetcher *media.Fetcher) *Publisher {
 	return &Publisher{
-		config: cfg,
+		fetcher: fetcher,
+		config:  cfg,
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
@@ -41,7 +49,7 @@ func (p *Publisher) IsEnabled() bool {
 }
 
 // Publish publishes content to Twitter
-func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResult, error) {
+func (p *Publisher) Publish(ctx context.Context, content *types.ProcessedContent) (*types.PublishResult, error) {
 	result := &types.PublishResult{
 		Platform:  types.PlatformTwitter,
 		Timestamp: time.Now(),
@@ -56,9 +64,9 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 	// Handle different content types
 	switch content.Type {
 	case types.ContentTypeText:
-		return p.publishText(content, result)
+		return p.publishText(ctx, content, result)
 	case types.ContentTypeImage:
-		return p.publishWithImages(content, result)
+		return p.publishWithImages(ctx, content, result)
 	case types.ContentTypeVideo:
 		result.Success = false
 		result.Error = "Video publishing to Twitter not yet implemented"
@@ -71,7 +79,7 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 }
 
 // publishText publishes text-only tweet
-func (p *Publisher) publishText(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishText(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	// Using Twitter API v2
 	apiURL := "https://api.twitter.com/2/tweets"
 
@@ -86,7 +94,7 @@ func (p *Publisher) publishText(content *types.ProcessedContent, result *types.P
 		return result, err
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
@@ -134,10 +142,10 @@ func (p *Publisher) publishText(content *types.ProcessedContent, result *types.P
 }
 
 // publishWithImages publishes tweet with images
-func (p *Publisher) publishWithImages(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishWithImages(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	if len(content.MediaURLs) == 0 {
 		// No images, publish as text
-		return p.publishText(content, result)
+		return p.publishText(ctx, content, result)
 	}
 
 	// Twitter supports max 4 images per tweet
@@ -146,10 +154,18 @@ func (p *Publisher) publishWithImages(content *types.ProcessedContent, result *t
 		content.MediaURLs = content.MediaURLs[:maxImages]
 	}
 
-	// Step 1: Download and upload all images to get media IDs
+	// Step 1: Upload all images to get media IDs, using the prepared local files when available
+	images := content.MediaURLs
+	if len(content.MediaFiles) > 0 {
+		images = content.MediaFiles
+	}
+	if len(images) > maxImages {
+		images = images[:maxImages]
+	}
+
 	var mediaIDs []string
-	for _, imageURL := range content.MediaURLs {
-		mediaID, err := p.uploadImage(imageURL)
+	for _, image := range images {
+		mediaID, err := p.uploadImage(ctx, image)
 		if err != nil {
 			result.Success = false
 			result.Error = fmt.Sprintf("failed to upload image: %v", err)
@@ -175,7 +191,7 @@ func (p *Publisher) publishWithImages(content *types.ProcessedContent, result *t
 		return result, err
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to create request: %v", err)
@@ -221,22 +237,30 @@ func (p *Publisher) publishWithImages(content *types.ProcessedContent, result *t
 	return result, nil
 }
 
-// uploadImage downloads and uploads image to Twitter, returns media ID
-func (p *Publisher) uploadImage(imageURL string) (string, error) {
-	// Step 1: Download image
-	imageData, err := p.downloadImage(imageURL)
+// uploadImage uploads an image (a local file or a URL to download) to Twitter, returns media ID
+func (p *Publisher) uploadImage(ctx context.Context, image string) (string, error) {
+	// Step 1: Download image unless it is already a local file
+	path, size, release, err := p.localImage(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	file, err := os.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to download image: %w", err)
+		return "", fmt.Errorf("failed to open image: %w", err)
 	}
+	defer file.Close()
 
 	// Step 2: Upload to Twitter media endpoint
 	// Using Twitter API v1.1 for media upload (v2 doesn't support media upload yet)
 	uploadURL := "https://upload.twitter.com/1.1/media/upload.json"
 
-	req, err := http.NewRequest("POST", uploadURL, bytes.NewBuffer(imageData))
+	req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, file)
 	if err != nil {
 		return "", fmt.Errorf("failed to create upload request: %w", err)
 	}
+	req.ContentLength = size
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.config.BearerToken))
 	req.Header.Set("Content-Type", "application/octet-stream")
@@ -265,22 +289,16 @@ func (p *Publisher) uploadImage(imageURL string) (string, error) {
 	return uploadResp.MediaIDString, nil
 }
 
-// downloadImage downloads image from URL
-func (p *Publisher) downloadImage(url string) ([]byte, error) {
-	resp, err := p.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status: %d", resp.StatusCode)
+// localImage returns a local path and size for an image, downloading it if it is a URL.
+// release must be called once the image has been uploaded.
+func (p *Publisher) localImage(ctx context.Context, image string) (path string, size int64, release func(), err error) {
+	if info, err := os.Stat(image); err == nil {
+		return image, info.Size(), func() {}, nil
 	}
 
-	data, err := io.ReadAll(resp.Body)
+	file, err := p.fetcher.Fetch(ctx, image, maxImageSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read image data: %w", err)
+		return "", 0, nil, fmt.Errorf("failed to download image: %w", err)
 	}
-
-	return data, nil
+	return file.Path, file.Size, file.Release, nil
 }
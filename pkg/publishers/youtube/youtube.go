@@ -2,6 +2,7 @@ package youtube
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/xpzouying/xiaohongshu-mcp/configs"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
 )
 
@@ -17,13 +19,15 @@ import (
 type Publisher struct {
 	config     *configs.YouTubeConfig
 	httpClient *http.Client
+	fetcher    *media.Fetcher
 	enabled    bool
 }
 
 // NewPublisher creates a new YouTube publisher
-func NewPublisher(cfg *configs.YouTubeConfig) *Publisher {
+func NewPublisher(cfg *configs.YouTubeConfig, fetcher *media.Fetcher) *Publisher {
 	return &Publisher{
-		config: cfg,
+		fetcher: fetcher,
+		config:  cfg,
 		httpClient: &http.Client{
 			Timeout: 300 * time.Second, // 5 minutes for large video uploads
 		},
@@ -42,7 +46,7 @@ func (p *Publisher) IsEnabled() bool {
 }
 
 // Publish publishes content to YouTube
-func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResult, error) {
+func (p *Publisher) Publish(ctx context.Context, content *types.ProcessedContent) (*types.PublishResult, error) {
 	result := &types.PublishResult{
 		Platform:  types.PlatformYouTube,
 		Timestamp: time.Now(),
@@ -57,7 +61,7 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 	// YouTube only supports video content
 	switch content.Type {
 	case types.ContentTypeVideo:
-		return p.publishVideo(content, result)
+		return p.publishVideo(ctx, content, result)
 	case types.ContentTypeImage:
 		result.Success = false
 		result.Error = "YouTube only supports video content. Images not supported."
@@ -74,7 +78,7 @@ func (p *Publisher) Publish(content *types.ProcessedContent) (*types.PublishResu
 }
 
 // publishVideo publishes video to YouTube
-func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
+func (p *Publisher) publishVideo(ctx context.Context, content *types.ProcessedContent, result *types.PublishResult) (*types.PublishResult, error) {
 	if len(content.MediaURLs) == 0 {
 		result.Success = false
 		result.Error = "no video URL provided"
@@ -84,12 +88,13 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	videoURL := content.MediaURLs[0]
 
 	// Step 1: Download video
-	videoData, err := p.downloadVideo(videoURL)
+	video, err := p.fetcher.Fetch(ctx, videoURL, 0)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to download video: %v", err)
 		return result, err
 	}
+	defer video.Release()
 
 	// Step 2: Prepare video metadata
 	title := content.Title
@@ -106,7 +111,7 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	}
 
 	// Step 3: Upload video to YouTube
-	videoID, videoURL, err := p.uploadVideo(videoData, title, description, content.Tags)
+	videoID, videoURL, err := p.uploadVideo(ctx, video, title, description, content.Tags)
 	if err != nil {
 		result.Success = false
 		result.Error = fmt.Sprintf("failed to upload video: %v", err)
@@ -120,30 +125,10 @@ func (p *Publisher) publishVideo(content *types.ProcessedContent, result *types.
 	return result, nil
 }
 
-// downloadVideo downloads video from URL
-func (p *Publisher) downloadVideo(url string) ([]byte, error) {
-	resp, err := p.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to download: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status: %d", resp.StatusCode)
-	}
-
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read video data: %w", err)
-	}
-
-	return data, nil
-}
-
 // uploadVideo uploads video to YouTube using YouTube Data API v3
-func (p *Publisher) uploadVideo(videoData []byte, title, description string, tags []string) (string, string, error) {
+func (p *Publisher) uploadVideo(ctx context.Context, video *media.File, title, description string, tags []string) (string, string, error) {
 	// Refresh access token if needed
-	if err := p.refreshAccessToken(); err != nil {
+	if err := p.refreshAccessToken(ctx); err != nil {
 		return "", "", fmt.Errorf("failed to refresh token: %w", err)
 	}
 
@@ -168,37 +153,49 @@ func (p *Publisher) uploadVideo(videoData []byte, title, description string, tag
 		return "", "", fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	// Create multipart form
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	// Add metadata part
-	metadataPart, err := writer.CreatePart(map[string][]string{
-		"Content-Type": {"application/json; charset=UTF-8"},
-	})
+	file, err := video.Open()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create metadata part: %w", err)
-	}
-	metadataPart.Write(metadataJSON)
-
-	// Add video part
-	videoPart, err := writer.CreatePart(map[string][]string{
-		"Content-Type": {"video/*"},
-	})
-	if err != nil {
-		return "", "", fmt.Errorf("failed to create video part: %w", err)
-	}
-	videoPart.Write(videoData)
-
-	writer.Close()
+		return "", "", fmt.Errorf("failed to open video: %w", err)
+	}
+	defer file.Close()
+
+	// Stream the multipart body so the video is never held in memory
+	body, writer := io.Pipe()
+	form := multipart.NewWriter(writer)
+
+	go func() {
+		// Add metadata part
+		metadataPart, err := form.CreatePart(map[string][]string{
+			"Content-Type": {"application/json; charset=UTF-8"},
+		})
+		if err == nil {
+			_, err = metadataPart.Write(metadataJSON)
+		}
+
+		// Add video part
+		var videoPart io.Writer
+		if err == nil {
+			videoPart, err = form.CreatePart(map[string][]string{
+				"Content-Type": {video.ContentType},
+			})
+		}
+		if err == nil {
+			_, err = io.Copy(videoPart, file)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		writer.CloseWithError(err)
+	}()
 
 	// Create request
-	req, err := http.NewRequest("POST", apiURL, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, body)
 	if err != nil {
+		body.Close()
 		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Content-Type", form.FormDataContentType())
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.config.AccessToken))
 
 	// Upload video
@@ -229,7 +226,7 @@ func (p *Publisher) uploadVideo(videoData []byte, title, description string, tag
 }
 
 // refreshAccessToken refreshes the OAuth 2.0 access token using refresh token
-func (p *Publisher) refreshAccessToken() error {
+func (p *Publisher) refreshAccessToken(ctx context.Context) error {
 	if p.config.RefreshToken == "" {
 		// No refresh token, assume access token is still valid
 		return nil
@@ -249,7 +246,7 @@ func (p *Publisher) refreshAccessToken() error {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", tokenURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -0,0 +1,130 @@
+// Package qrterm renders a QR code image as text, so it can be scanned from a terminal
+// (for example over SSH on a headless server). The image is sampled module by module,
+// no QR decoding is involved.
+package qrterm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/jpeg" // decode JPEG QR images
+	_ "image/png"  // decode PNG QR images
+	"math"
+	"strings"
+)
+
+// quietZone is the light border drawn around the code, in modules
+const quietZone = 2
+
+// RenderDataURI renders a QR code given as a data URI (data:image/png;base64,...) or plain base64
+func RenderDataURI(src string, invert bool) (string, error) {
+	if i := strings.Index(src, ","); strings.HasPrefix(src, "data:") && i >= 0 {
+		src = src[i+1:]
+	}
+
+	data, err := base64.StdEncoding.DecodeString(src)
+	if err != nil {
+		return "", errors.New("qrterm: QR code is not a base64 image")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	return Render(img, invert)
+}
+
+// Render renders img with half-block characters, two modules per line. Light modules are
+// drawn as blocks, which reads correctly on dark terminals; set invert for light terminals.
+func Render(img image.Image, invert bool) (string, error) {
+	modules, err := Modules(img)
+	if err != nil {
+		return "", err
+	}
+
+	n := len(modules)
+	dark := func(x, y int) bool {
+		x -= quietZone
+		y -= quietZone
+		if x < 0 || y < 0 || x >= n || y >= n {
+			return false
+		}
+		return modules[y][x]
+	}
+
+	size := n + 2*quietZone
+	var b strings.Builder
+	for y := 0; y < size; y += 2 {
+		for x := 0; x < size; x++ {
+			// Past the last row is quiet zone, dark() reports it as light
+			top, bottom := dark(x, y) != invert, dark(x, y+1) != invert
+			switch {
+			case !top && !bottom:
+				b.WriteRune('█')
+			case !top:
+				b.WriteRune('▀')
+			case !bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// Modules samples the module grid of a QR code image, true is a dark module
+func Modules(img image.Image) ([][]bool, error) {
+	bounds := img.Bounds()
+	isDark := func(x, y int) bool {
+		return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y < 128
+	}
+
+	// Bounding box of the dark pixels is the code without its quiet zone
+	x0, y0, x1, y1 := bounds.Max.X, bounds.Max.Y, bounds.Min.X-1, bounds.Min.Y-1
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if isDark(x, y) {
+				x0, y0 = min(x0, x), min(y0, y)
+				x1, y1 = max(x1, x), max(y1, y)
+			}
+		}
+	}
+	if x1 < x0 || y1 < y0 {
+		return nil, errors.New("qrterm: image has no dark pixels")
+	}
+
+	// The top-left finder pattern starts with a solid run of 7 modules
+	run := 0
+	for x := x0; x <= x1 && isDark(x, y0); x++ {
+		run++
+	}
+	moduleSize := float64(run) / 7
+	if moduleSize < 1 {
+		return nil, errors.New("qrterm: finder pattern not found")
+	}
+
+	// QR codes are 17+4v modules wide for version v
+	width := float64(x1 - x0 + 1)
+	version := math.Round((width/moduleSize - 17) / 4)
+	if version < 1 || version > 40 {
+		return nil, errors.New("qrterm: image does not look like a QR code")
+	}
+	n := int(17 + 4*version)
+	stepX := width / float64(n)
+	stepY := float64(y1-y0+1) / float64(n)
+
+	modules := make([][]bool, n)
+	for row := range modules {
+		modules[row] = make([]bool, n)
+		y := y0 + int((float64(row)+0.5)*stepY)
+		for col := range modules[row] {
+			x := x0 + int((float64(col)+0.5)*stepX)
+			modules[row][col] = isDark(x, y)
+		}
+	}
+	return modules, nil
+}
@@ -0,0 +1,103 @@
+package qrterm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCode builds a version 1 (21x21) module grid with finder patterns and random data
+func testCode() [][]bool {
+	const n = 21
+	grid := make([][]bool, n)
+	rng := rand.New(rand.NewSource(1))
+	for y := range grid {
+		grid[y] = make([]bool, n)
+		for x := range grid[y] {
+			grid[y][x] = rng.Intn(2) == 0
+		}
+	}
+
+	finder := func(ox, oy int) {
+		for y := -1; y <= 7; y++ {
+			for x := -1; x <= 7; x++ {
+				if ox+x < 0 || oy+y < 0 || ox+x >= n || oy+y >= n {
+					continue
+				}
+				ring := max(abs(x-3), abs(y-3))
+				grid[oy+y][ox+x] = ring != 2 && ring != 4
+			}
+		}
+	}
+	finder(0, 0)
+	finder(n-7, 0)
+	finder(0, n-7)
+	return grid
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func drawCode(grid [][]bool, scale, margin int) image.Image {
+	size := len(grid)*scale + 2*margin
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	for my, row := range grid {
+		for mx, dark := range row {
+			if !dark {
+				continue
+			}
+			for y := 0; y < scale; y++ {
+				for x := 0; x < scale; x++ {
+					img.SetGray(margin+mx*scale+x, margin+my*scale+y, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+func TestModules(t *testing.T) {
+	grid := testCode()
+
+	modules, err := Modules(drawCode(grid, 6, 20))
+	require.NoError(t, err)
+	assert.Equal(t, grid, modules)
+}
+
+func TestRenderDataURI(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, drawCode(testCode(), 4, 8)))
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	out, err := RenderDataURI(src, false)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	// 21 modules plus the quiet zone on both sides, two modules per line
+	assert.Len(t, lines, 13)
+	for _, line := range lines {
+		assert.Equal(t, 25, len([]rune(line)))
+	}
+	// The quiet zone is light, drawn as full blocks
+	assert.Equal(t, strings.Repeat("█", 25), lines[0])
+
+	_, err = RenderDataURI("https://example.com/qr.png", false)
+	assert.Error(t, err)
+}
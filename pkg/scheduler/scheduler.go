@@ -1,12 +1,14 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/imaging"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/processor"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/publishers"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
@@ -19,18 +21,33 @@ type Scheduler struct {
 	publishers map[types.Platform]publishers.Publisher
 	jobs       map[string]*types.ScheduledJob
 	mu         sync.RWMutex
+	images     *imaging.Pipeline
 	stopCh     chan struct{}
 	wg         sync.WaitGroup
 }
 
+// Option configures a Scheduler
+type Option func(*Scheduler)
+
+// WithImagePipeline transforms images to each platform's requirements before publishing
+func WithImagePipeline(pipeline *imaging.Pipeline) Option {
+	return func(s *Scheduler) {
+		s.images = pipeline
+	}
+}
+
 // NewScheduler creates a new scheduler
-func NewScheduler(proc *processor.Processor, pubs map[types.Platform]publishers.Publisher) *Scheduler {
-	return &Scheduler{
+func NewScheduler(proc *processor.Processor, pubs map[types.Platform]publishers.Publisher, options ...Option) *Scheduler {
+	s := &Scheduler{
 		processor:  proc,
 		publishers: pubs,
 		jobs:       make(map[string]*types.ScheduledJob),
 		stopCh:     make(chan struct{}),
 	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
 }
 
 // Start starts the scheduler
@@ -89,12 +106,12 @@ func (s *Scheduler) executeJob(job *types.ScheduledJob) {
 	job.Status = types.JobStatusRunning
 	s.mu.Unlock()
 
-	logrus.Infof("Executing scheduled job: %s", job.ID)
+	logrus.Infof("Executing scheduled job: %s (account %s)", job.ID, job.Account)
 
 	// Get feed detail from Xiaohongshu
 	// Note: This requires Xiaohongshu service to be available
 	// For now, we'll assume feed details are already stored in the job
-	// In a production system, you'd fetch the feed here
+	// In a production system, you'd fetch the feed here with job.Account
 
 	var results []types.PublishResult
 
@@ -124,7 +141,7 @@ func (s *Scheduler) executeJob(job *types.ScheduledJob) {
 		// }
 
 		// Publish content
-		// result, err := publisher.Publish(content)
+		// result, err := publisher.Publish(ctx, content)
 		// if err != nil {
 		// 	logrus.Errorf("Failed to publish to %s: %v", platform, err)
 		// }
@@ -139,8 +156,8 @@ func (s *Scheduler) executeJob(job *types.ScheduledJob) {
 	logrus.Infof("Completed scheduled job: %s", job.ID)
 }
 
-// ScheduleJob schedules a new job
-func (s *Scheduler) ScheduleJob(feedID string, platforms []types.Platform, scheduledAt time.Time) (string, error) {
+// ScheduleJob schedules a new job for the given Xiaohongshu account
+func (s *Scheduler) ScheduleJob(feedID, account string, platforms []types.Platform, scheduledAt time.Time) (string, error) {
 	if scheduledAt.Before(time.Now()) {
 		return "", fmt.Errorf("scheduled time is in the past")
 	}
@@ -152,6 +169,7 @@ func (s *Scheduler) ScheduleJob(feedID string, platforms []types.Platform, sched
 	job := &types.ScheduledJob{
 		ID:          uuid.New().String(),
 		FeedID:      feedID,
+		Account:     account,
 		Platforms:   platforms,
 		ScheduledAt: scheduledAt,
 		Status:      types.JobStatusPending,
@@ -162,7 +180,7 @@ func (s *Scheduler) ScheduleJob(feedID string, platforms []types.Platform, sched
 	s.jobs[job.ID] = job
 	s.mu.Unlock()
 
-	logrus.Infof("Scheduled job %s for feed %s at %s", job.ID, feedID, scheduledAt)
+	logrus.Infof("Scheduled job %s for feed %s (account %s) at %s", job.ID, feedID, account, scheduledAt)
 
 	return job.ID, nil
 }
@@ -239,8 +257,9 @@ func (s *Scheduler) DeleteJob(jobID string) error {
 	return nil
 }
 
-// PublishNow publishes content to specified platforms immediately
-func (s *Scheduler) PublishNow(feed *xiaohongshu.FeedDetail, platforms []types.Platform) ([]types.PublishResult, error) {
+// PublishNow publishes content to specified platforms immediately, media downloads and
+// uploads stop when ctx is done
+func (s *Scheduler) PublishNow(ctx context.Context, feed *xiaohongshu.FeedDetail, platforms []types.Platform) ([]types.PublishResult, error) {
 	if len(platforms) == 0 {
 		return nil, fmt.Errorf("no platforms specified")
 	}
@@ -284,8 +303,26 @@ func (s *Scheduler) PublishNow(feed *xiaohongshu.FeedDetail, platforms []types.P
 				return
 			}
 
+			// Prepare images for the platform
+			if s.images != nil {
+				release, err := s.images.Prepare(ctx, content)
+				if err != nil {
+					logrus.Errorf("Failed to prepare images for %s: %v", p, err)
+					mu.Lock()
+					results = append(results, types.PublishResult{
+						Platform:  p,
+						Success:   false,
+						Error:     fmt.Sprintf("failed to prepare images: %v", err),
+						Timestamp: time.Now(),
+					})
+					mu.Unlock()
+					return
+				}
+				defer release()
+			}
+
 			// Publish content
-			result, err := publisher.Publish(content)
+			result, err := publisher.Publish(ctx, content)
 			if err != nil {
 				logrus.Errorf("Failed to publish to %s: %v", p, err)
 			}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/types"
+)
+
+func TestScheduleJobKeepsAccount(t *testing.T) {
+	s := NewScheduler(nil, nil)
+
+	id, err := s.ScheduleJob("feed-1", "brand-a", []types.Platform{types.PlatformTwitter}, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	job, err := s.GetJob(id)
+	require.NoError(t, err)
+	assert.Equal(t, "brand-a", job.Account)
+	assert.Equal(t, types.JobStatusPending, job.Status)
+
+	_, err = s.ScheduleJob("feed-1", "brand-a", []types.Platform{types.PlatformTwitter}, time.Now().Add(-time.Minute))
+	assert.Error(t, err)
+}
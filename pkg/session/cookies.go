@@ -0,0 +1,41 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// SessionCookie is the cookie that carries the Xiaohongshu login session
+const SessionCookie = "web_session"
+
+// ErrNoSessionCookie means the stored cookies do not contain a login session
+var ErrNoSessionCookie = errors.New("no " + SessionCookie + " cookie")
+
+// storedCookie is the subset of a saved browser cookie needed to read its expiry
+type storedCookie struct {
+	Name    string  `json:"name"`
+	Expires float64 `json:"expires"` // seconds since epoch, <= 0 for session cookies
+}
+
+// CookieExpiry returns when the session cookie in data (as saved by the browser) expires.
+// A zero time means the cookie lives for the browser session only.
+func CookieExpiry(data []byte) (time.Time, error) {
+	var cookies []storedCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return time.Time{}, err
+	}
+
+	for _, c := range cookies {
+		if c.Name != SessionCookie {
+			continue
+		}
+		if c.Expires <= 0 {
+			return time.Time{}, nil
+		}
+		sec := int64(c.Expires)
+		nsec := int64((c.Expires - float64(sec)) * float64(time.Second))
+		return time.Unix(sec, nsec), nil
+	}
+	return time.Time{}, ErrNoSessionCookie
+}
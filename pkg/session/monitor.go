@@ -0,0 +1,329 @@
+// Package session watches the login sessions of Xiaohongshu accounts in the background,
+// so that an expired login is noticed before a publish or scheduled job fails.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCheckSkipped is returned by Config.Check when the account is busy and the
+// validation should be retried at the next tick instead of being recorded as failed
+var ErrCheckSkipped = errors.New("session check skipped")
+
+// State is the health of an account's login session
+type State string
+
+const (
+	StateUnknown  State = "unknown"  // not checked yet
+	StateValid    State = "valid"    // logged in
+	StateExpiring State = "expiring" // logged in, but the session cookie expires within the warning window
+	StateExpired  State = "expired"  // logged out or cookies expired, re-login required
+)
+
+// Status is the last known session state of an account
+type Status struct {
+	Account         string     `json:"account"`
+	State           State      `json:"state"`
+	LoggedIn        bool       `json:"logged_in"`
+	CheckedAt       *time.Time `json:"checked_at,omitempty"` // last validation in the browser
+	CookiesExpireAt *time.Time `json:"cookies_expire_at,omitempty"`
+	Error           string     `json:"error,omitempty"` // last validation error, the previous result is kept
+}
+
+// Config configures a Monitor
+type Config struct {
+	// Interval between browser validations of each account
+	Interval time.Duration
+	// Tick is how often cookie expiry and upcoming jobs are inspected, defaults to one minute
+	Tick time.Duration
+	// ExpiryWarning is how long before the session cookie expires the account is reported as expiring
+	ExpiryWarning time.Duration
+	// JobLead is how long before a scheduled job the session is validated again
+	JobLead time.Duration
+
+	// Accounts lists the accounts to watch
+	Accounts func() []string
+	// Check validates the login of an account in the browser, returning ErrCheckSkipped to retry at the next tick
+	Check func(ctx context.Context, account string) (bool, error)
+	// LoadCookies returns the saved cookies of an account
+	LoadCookies func(account string) ([]byte, error)
+	// UpcomingJobs returns the times of pending scheduled jobs that run with an account, may be nil
+	UpcomingJobs func(account string) []time.Time
+
+	Notifiers []Notifier
+}
+
+// Monitor periodically validates account sessions and notifies when a re-login is needed
+type Monitor struct {
+	cfg Config
+
+	mu       sync.Mutex
+	statuses map[string]*Status
+	notified map[notifyKey]string // key of the last event delivered per account and notifier, to notify once per change
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewMonitor creates a monitor, call Start to run it
+func NewMonitor(cfg Config) *Monitor {
+	if cfg.Tick <= 0 {
+		cfg.Tick = time.Minute
+	}
+	return &Monitor{
+		cfg:      cfg,
+		statuses: map[string]*Status{},
+		notified: map[notifyKey]string{},
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start runs the monitor in the background until Stop is called
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(m.cfg.Tick)
+		defer ticker.Stop()
+
+		m.Run(context.Background(), time.Now())
+		for {
+			select {
+			case <-m.stop:
+				return
+			case now := <-ticker.C:
+				m.Run(context.Background(), now)
+			}
+		}
+	}()
+}
+
+// Stop stops the monitor and waits for a running check to finish
+func (m *Monitor) Stop() {
+	close(m.stop)
+	m.wg.Wait()
+}
+
+// Run inspects every account once
+func (m *Monitor) Run(ctx context.Context, now time.Time) {
+	accounts := m.cfg.Accounts()
+	for _, account := range accounts {
+		m.inspect(ctx, account, now, m.nextJob(account, now))
+	}
+
+	// Forget removed accounts
+	watched := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		watched[account] = true
+	}
+	m.mu.Lock()
+	for account := range m.statuses {
+		if !watched[account] {
+			delete(m.statuses, account)
+			m.forgetLocked(account)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// Statuses returns the session state of every watched account, sorted by account
+func (m *Monitor) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]Status, 0, len(m.statuses))
+	for _, st := range m.statuses {
+		statuses = append(statuses, *st)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Account < statuses[j].Account })
+	return statuses
+}
+
+// nextJob returns the earliest pending job of an account at or after now
+func (m *Monitor) nextJob(account string, now time.Time) time.Time {
+	if m.cfg.UpcomingJobs == nil {
+		return time.Time{}
+	}
+
+	var next time.Time
+	for _, at := range m.cfg.UpcomingJobs(account) {
+		if at.Before(now) {
+			continue
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// inspect updates the state of one account from its cookies and, when due, a browser validation
+func (m *Monitor) inspect(ctx context.Context, account string, now, jobAt time.Time) {
+	st := m.snapshot(account)
+
+	jobSoon := !jobAt.IsZero() && jobAt.Sub(now) <= m.cfg.JobLead
+
+	// Cookies are read from storage, no browser is needed to notice a missing or expired session
+	expireAt, cookieErr := m.cookieExpiry(account)
+	st.CookiesExpireAt = nil
+	if !expireAt.IsZero() {
+		st.CookiesExpireAt = &expireAt
+	}
+	if cookieErr == nil && !expireAt.IsZero() && !expireAt.After(now) {
+		cookieErr = errors.New("session cookie expired")
+	}
+
+	st.Error = ""
+	if cookieErr != nil {
+		st.LoggedIn = false
+		st.Error = cookieErr.Error()
+		// Validate in the browser as soon as new cookies are saved
+		st.CheckedAt = nil
+	} else if m.dueForCheck(st, now, jobAt, jobSoon) {
+		loggedIn, err := m.cfg.Check(ctx, account)
+		if errors.Is(err, ErrCheckSkipped) {
+			// Not due yet as far as the next tick is concerned, CheckedAt is unchanged
+			logrus.Debugf("session check for %s skipped: %v", account, err)
+		} else if err != nil {
+			// Keep the previous result, a failed check says nothing about the session
+			st.Error = err.Error()
+			logrus.Warnf("session check for %s failed: %v", account, err)
+		} else {
+			checkedAt := now
+			st.LoggedIn = loggedIn
+			st.CheckedAt = &checkedAt
+		}
+	}
+
+	switch {
+	case cookieErr != nil:
+		st.State = StateExpired
+	case st.CheckedAt == nil:
+		st.State = StateUnknown
+	case !st.LoggedIn:
+		st.State = StateExpired
+	case !expireAt.IsZero() && expireAt.Sub(now) <= m.cfg.ExpiryWarning:
+		st.State = StateExpiring
+	default:
+		st.State = StateValid
+	}
+
+	m.mu.Lock()
+	m.statuses[account] = &st
+	m.mu.Unlock()
+
+	m.notify(ctx, st, now, jobAt, jobSoon)
+}
+
+// dueForCheck reports whether the session should be validated in the browser now
+func (m *Monitor) dueForCheck(st Status, now, jobAt time.Time, jobSoon bool) bool {
+	if st.CheckedAt == nil || now.Sub(*st.CheckedAt) >= m.cfg.Interval {
+		return true
+	}
+	// Validate again once the job is within the lead window, unless already done in the window
+	return jobSoon && st.CheckedAt.Before(jobAt.Add(-m.cfg.JobLead))
+}
+
+func (m *Monitor) cookieExpiry(account string) (time.Time, error) {
+	data, err := m.cfg.LoadCookies(account)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("no saved cookies: %w", err)
+	}
+
+	expireAt, err := CookieExpiry(data)
+	if errors.Is(err, ErrNoSessionCookie) {
+		return time.Time{}, err
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid saved cookies: %w", err)
+	}
+	return expireAt, nil
+}
+
+func (m *Monitor) snapshot(account string) Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if st, ok := m.statuses[account]; ok {
+		return *st
+	}
+	return Status{Account: account, State: StateUnknown}
+}
+
+// notify sends an event when the state turns bad or a job is due with a bad session, once per change
+func (m *Monitor) notify(ctx context.Context, st Status, now, jobAt time.Time, jobSoon bool) {
+	event := Event{
+		Account:         st.Account,
+		State:           st.State,
+		CookiesExpireAt: st.CookiesExpireAt,
+		Time:            now,
+	}
+
+	switch {
+	case jobSoon && st.State == StateExpired:
+		event.Type = EventJobAtRisk
+		event.JobAt = &jobAt
+		event.Message = fmt.Sprintf("account %s must log in again before the job scheduled at %s", st.Account, jobAt.Format(time.RFC3339))
+	case st.State == StateExpired:
+		event.Type = EventReloginRequired
+		event.Message = fmt.Sprintf("account %s is logged out, scan the login QR code again", st.Account)
+	case st.State == StateExpiring:
+		event.Type = EventExpiring
+		event.Message = fmt.Sprintf("session of account %s expires at %s, log in again soon", st.Account, st.CookiesExpireAt.Format(time.RFC3339))
+	default:
+		// Healthy again, notify anew on the next failure
+		m.mu.Lock()
+		m.forgetLocked(st.Account)
+		m.mu.Unlock()
+		return
+	}
+
+	key := string(event.Type)
+	if event.JobAt != nil {
+		key += "@" + event.JobAt.Format(time.RFC3339)
+	}
+
+	// Each notifier is marked only after it delivered the event, a failed delivery is retried next tick
+	for i, n := range m.cfg.Notifiers {
+		nk := notifyKey{account: st.Account, notifier: i}
+
+		m.mu.Lock()
+		delivered := m.notified[nk] == key
+		m.mu.Unlock()
+		if delivered {
+			continue
+		}
+
+		if err := n.Notify(ctx, event); err != nil {
+			logrus.Warnf("failed to send session notification for %s: %v", st.Account, err)
+			continue
+		}
+
+		m.mu.Lock()
+		m.notified[nk] = key
+		m.mu.Unlock()
+	}
+}
+
+// notifyKey identifies the notifications of an account sent through one notifier
+type notifyKey struct {
+	account  string
+	notifier int
+}
+
+// forgetLocked clears the delivered notifications of an account, m.mu must be held
+func (m *Monitor) forgetLocked(account string) {
+	for k := range m.notified {
+		if k.account == account {
+			delete(m.notified, k)
+		}
+	}
+}
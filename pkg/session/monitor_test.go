@@ -0,0 +1,171 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	events []Event
+	err    error // returned instead of recording the event
+}
+
+func (r *recorder) Notify(_ context.Context, event Event) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.events = append(r.events, event)
+	return nil
+}
+
+func cookiesExpiringAt(t time.Time) []byte {
+	return []byte(fmt.Sprintf(`[{"name":"a1","expires":-1},{"name":"web_session","expires":%d}]`, t.Unix()))
+}
+
+func TestCookieExpiry(t *testing.T) {
+	at := time.Unix(1767225600, 0)
+	got, err := CookieExpiry(cookiesExpiringAt(at))
+	require.NoError(t, err)
+	assert.True(t, got.Equal(at))
+
+	got, err = CookieExpiry([]byte(`[{"name":"web_session","expires":-1}]`))
+	require.NoError(t, err)
+	assert.True(t, got.IsZero())
+
+	_, err = CookieExpiry([]byte(`[{"name":"a1","expires":-1}]`))
+	assert.ErrorIs(t, err, ErrNoSessionCookie)
+}
+
+func TestMonitor(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	cookies := map[string][]byte{"default": cookiesExpiringAt(now.Add(30 * 24 * time.Hour))}
+	loggedIn := map[string]bool{"default": true}
+	checks := 0
+	var jobs []time.Time
+
+	rec := &recorder{}
+	m := NewMonitor(Config{
+		Interval:      time.Hour,
+		ExpiryWarning: 24 * time.Hour,
+		JobLead:       30 * time.Minute,
+		Accounts:      func() []string { return []string{"default"} },
+		Check: func(_ context.Context, account string) (bool, error) {
+			checks++
+			return loggedIn[account], nil
+		},
+		LoadCookies: func(account string) ([]byte, error) {
+			data, ok := cookies[account]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return data, nil
+		},
+		UpcomingJobs: func(account string) []time.Time {
+			if account != "default" {
+				return nil
+			}
+			return jobs
+		},
+		Notifiers: []Notifier{rec},
+	})
+	ctx := context.Background()
+
+	m.Run(ctx, now)
+	assert.Equal(t, StateValid, m.Statuses()[0].State)
+	assert.Equal(t, 1, checks)
+
+	// Within the interval only cookies are inspected
+	m.Run(ctx, now.Add(10*time.Minute))
+	assert.Equal(t, 1, checks)
+
+	// The session is gone, noticed at the next browser check and notified once
+	loggedIn["default"] = false
+	m.Run(ctx, now.Add(time.Hour))
+	m.Run(ctx, now.Add(time.Hour+time.Minute))
+	assert.Equal(t, StateExpired, m.Statuses()[0].State)
+	require.Len(t, rec.events, 1)
+	assert.Equal(t, EventReloginRequired, rec.events[0].Type)
+
+	// A job coming up with an expired session is notified separately, the last check
+	// already happened within the lead window so the browser is not used again
+	jobs = []time.Time{now.Add(time.Hour + 20*time.Minute)}
+	m.Run(ctx, now.Add(time.Hour+2*time.Minute))
+	require.Len(t, rec.events, 2)
+	assert.Equal(t, EventJobAtRisk, rec.events[1].Type)
+	assert.Equal(t, 2, checks)
+
+	// Re-login with cookies expiring soon
+	loggedIn["default"] = true
+	jobs = nil
+	cookies["default"] = cookiesExpiringAt(now.Add(26 * time.Hour))
+	m.Run(ctx, now.Add(3*time.Hour))
+	assert.Equal(t, StateExpiring, m.Statuses()[0].State)
+	require.Len(t, rec.events, 3)
+	assert.Equal(t, EventExpiring, rec.events[2].Type)
+
+	// Missing cookies are noticed without a browser check
+	delete(cookies, "default")
+	m.Run(ctx, now.Add(3*time.Hour+time.Minute))
+	assert.Equal(t, StateExpired, m.Statuses()[0].State)
+	assert.Equal(t, 3, checks)
+}
+
+func TestMonitorRetriesFailedNotification(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	log, webhook := &recorder{}, &recorder{err: fmt.Errorf("webhook down")}
+	m := NewMonitor(Config{
+		Interval:    time.Hour,
+		Accounts:    func() []string { return []string{"default"} },
+		Check:       func(context.Context, string) (bool, error) { return false, nil },
+		LoadCookies: func(string) ([]byte, error) { return nil, os.ErrNotExist },
+		Notifiers:   []Notifier{log, webhook},
+	})
+	ctx := context.Background()
+
+	m.Run(ctx, now)
+	require.Len(t, log.events, 1)
+	assert.Empty(t, webhook.events)
+
+	// The webhook is retried until it succeeds, the log is not repeated
+	webhook.err = nil
+	m.Run(ctx, now.Add(time.Minute))
+	m.Run(ctx, now.Add(2*time.Minute))
+	assert.Len(t, log.events, 1)
+	require.Len(t, webhook.events, 1)
+	assert.Equal(t, EventReloginRequired, webhook.events[0].Type)
+}
+
+func TestMonitorSkippedCheckRetriesNextTick(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	checkErr := fmt.Errorf("queue busy: %w", ErrCheckSkipped)
+	checks := 0
+	m := NewMonitor(Config{
+		Interval: time.Hour,
+		Accounts: func() []string { return []string{"default"} },
+		Check: func(context.Context, string) (bool, error) {
+			checks++
+			return true, checkErr
+		},
+		LoadCookies: func(string) ([]byte, error) { return cookiesExpiringAt(now.Add(30 * 24 * time.Hour)), nil },
+	})
+	ctx := context.Background()
+
+	m.Run(ctx, now)
+	st := m.Statuses()[0]
+	assert.Equal(t, StateUnknown, st.State)
+	assert.Empty(t, st.Error)
+
+	checkErr = nil
+	m.Run(ctx, now.Add(time.Minute))
+	assert.Equal(t, StateValid, m.Statuses()[0].State)
+	assert.Equal(t, 2, checks)
+}
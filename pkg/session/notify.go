@@ -0,0 +1,86 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventType describes why a notification was sent
+type EventType string
+
+const (
+	EventReloginRequired EventType = "relogin_required" // the session is gone, scan the QR code again
+	EventExpiring        EventType = "session_expiring" // the session cookie expires soon
+	EventJobAtRisk       EventType = "job_at_risk"      // a scheduled job is due but the session is not valid
+)
+
+// Event is a session notification
+type Event struct {
+	Type            EventType  `json:"type"`
+	Account         string     `json:"account"`
+	State           State      `json:"state"`
+	Message         string     `json:"message"`
+	CookiesExpireAt *time.Time `json:"cookies_expire_at,omitempty"`
+	JobAt           *time.Time `json:"job_at,omitempty"`
+	Time            time.Time  `json:"time"`
+}
+
+// Notifier delivers session events
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// LogNotifier writes events to the log
+type LogNotifier struct{}
+
+// Notify logs the event as a warning
+func (LogNotifier) Notify(_ context.Context, event Event) error {
+	logrus.WithFields(logrus.Fields{
+		"event":   event.Type,
+		"account": event.Account,
+		"state":   event.State,
+	}).Warn(event.Message)
+	return nil
+}
+
+// WebhookNotifier posts events as JSON to a URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url with a 10 second timeout
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts the event, any non-2xx response is an error
+func (w *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
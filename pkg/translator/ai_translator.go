@@ -6,14 +6,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
 // AITranslator ใช้ AI Services (ChatGPT, Claude, Gemini) แปลภาษา
 type AITranslator struct {
-	provider   string // "openai", "anthropic", "google"
+	provider   string // "openai", "anthropic", "google", "local"
 	apiKey     string
 	model      string
+	baseURL    string // ใช้กับ "local" เท่านั้น
+	glossary   *Glossary
 	httpClient *http.Client
 }
 
@@ -29,6 +32,8 @@ func NewAITranslator(provider, apiKey, model string) *AITranslator {
 			model = "claude-3-haiku-20240307" // ถูกและเร็ว
 		case "google":
 			model = "gemini-1.5-flash" // ถูกและเร็ว
+		case "local":
+			model = "qwen2.5" // รองรับภาษาจีนได้ดี
 		}
 	}
 
@@ -42,38 +47,111 @@ func NewAITranslator(provider, apiKey, model string) *AITranslator {
 	}
 }
 
+// NewLocalAITranslator สร้าง translator สำหรับ endpoint ในเครื่องที่เข้ากันได้กับ OpenAI API
+// เช่น Ollama (http://localhost:11434/v1), vLLM หรือ LM Studio
+// baseURL คือ prefix ก่อน /chat/completions, apiKey ไม่บังคับ
+func NewLocalAITranslator(baseURL, apiKey, model string) *AITranslator {
+	t := NewAITranslator("local", apiKey, model)
+	t.baseURL = strings.TrimRight(baseURL, "/")
+	// โมเดลในเครื่องอาจตอบช้ากว่า cloud
+	t.httpClient.Timeout = 180 * time.Second
+	return t
+}
+
+// Name คืนชื่อ provider
+func (t *AITranslator) Name() string {
+	return t.provider
+}
+
+// SetGlossary กำหนด glossary ที่จะใส่เป็นคำสั่งใน prompt
+func (t *AITranslator) SetGlossary(g *Glossary) {
+	t.glossary = g
+}
+
 // Translate แปลข้อความด้วย AI
 func (t *AITranslator) Translate(text, sourceLang, targetLang string) (string, error) {
-	switch t.provider {
-	case "openai":
-		return t.translateWithOpenAI(text, sourceLang, targetLang)
-	case "anthropic":
-		return t.translateWithClaude(text, sourceLang, targetLang)
-	case "google":
-		return t.translateWithGemini(text, sourceLang, targetLang)
-	default:
-		return "", fmt.Errorf("unsupported AI provider: %s", t.provider)
+	prompt := fmt.Sprintf("Translate the following %s text to %s. Return ONLY the translated text, no explanations.%s\n\n%s",
+		sourceLang, targetLang, t.glossary.PromptInstructions(sourceLang, targetLang, text), text)
+
+	translated, err := t.complete(prompt, false)
+	if err != nil {
+		return "", err
 	}
+
+	t.glossary.warnMissingTerms(sourceLang, targetLang, []string{text}, []string{translated})
+	return translated, nil
 }
 
-// TranslateBatch แปลหลายข้อความพร้อมกัน
+// TranslateBatch แปลหลายข้อความใน request เดียว
+// ส่งข้อความเป็น JSON array ที่มี index กำกับ และตรวจสอบว่าผลลัพธ์ครบทุก index
 func (t *AITranslator) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
-	var results []string
-	for _, text := range texts {
-		translated, err := t.Translate(text, sourceLang, targetLang)
+	results := make([]string, len(texts))
+
+	// ข้อความว่างไม่ต้องส่งไปแปล
+	var items []batchItem
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			results[i] = text
+			continue
+		}
+		items = append(items, batchItem{Index: i, Text: text})
+	}
+
+	switch len(items) {
+	case 0:
+		return results, nil
+	case 1:
+		translated, err := t.Translate(items[0].Text, sourceLang, targetLang)
 		if err != nil {
 			return nil, err
 		}
-		results = append(results, translated)
+		results[items[0].Index] = translated
+		return results, nil
+	}
+
+	prompt, err := buildBatchPrompt(items, sourceLang, targetLang, t.glossary.PromptInstructions(sourceLang, targetLang, texts...))
+	if err != nil {
+		return nil, err
 	}
+
+	raw, err := t.complete(prompt, true)
+	if err != nil {
+		return nil, err
+	}
+
+	translated, err := parseBatchResponse(raw, items)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, text := range translated {
+		results[index] = text
+	}
+
+	t.glossary.warnMissingTerms(sourceLang, targetLang, texts, results)
 	return results, nil
 }
 
-// translateWithOpenAI แปลด้วย ChatGPT
-func (t *AITranslator) translateWithOpenAI(text, sourceLang, targetLang string) (string, error) {
-	apiURL := "https://api.openai.com/v1/chat/completions"
+// complete ส่ง prompt ไปยัง provider ที่ตั้งค่าไว้
+// jsonMode = true จะขอให้ provider ตอบกลับเป็น JSON (ถ้ารองรับ)
+func (t *AITranslator) complete(prompt string, jsonMode bool) (string, error) {
+	switch t.provider {
+	case "openai":
+		return t.completeWithOpenAI("https://api.openai.com/v1", prompt, jsonMode)
+	case "local":
+		return t.completeWithOpenAI(t.baseURL, prompt, jsonMode)
+	case "anthropic":
+		return t.completeWithClaude(prompt, jsonMode)
+	case "google":
+		return t.completeWithGemini(prompt, jsonMode)
+	default:
+		return "", fmt.Errorf("unsupported AI provider: %s", t.provider)
+	}
+}
 
-	prompt := fmt.Sprintf("Translate the following %s text to %s. Return ONLY the translated text, no explanations:\n\n%s", sourceLang, targetLang, text)
+// completeWithOpenAI เรียก ChatGPT หรือ endpoint ที่เข้ากันได้กับ OpenAI
+func (t *AITranslator) completeWithOpenAI(baseURL, prompt string, jsonMode bool) (string, error) {
+	apiURL := baseURL + "/chat/completions"
 
 	reqBody := map[string]interface{}{
 		"model": t.model,
@@ -85,33 +163,18 @@ func (t *AITranslator) translateWithOpenAI(text, sourceLang, targetLang string)
 		},
 		"temperature": 0.3,
 	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	if jsonMode {
+		reqBody["response_format"] = map[string]string{"type": "json_object"}
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+	headers := map[string]string{}
+	if t.apiKey != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", t.apiKey)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.apiKey))
-
-	resp, err := t.httpClient.Do(req)
+	body, err := t.postJSON(apiURL, reqBody, headers)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: %s (status: %d)", string(body), resp.StatusCode)
+		return "", err
 	}
 
 	var result struct {
@@ -133,11 +196,15 @@ func (t *AITranslator) translateWithOpenAI(text, sourceLang, targetLang string)
 	return result.Choices[0].Message.Content, nil
 }
 
-// translateWithClaude แปลด้วย Claude
-func (t *AITranslator) translateWithClaude(text, sourceLang, targetLang string) (string, error) {
+// completeWithClaude เรียก Claude
+func (t *AITranslator) completeWithClaude(prompt string, jsonMode bool) (string, error) {
 	apiURL := "https://api.anthropic.com/v1/messages"
 
-	prompt := fmt.Sprintf("Translate the following %s text to %s. Return ONLY the translated text, no explanations:\n\n%s", sourceLang, targetLang, text)
+	maxTokens := 1024
+	if jsonMode {
+		// batch ต้องการพื้นที่มากกว่าสำหรับ JSON ที่ครอบข้อความ
+		maxTokens = 4096
+	}
 
 	reqBody := map[string]interface{}{
 		"model": t.model,
@@ -147,36 +214,15 @@ func (t *AITranslator) translateWithClaude(text, sourceLang, targetLang string)
 				"content": prompt,
 			},
 		},
-		"max_tokens": 1024,
-	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		"max_tokens": maxTokens,
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", t.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := t.httpClient.Do(req)
+	body, err := t.postJSON(apiURL, reqBody, map[string]string{
+		"x-api-key":         t.apiKey,
+		"anthropic-version": "2023-06-01",
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: %s (status: %d)", string(body), resp.StatusCode)
+		return "", err
 	}
 
 	var result struct {
@@ -196,12 +242,10 @@ func (t *AITranslator) translateWithClaude(text, sourceLang, targetLang string)
 	return result.Content[0].Text, nil
 }
 
-// translateWithGemini แปลด้วย Gemini
-func (t *AITranslator) translateWithGemini(text, sourceLang, targetLang string) (string, error) {
+// completeWithGemini เรียก Gemini
+func (t *AITranslator) completeWithGemini(prompt string, jsonMode bool) (string, error) {
 	apiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", t.model, t.apiKey)
 
-	prompt := fmt.Sprintf("Translate the following %s text to %s. Return ONLY the translated text, no explanations:\n\n%s", sourceLang, targetLang, text)
-
 	reqBody := map[string]interface{}{
 		"contents": []map[string]interface{}{
 			{
@@ -211,32 +255,13 @@ func (t *AITranslator) translateWithGemini(text, sourceLang, targetLang string)
 			},
 		},
 	}
-
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+	if jsonMode {
+		reqBody["generationConfig"] = map[string]string{"responseMimeType": "application/json"}
 	}
 
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	body, err := t.postJSON(apiURL, reqBody, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := t.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: %s (status: %d)", string(body), resp.StatusCode)
+		return "", err
 	}
 
 	var result struct {
@@ -259,3 +284,38 @@ func (t *AITranslator) translateWithGemini(text, sourceLang, targetLang string)
 
 	return result.Candidates[0].Content.Parts[0].Text, nil
 }
+
+// postJSON ส่ง POST request แบบ JSON และคืน body เมื่อ status เป็น 200
+func (t *AITranslator) postJSON(apiURL string, reqBody interface{}, headers map[string]string) ([]byte, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s (status: %d)", string(body), resp.StatusCode)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,77 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// batchItem ข้อความหนึ่งรายการใน batch พร้อม index ในลิสต์ต้นฉบับ
+type batchItem struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+// batchResponse รูปแบบ JSON ที่ขอให้ AI ตอบกลับ
+type batchResponse struct {
+	Translations []batchItem `json:"translations"`
+}
+
+// buildBatchPrompt สร้าง prompt สำหรับแปลหลายข้อความในครั้งเดียว
+// instructions คือคำสั่งเพิ่มเติม เช่น glossary (ว่างได้)
+func buildBatchPrompt(items []batchItem, sourceLang, targetLang, instructions string) (string, error) {
+	payload, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	return fmt.Sprintf(`Translate the "text" of every item in the following JSON array from %s to %s.
+Respond with ONLY a JSON object of the form {"translations":[{"index":<index>,"text":"<translated text>"}]}.
+Return exactly %d items, one per input item, and keep each "index" unchanged. Do not merge, split or omit items and do not add explanations.%s
+
+%s`, sourceLang, targetLang, len(items), instructions, string(payload)), nil
+}
+
+// parseBatchResponse แปลงคำตอบของ AI และตรวจสอบว่า index ตรงกับที่ส่งไปครบทุกตัว
+// คืน map จาก index ต้นฉบับไปยังข้อความที่แปลแล้ว
+func parseBatchResponse(raw string, items []batchItem) (map[int]string, error) {
+	var resp batchResponse
+	if err := json.Unmarshal([]byte(extractJSONObject(raw)), &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse batch response: %w", err)
+	}
+
+	if len(resp.Translations) != len(items) {
+		return nil, fmt.Errorf("batch response has %d translations, expected %d", len(resp.Translations), len(items))
+	}
+
+	expected := make(map[int]bool, len(items))
+	for _, item := range items {
+		expected[item.Index] = true
+	}
+
+	translated := make(map[int]string, len(items))
+	for _, tr := range resp.Translations {
+		if !expected[tr.Index] {
+			return nil, fmt.Errorf("batch response contains unexpected index %d", tr.Index)
+		}
+		if _, dup := translated[tr.Index]; dup {
+			return nil, fmt.Errorf("batch response contains duplicate index %d", tr.Index)
+		}
+		if strings.TrimSpace(tr.Text) == "" {
+			return nil, fmt.Errorf("batch response has empty translation for index %d", tr.Index)
+		}
+		translated[tr.Index] = tr.Text
+	}
+
+	return translated, nil
+}
+
+// extractJSONObject ตัด code fence หรือข้อความที่ห่อ JSON ออก
+func extractJSONObject(raw string) string {
+	start := strings.Index(raw, "{")
+	end := strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return raw
+	}
+	return raw[start : end+1]
+}
@@ -0,0 +1,47 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchResponse(t *testing.T) {
+	items := []batchItem{{Index: 0, Text: "标题"}, {Index: 2, Text: "正文"}}
+
+	t.Run("code fence and reordered", func(t *testing.T) {
+		raw := "```json\n{\"translations\":[{\"index\":2,\"text\":\"Body\"},{\"index\":0,\"text\":\"Title\"}]}\n```"
+		got, err := parseBatchResponse(raw, items)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]string{0: "Title", 2: "Body"}, got)
+	})
+
+	t.Run("missing item", func(t *testing.T) {
+		_, err := parseBatchResponse(`{"translations":[{"index":0,"text":"Title"}]}`, items)
+		assert.Error(t, err)
+	})
+
+	t.Run("unexpected index", func(t *testing.T) {
+		_, err := parseBatchResponse(`{"translations":[{"index":0,"text":"Title"},{"index":1,"text":"Body"}]}`, items)
+		assert.Error(t, err)
+	})
+
+	t.Run("duplicate index", func(t *testing.T) {
+		_, err := parseBatchResponse(`{"translations":[{"index":0,"text":"Title"},{"index":0,"text":"Body"}]}`, items)
+		assert.Error(t, err)
+	})
+
+	t.Run("empty translation", func(t *testing.T) {
+		_, err := parseBatchResponse(`{"translations":[{"index":0,"text":"Title"},{"index":2,"text":" "}]}`, items)
+		assert.Error(t, err)
+	})
+}
+
+func TestTranslateBatchSkipsEmptyTexts(t *testing.T) {
+	tr := NewAITranslator("openai", "key", "")
+
+	got, err := tr.TranslateBatch([]string{"", "  "}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "  "}, got)
+}
@@ -0,0 +1,330 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ProviderTranslator is a named translation backend used as a link of ChainTranslator
+type ProviderTranslator struct {
+	Name       string
+	Translator Translator
+}
+
+// BreakerConfig configures the per-provider circuit breaker
+type BreakerConfig struct {
+	WindowSize     int           // number of recent requests used for the error rate
+	MinSamples     int           // minimum requests in the window before the rate is evaluated
+	FailureRate    float64       // open the circuit at or above this error rate (0-1)
+	MaxConsecutive int           // open the circuit after this many consecutive failures
+	Cooldown       time.Duration // how long to wait before letting a probe request through
+}
+
+// DefaultBreakerConfig returns the default circuit breaker configuration
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		WindowSize:     20,
+		MinSamples:     5,
+		FailureRate:    0.5,
+		MaxConsecutive: 3,
+		Cooldown:       60 * time.Second,
+	}
+}
+
+// ProviderStats describes the health of a single translation backend
+type ProviderStats struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"` // closed, open, half-open
+	Requests            int64      `json:"requests"`
+	Failures            int64      `json:"failures"`
+	ErrorRate           float64    `json:"error_rate"` // error rate over the recent window
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// chainMember is one backend of the chain together with its breaker state
+type chainMember struct {
+	ProviderTranslator
+
+	outcomes    []bool // ring buffer of recent outcomes, true means failed
+	next        int
+	filled      int
+	consecutive int
+	requests    int64
+	failures    int64
+	openUntil   time.Time
+	probing     bool
+	lastError   string
+}
+
+// ChainTranslator tries an ordered list of translation backends, falling back to the
+// next one on failure. Each backend has a circuit breaker so a provider that is down
+// is skipped instead of being retried on every request.
+//
+// Localization has breakers of its own: an LLM that writes captions the parser
+// rejects must not stop it from serving ordinary translations.
+type ChainTranslator struct {
+	members    []*chainMember
+	localizers []*chainMember // backends that implement Localizer
+	config     BreakerConfig
+	now        func() time.Time
+	mu         sync.Mutex
+}
+
+// NewChainTranslator creates a chain that tries providers in the given order
+func NewChainTranslator(providers []ProviderTranslator, config BreakerConfig) *ChainTranslator {
+	var members, localizers []*chainMember
+	for _, p := range providers {
+		members = append(members, newChainMember(p, config))
+		if _, ok := p.Translator.(Localizer); ok {
+			localizers = append(localizers, newChainMember(p, config))
+		}
+	}
+
+	return &ChainTranslator{
+		members:    members,
+		localizers: localizers,
+		config:     config,
+		now:        time.Now,
+	}
+}
+
+func newChainMember(p ProviderTranslator, config BreakerConfig) *chainMember {
+	return &chainMember{
+		ProviderTranslator: p,
+		outcomes:           make([]bool, max(config.WindowSize, 1)),
+	}
+}
+
+// Name returns the names of all backends in the chain
+func (c *ChainTranslator) Name() string {
+	names := make([]string, 0, len(c.members))
+	for _, m := range c.members {
+		names = append(names, m.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+// Translate translates a single text
+func (c *ChainTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	var result string
+	_, err := c.run(c.members, func(t Translator) error {
+		translated, err := t.Translate(text, sourceLang, targetLang)
+		if err != nil {
+			return err
+		}
+		result = translated
+		return nil
+	})
+	return result, err
+}
+
+// TranslateBatch translates multiple texts
+func (c *ChainTranslator) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
+	results, _, err := c.TranslateBatchWithProvider(texts, sourceLang, targetLang)
+	return results, err
+}
+
+// TranslateBatchWithProvider translates multiple texts and reports which backend produced them
+func (c *ChainTranslator) TranslateBatchWithProvider(texts []string, sourceLang, targetLang string) ([]string, string, error) {
+	var results []string
+	provider, err := c.run(c.members, func(t Translator) error {
+		translated, err := t.TranslateBatch(texts, sourceLang, targetLang)
+		if err != nil {
+			return err
+		}
+		if len(translated) != len(texts) {
+			return fmt.Errorf("got %d translations, expected %d", len(translated), len(texts))
+		}
+		results = translated
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return results, provider, nil
+}
+
+// Stats returns the translation health of every backend
+func (c *ChainTranslator) Stats() []ProviderStats {
+	return c.stats(c.members)
+}
+
+// LocalizeStats returns the localization health of every backend that supports it
+func (c *ChainTranslator) LocalizeStats() []ProviderStats {
+	return c.stats(c.localizers)
+}
+
+func (c *ChainTranslator) stats(members []*chainMember) []ProviderStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	stats := make([]ProviderStats, 0, len(members))
+	for _, m := range members {
+		s := ProviderStats{
+			Name:                m.Name,
+			State:               c.stateLocked(m, now),
+			Requests:            m.requests,
+			Failures:            m.failures,
+			ErrorRate:           m.errorRate(),
+			ConsecutiveFailures: m.consecutive,
+			LastError:           m.lastError,
+		}
+		if !m.openUntil.IsZero() {
+			openUntil := m.openUntil
+			s.OpenUntil = &openUntil
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+// run calls fn against each of members in order until one succeeds and returns its name.
+// Backends with an open circuit are skipped; if every circuit is open the breakers are
+// ignored and the chain is tried once more as a last resort.
+func (c *ChainTranslator) run(members []*chainMember, fn func(Translator) error) (string, error) {
+	if len(members) == 0 {
+		return "", fmt.Errorf("no translation providers configured")
+	}
+
+	var errs []string
+	attempted := false
+
+	for _, m := range members {
+		if !c.allow(m) {
+			continue
+		}
+		attempted = true
+
+		err := fn(m.Translator)
+		c.record(m, err)
+		if err == nil {
+			return m.Name, nil
+		}
+
+		logrus.Warnf("Translation provider %s failed, trying next: %v", m.Name, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", m.Name, err))
+	}
+
+	if !attempted {
+		logrus.Warn("All translation providers have open circuits, retrying without breakers")
+		for _, m := range members {
+			err := fn(m.Translator)
+			c.record(m, err)
+			if err == nil {
+				return m.Name, nil
+			}
+			errs = append(errs, fmt.Sprintf("%s: %v", m.Name, err))
+		}
+	}
+
+	return "", fmt.Errorf("all translation providers failed: %s", strings.Join(errs, "; "))
+}
+
+// allow reports whether a request may be sent to the backend right now
+func (c *ChainTranslator) allow(m *chainMember) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.stateLocked(m, c.now()) {
+	case breakerClosed:
+		return true
+	case breakerHalfOpen:
+		if m.probing {
+			return false
+		}
+		m.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// record stores the outcome of a request and updates the breaker state
+func (c *ChainTranslator) record(m *chainMember, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	wasProbe := m.probing
+	m.probing = false
+	m.requests++
+
+	if err == nil {
+		m.consecutive = 0
+		m.lastError = ""
+		if !m.openUntil.IsZero() {
+			logrus.Infof("Translation provider %s recovered", m.Name)
+			m.openUntil = time.Time{}
+			m.resetWindow()
+		}
+		m.push(false)
+		return
+	}
+
+	m.failures++
+	m.consecutive++
+	m.lastError = err.Error()
+	m.push(true)
+
+	shouldOpen := wasProbe ||
+		(c.config.MaxConsecutive > 0 && m.consecutive >= c.config.MaxConsecutive) ||
+		(m.filled >= c.config.MinSamples && m.errorRate() >= c.config.FailureRate)
+
+	if shouldOpen {
+		m.openUntil = now.Add(c.config.Cooldown)
+		logrus.Warnf("Translation provider %s circuit opened for %s (error rate %.0f%%, %d consecutive failures)",
+			m.Name, c.config.Cooldown, m.errorRate()*100, m.consecutive)
+	}
+}
+
+func (c *ChainTranslator) stateLocked(m *chainMember, now time.Time) string {
+	if m.openUntil.IsZero() {
+		return breakerClosed
+	}
+	if now.Before(m.openUntil) {
+		return breakerOpen
+	}
+	return breakerHalfOpen
+}
+
+func (m *chainMember) push(failed bool) {
+	m.outcomes[m.next] = failed
+	m.next = (m.next + 1) % len(m.outcomes)
+	if m.filled < len(m.outcomes) {
+		m.filled++
+	}
+}
+
+func (m *chainMember) resetWindow() {
+	for i := range m.outcomes {
+		m.outcomes[i] = false
+	}
+	m.next = 0
+	m.filled = 0
+}
+
+func (m *chainMember) errorRate() float64 {
+	if m.filled == 0 {
+		return 0
+	}
+	failed := 0
+	for i := 0; i < m.filled; i++ {
+		if m.outcomes[i] {
+			failed++
+		}
+	}
+	return float64(failed) / float64(m.filled)
+}
@@ -0,0 +1,130 @@
+package translator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTranslator struct {
+	prefix string
+	err    error
+	calls  int
+}
+
+func (f *fakeTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.prefix + text, nil
+}
+
+func (f *fakeTranslator) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = f.prefix + text
+	}
+	return out, nil
+}
+
+func TestChainTranslatorFallbackAndBreaker(t *testing.T) {
+	primary := &fakeTranslator{prefix: "a:", err: errors.New("down")}
+	secondary := &fakeTranslator{prefix: "b:"}
+
+	chain := NewChainTranslator([]ProviderTranslator{
+		{Name: "anthropic", Translator: primary},
+		{Name: "openai", Translator: secondary},
+	}, BreakerConfig{WindowSize: 10, MinSamples: 5, FailureRate: 0.5, MaxConsecutive: 2, Cooldown: time.Minute})
+
+	now := time.Now()
+	chain.now = func() time.Time { return now }
+
+	// the circuit opens after two consecutive failures
+	for i := 0; i < 2; i++ {
+		got, provider, err := chain.TranslateBatchWithProvider([]string{"x"}, "zh", "en")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b:x"}, got)
+		assert.Equal(t, "openai", provider)
+	}
+	assert.Equal(t, breakerOpen, chain.Stats()[0].State)
+
+	// while open, the primary backend is not called
+	_, _, err := chain.TranslateBatchWithProvider([]string{"x"}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.calls)
+
+	// after the cooldown one probe is let through and closes the circuit on success
+	now = now.Add(2 * time.Minute)
+	primary.err = nil
+	got, provider, err := chain.TranslateBatchWithProvider([]string{"x"}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:x"}, got)
+	assert.Equal(t, "anthropic", provider)
+	assert.Equal(t, breakerClosed, chain.Stats()[0].State)
+}
+
+func TestChainTranslatorAllFailed(t *testing.T) {
+	chain := NewChainTranslator([]ProviderTranslator{
+		{Name: "openai", Translator: &fakeTranslator{err: errors.New("boom")}},
+		{Name: "google-translate", Translator: &fakeTranslator{err: errors.New("quota")}},
+	}, DefaultBreakerConfig())
+
+	_, err := chain.Translate("x", "zh", "en")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "openai: boom")
+	assert.Contains(t, err.Error(), "google-translate: quota")
+}
+
+type namedFakeTranslator struct {
+	fakeTranslator
+}
+
+func (n *namedFakeTranslator) Name() string { return "fake" }
+
+func TestTranslateBatchWithProviderUsesName(t *testing.T) {
+	got, provider, err := TranslateBatchWithProvider(&namedFakeTranslator{fakeTranslator{prefix: "n:"}}, []string{"x"}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"n:x"}, got)
+	assert.Equal(t, "fake", provider)
+}
+
+type fakeLocalizer struct {
+	fakeTranslator
+	localizeErr error
+}
+
+func (f *fakeLocalizer) Localize(req LocalizeRequest) (*LocalizedCaption, error) {
+	if f.localizeErr != nil {
+		return nil, f.localizeErr
+	}
+	return &LocalizedCaption{Caption: "localized " + req.Title}, nil
+}
+
+func TestChainLocalizeHasOwnBreaker(t *testing.T) {
+	llm := &fakeLocalizer{fakeTranslator: fakeTranslator{prefix: "a:"}, localizeErr: errors.New("failed to parse localized caption")}
+	chain := NewChainTranslator([]ProviderTranslator{
+		{Name: "anthropic", Translator: llm},
+		{Name: "google-translate", Translator: &fakeTranslator{prefix: "g:"}},
+	}, BreakerConfig{WindowSize: 10, MinSamples: 5, FailureRate: 0.5, MaxConsecutive: 2, Cooldown: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := chain.Localize(LocalizeRequest{Title: "x"})
+		require.Error(t, err)
+	}
+	assert.Equal(t, breakerOpen, chain.LocalizeStats()[0].State)
+
+	// bad captions do not stop the provider from translating
+	got, provider, err := chain.TranslateBatchWithProvider([]string{"x"}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:x"}, got)
+	assert.Equal(t, "anthropic", provider)
+	assert.Equal(t, breakerClosed, chain.Stats()[0].State)
+}
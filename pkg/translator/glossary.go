@@ -0,0 +1,282 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GlossaryEntry maps a source term to the exact text it must become in the target
+// language. For do-not-translate terms Target equals Source.
+type GlossaryEntry struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// glossaryFile is the on-disk format of one language pair, e.g. glossary/zh-en.json:
+//
+//	{
+//	  "terms": {"小红书": "Xiaohongshu"},
+//	  "do_not_translate": ["iPhone", "@creator_handle"]
+//	}
+type glossaryFile struct {
+	Terms          map[string]string `json:"terms"`
+	DoNotTranslate []string          `json:"do_not_translate"`
+}
+
+// Glossary holds protected terms per language pair. A nil *Glossary is valid and
+// behaves as an empty glossary.
+type Glossary struct {
+	pairs map[string][]GlossaryEntry
+}
+
+// GlossaryAware is implemented by translators that honour a glossary
+type GlossaryAware interface {
+	SetGlossary(g *Glossary)
+}
+
+// NewGlossary creates an empty glossary
+func NewGlossary() *Glossary {
+	return &Glossary{pairs: make(map[string][]GlossaryEntry)}
+}
+
+// LoadGlossary loads every <source>-<target>.json file in dir. Language codes
+// with a region are supported: the pair is split on the last "-", or on "_" when
+// the file name contains one (zh-CN_en-US.json).
+func LoadGlossary(dir string) (*Glossary, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list glossary files: %w", err)
+	}
+
+	g := NewGlossary()
+	for _, file := range files {
+		sourceLang, targetLang, ok := parseGlossaryPair(filepath.Base(file))
+		if !ok {
+			logrus.Warnf("Skipping glossary file %s: name must be <source>-<target>.json", file)
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read glossary %s: %w", file, err)
+		}
+
+		var gf glossaryFile
+		if err := json.Unmarshal(data, &gf); err != nil {
+			return nil, fmt.Errorf("failed to parse glossary %s: %w", file, err)
+		}
+
+		var entries []GlossaryEntry
+		for source, target := range gf.Terms {
+			entries = append(entries, GlossaryEntry{Source: source, Target: target})
+		}
+		for _, term := range gf.DoNotTranslate {
+			entries = append(entries, GlossaryEntry{Source: term, Target: term})
+		}
+		g.Add(sourceLang, targetLang, entries...)
+	}
+
+	return g, nil
+}
+
+// Add adds entries for a language pair
+func (g *Glossary) Add(sourceLang, targetLang string, entries ...GlossaryEntry) {
+	key := glossaryKey(sourceLang, targetLang)
+	for _, e := range entries {
+		if e.Source == "" {
+			continue
+		}
+		if e.Target == "" {
+			e.Target = e.Source
+		}
+		g.pairs[key] = append(g.pairs[key], e)
+	}
+
+	// longest terms first so that "小红书App" is matched before "小红书"
+	sort.SliceStable(g.pairs[key], func(i, j int) bool {
+		return len(g.pairs[key][i].Source) > len(g.pairs[key][j].Source)
+	})
+}
+
+// Len returns the total number of entries across all language pairs
+func (g *Glossary) Len() int {
+	if g == nil {
+		return 0
+	}
+	n := 0
+	for _, entries := range g.pairs {
+		n += len(entries)
+	}
+	return n
+}
+
+// Matching returns the entries of the pair whose source term occurs in any of texts
+func (g *Glossary) Matching(sourceLang, targetLang string, texts ...string) []GlossaryEntry {
+	if g == nil {
+		return nil
+	}
+
+	var matched []GlossaryEntry
+	for _, e := range g.pairs[glossaryKey(sourceLang, targetLang)] {
+		for _, text := range texts {
+			if strings.Contains(text, e.Source) {
+				matched = append(matched, e)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// PromptInstructions returns glossary instructions for an LLM prompt, limited to
+// the terms that occur in texts. It returns "" when no term applies.
+func (g *Glossary) PromptInstructions(sourceLang, targetLang string, texts ...string) string {
+	matched := g.Matching(sourceLang, targetLang, texts...)
+	if len(matched) == 0 {
+		return ""
+	}
+
+	var translate, keep []string
+	for _, e := range matched {
+		if e.Source == e.Target {
+			keep = append(keep, fmt.Sprintf("- %s", e.Source))
+		} else {
+			translate = append(translate, fmt.Sprintf("- %s => %s", e.Source, e.Target))
+		}
+	}
+
+	var b strings.Builder
+	if len(translate) > 0 {
+		b.WriteString("\n\nGlossary: always translate these terms exactly as given:\n")
+		b.WriteString(strings.Join(translate, "\n"))
+	}
+	if len(keep) > 0 {
+		b.WriteString("\n\nDo not translate or alter these terms, keep them exactly as written:\n")
+		b.WriteString(strings.Join(keep, "\n"))
+	}
+	return b.String()
+}
+
+// Mask replaces glossary terms in text with placeholders that machine translation
+// leaves untouched. The returned function restores the placeholders to the target
+// terms in the translated text.
+func (g *Glossary) Mask(sourceLang, targetLang, text string) (string, func(string) string) {
+	matched := g.Matching(sourceLang, targetLang, text)
+	if len(matched) == 0 {
+		return text, func(s string) string { return s }
+	}
+
+	masked := text
+	var targets []string
+	for _, e := range matched {
+		replaced, ok := replaceOutsidePlaceholders(masked, e.Source, fmt.Sprintf("⟦%d⟧", len(targets)))
+		if !ok {
+			continue
+		}
+		masked = replaced
+		targets = append(targets, e.Target)
+	}
+
+	restore := func(s string) string {
+		return placeholderPattern.ReplaceAllStringFunc(s, func(m string) string {
+			idx, err := strconv.Atoi(placeholderPattern.FindStringSubmatch(m)[1])
+			if err != nil || idx >= len(targets) {
+				return m
+			}
+			return targets[idx]
+		})
+	}
+
+	return masked, restore
+}
+
+// Missing returns glossary entries that occur in source but whose target term is
+// absent from translated
+func (g *Glossary) Missing(sourceLang, targetLang, source, translated string) []GlossaryEntry {
+	var missing []GlossaryEntry
+	lower := strings.ToLower(translated)
+	for _, e := range g.Matching(sourceLang, targetLang, source) {
+		if !strings.Contains(lower, strings.ToLower(e.Target)) {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// warnMissingTerms logs a warning for every glossary term that did not survive translation
+func (g *Glossary) warnMissingTerms(sourceLang, targetLang string, sources, translated []string) {
+	if g == nil || len(sources) != len(translated) {
+		return
+	}
+	for i := range sources {
+		for _, e := range g.Missing(sourceLang, targetLang, sources[i], translated[i]) {
+			logrus.Warnf("Glossary term %q expected as %q but missing from translation: %q", e.Source, e.Target, translated[i])
+		}
+	}
+}
+
+// ApplyGlossary sets the glossary on t, and on every backend when t is a ChainTranslator
+func ApplyGlossary(t Translator, g *Glossary) {
+	if chain, ok := t.(*ChainTranslator); ok {
+		for _, m := range chain.members {
+			ApplyGlossary(m.Translator, g)
+		}
+		return
+	}
+	if aware, ok := t.(GlossaryAware); ok {
+		aware.SetGlossary(g)
+	}
+}
+
+var placeholderPattern = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+// replaceOutsidePlaceholders replaces old with new in s, leaving placeholders
+// inserted by earlier terms intact so that a term such as "1" cannot corrupt ⟦1⟧.
+// It reports whether anything was replaced.
+func replaceOutsidePlaceholders(s, old, new string) (string, bool) {
+	var b strings.Builder
+	replaced := false
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(s, -1) {
+		part := s[last:loc[0]]
+		if strings.Contains(part, old) {
+			part = strings.ReplaceAll(part, old, new)
+			replaced = true
+		}
+		b.WriteString(part)
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	part := s[last:]
+	if strings.Contains(part, old) {
+		part = strings.ReplaceAll(part, old, new)
+		replaced = true
+	}
+	b.WriteString(part)
+	return b.String(), replaced
+}
+
+// parseGlossaryPair extracts the language pair from a glossary file name
+func parseGlossaryPair(name string) (sourceLang, targetLang string, ok bool) {
+	pair := strings.TrimSuffix(name, ".json")
+	if source, target, found := strings.Cut(pair, "_"); found {
+		return source, target, source != "" && target != ""
+	}
+	i := strings.LastIndex(pair, "-")
+	if i <= 0 || i == len(pair)-1 {
+		return "", "", false
+	}
+	return pair[:i], pair[i+1:], true
+}
+
+func glossaryKey(sourceLang, targetLang string) string {
+	return strings.ToLower(sourceLang) + "-" + strings.ToLower(targetLang)
+}
@@ -0,0 +1,89 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGlossary(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zh-en.json"),
+		[]byte(`{"terms":{"小红书":"Xiaohongshu"},"do_not_translate":["iPhone"]}`), 0644))
+
+	g, err := LoadGlossary(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, g.Len())
+	assert.Len(t, g.Matching("zh", "en", "我在小红书看到 iPhone"), 2)
+	assert.Empty(t, g.Matching("en", "zh", "小红书"))
+}
+
+func TestLoadGlossaryRegionCodes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "zh-CN-en.json"), []byte(`{"terms":{"小红书":"Xiaohongshu"}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en_zh-TW.json"), []byte(`{"terms":{"Xiaohongshu":"小紅書"}}`), 0644))
+
+	g, err := LoadGlossary(dir)
+	require.NoError(t, err)
+	assert.Len(t, g.Matching("zh-CN", "en", "小红书"), 1)
+	assert.Len(t, g.Matching("en", "zh-TW", "Xiaohongshu"), 1)
+}
+
+func TestGlossaryMaskRestore(t *testing.T) {
+	g := NewGlossary()
+	g.Add("zh", "en",
+		GlossaryEntry{Source: "小红书", Target: "Xiaohongshu"},
+		GlossaryEntry{Source: "小红书App", Target: "Xiaohongshu app"},
+		GlossaryEntry{Source: "@小美"},
+	)
+
+	masked, restore := g.Mask("zh", "en", "打开小红书App，关注@小美和小红书")
+	assert.NotContains(t, masked, "小红书")
+	assert.NotContains(t, masked, "@小美")
+
+	// machine translation may add spaces inside the placeholder
+	translated := "Open ⟦0⟧, follow ⟦ 2 ⟧ and ⟦1⟧"
+	assert.Equal(t, "Open Xiaohongshu app, follow @小美 and Xiaohongshu", restore(translated))
+}
+
+func TestGlossaryMaskNumericTerm(t *testing.T) {
+	g := NewGlossary()
+	g.Add("zh", "en",
+		GlossaryEntry{Source: "小红书", Target: "Xiaohongshu"},
+		GlossaryEntry{Source: "iPhone"},
+		GlossaryEntry{Source: "1", Target: "one"},
+	)
+
+	// "1" must not match inside the placeholders created for the longer terms
+	masked, restore := g.Mask("zh", "en", "小红书 iPhone 1")
+	assert.Equal(t, "⟦0⟧ ⟦1⟧ ⟦2⟧", masked)
+	assert.Equal(t, "Xiaohongshu iPhone one", restore(masked))
+}
+
+func TestGlossaryPromptAndMissing(t *testing.T) {
+	g := NewGlossary()
+	g.Add("zh", "en",
+		GlossaryEntry{Source: "小红书", Target: "Xiaohongshu"},
+		GlossaryEntry{Source: "iPhone"},
+	)
+
+	prompt := g.PromptInstructions("zh", "en", "小红书上的 iPhone")
+	assert.Contains(t, prompt, "小红书 => Xiaohongshu")
+	assert.Contains(t, prompt, "- iPhone")
+	assert.Empty(t, g.PromptInstructions("zh", "en", "没有术语"))
+
+	missing := g.Missing("zh", "en", "小红书上的 iPhone", "iphone on Little Red Book")
+	require.Len(t, missing, 1)
+	assert.Equal(t, "小红书", missing[0].Source)
+}
+
+func TestNilGlossary(t *testing.T) {
+	var g *Glossary
+	masked, restore := g.Mask("zh", "en", "text")
+	assert.Equal(t, "text", masked)
+	assert.Equal(t, "x", restore("x"))
+	assert.Empty(t, g.PromptInstructions("zh", "en", "text"))
+}
@@ -0,0 +1,141 @@
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LibreTranslator uses a self-hosted LibreTranslate server
+type LibreTranslator struct {
+	baseURL    string
+	apiKey     string
+	glossary   *Glossary
+	httpClient *http.Client
+}
+
+// NewLibreTranslator creates a new LibreTranslate translator
+// baseURL is the server root, e.g. http://localhost:5000; apiKey is optional
+func NewLibreTranslator(baseURL, apiKey string) *LibreTranslator {
+	return &LibreTranslator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Name returns the translator name
+func (t *LibreTranslator) Name() string {
+	return "libretranslate"
+}
+
+// SetGlossary sets the glossary whose terms are protected from machine translation
+func (t *LibreTranslator) SetGlossary(g *Glossary) {
+	t.glossary = g
+}
+
+// Translate translates text using LibreTranslate
+func (t *LibreTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	masked, restore := t.glossary.Mask(sourceLang, targetLang, text)
+
+	body, err := t.post(masked, sourceLang, targetLang)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		TranslatedText string `json:"translatedText"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	translated := restore(result.TranslatedText)
+	t.glossary.warnMissingTerms(sourceLang, targetLang, []string{text}, []string{translated})
+	return translated, nil
+}
+
+// TranslateBatch translates multiple texts in a single request
+func (t *LibreTranslator) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
+	if len(texts) == 0 {
+		return []string{}, nil
+	}
+
+	masked := make([]string, len(texts))
+	restores := make([]func(string) string, len(texts))
+	for i, text := range texts {
+		masked[i], restores[i] = t.glossary.Mask(sourceLang, targetLang, text)
+	}
+
+	body, err := t.post(masked, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if len(result.TranslatedText) != len(texts) {
+		return nil, fmt.Errorf("got %d translations, expected %d", len(result.TranslatedText), len(texts))
+	}
+
+	for i := range result.TranslatedText {
+		result.TranslatedText[i] = restores[i](result.TranslatedText[i])
+	}
+	t.glossary.warnMissingTerms(sourceLang, targetLang, texts, result.TranslatedText)
+	return result.TranslatedText, nil
+}
+
+// post sends a translate request; q is either a string or a []string
+func (t *LibreTranslator) post(q interface{}, sourceLang, targetLang string) ([]byte, error) {
+	reqBody := map[string]interface{}{
+		"q":      q,
+		"source": sourceLang,
+		"target": targetLang,
+		"format": "text",
+	}
+	if t.apiKey != "" {
+		reqBody["api_key"] = t.apiKey
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", t.baseURL+"/translate", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: %s (status: %d)", string(body), resp.StatusCode)
+	}
+
+	return body, nil
+}
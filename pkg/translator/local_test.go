@@ -0,0 +1,98 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalAITranslator(t *testing.T) {
+	var gotAuth string
+	var gotJSONMode bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		gotAuth = r.Header.Get("Authorization")
+
+		var req struct {
+			Model          string              `json:"model"`
+			Messages       []map[string]string `json:"messages"`
+			ResponseFormat map[string]string   `json:"response_format"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "qwen2.5", req.Model)
+		gotJSONMode = req.ResponseFormat["type"] == "json_object"
+
+		content := "Hello"
+		if gotJSONMode {
+			content = `{"translations":[{"index":0,"text":"Title"},{"index":1,"text":"Body"}]}`
+		}
+		fmt.Fprintf(w, `{"choices":[{"message":{"content":%q}}]}`, content)
+	}))
+	defer server.Close()
+
+	tr := NewLocalAITranslator(server.URL+"/v1/", "", "")
+	assert.Equal(t, "local", tr.Name())
+
+	got, err := tr.Translate("你好", "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", got)
+	assert.Empty(t, gotAuth, "no Authorization header without an API key")
+	assert.False(t, gotJSONMode)
+
+	batch, err := tr.TranslateBatch([]string{"标题", "正文"}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Title", "Body"}, batch)
+	assert.True(t, gotJSONMode)
+}
+
+func TestLibreTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/translate", r.URL.Path)
+
+		var req map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "zh", req["source"])
+		assert.Equal(t, "en", req["target"])
+		assert.Equal(t, "secret", req["api_key"])
+
+		switch q := req["q"].(type) {
+		case string:
+			fmt.Fprintf(w, `{"translatedText":%q}`, "en:"+q)
+		case []interface{}:
+			out := make([]string, len(q))
+			for i, v := range q {
+				out[i] = "en:" + v.(string)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"translatedText": out})
+		}
+	}))
+	defer server.Close()
+
+	tr := NewLibreTranslator(server.URL, "secret")
+
+	got, err := tr.Translate("你好", "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, "en:你好", got)
+
+	batch, err := tr.TranslateBatch([]string{"a", "b"}, "zh", "en")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en:a", "en:b"}, batch)
+}
+
+func TestLibreTranslatorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"unsupported language"}`, http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	_, err := NewLibreTranslator(server.URL, "").Translate("x", "zh", "xx")
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "status: 400"))
+}
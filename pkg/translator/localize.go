@@ -0,0 +1,162 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// LocalizeRequest describes a post to be rewritten as a platform-native caption
+type LocalizeRequest struct {
+	Platform   string
+	MaxLength  int  // maximum caption length in characters
+	Weighted   bool // count CJK characters and emojis as two, as Twitter does
+	Tone       string
+	Audience   string
+	SourceLang string
+	TargetLang string
+
+	Title       string
+	Description string
+	Tags        []string
+}
+
+// LocalizedCaption is a caption adapted for a platform
+type LocalizedCaption struct {
+	Caption  string   `json:"caption"`  // ready-to-post text: hook line, body, emojis, CTA
+	Hashtags []string `json:"hashtags"` // hashtags without the leading '#'
+	Provider string   `json:"-"`        // backend that produced the caption
+}
+
+// Localizer is implemented by translators that can rewrite content for a platform
+// instead of translating it literally
+type Localizer interface {
+	Localize(req LocalizeRequest) (*LocalizedCaption, error)
+}
+
+// Localize rewrites the post as a platform-native caption in the target language
+func (t *AITranslator) Localize(req LocalizeRequest) (*LocalizedCaption, error) {
+	prompt := buildLocalizePrompt(req, t.glossary.PromptInstructions(req.SourceLang, req.TargetLang, req.Title, req.Description))
+
+	raw, err := t.complete(prompt, true)
+	if err != nil {
+		return nil, err
+	}
+
+	caption, err := parseLocalizeResponse(raw, req.MaxLength, req.Weighted)
+	if err != nil {
+		return nil, err
+	}
+
+	caption.Provider = t.Name()
+	return caption, nil
+}
+
+// Localize rewrites the post using the first healthy backend that supports localization.
+// Failures are recorded in the localization breakers only, never in the translation ones.
+func (c *ChainTranslator) Localize(req LocalizeRequest) (*LocalizedCaption, error) {
+	if len(c.localizers) == 0 {
+		return nil, fmt.Errorf("no translation provider supports localization")
+	}
+
+	var caption *LocalizedCaption
+	provider, err := c.run(c.localizers, func(t Translator) error {
+		result, err := t.(Localizer).Localize(req)
+		if err != nil {
+			return err
+		}
+		caption = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	caption.Provider = provider
+	return caption, nil
+}
+
+// buildLocalizePrompt builds the LLM prompt for a localized caption
+func buildLocalizePrompt(req LocalizeRequest, instructions string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "You are a social media editor. Rewrite the following %s post as a native %s post written in %s. Adapt it for the platform rather than translating it literally.\n\n", req.SourceLang, req.Platform, req.TargetLang)
+	fmt.Fprintf(&b, "Platform: %s\n", req.Platform)
+	if req.MaxLength > 0 {
+		fmt.Fprintf(&b, "Maximum length: %d characters in total, including emojis and hashtags", req.MaxLength)
+		if req.Weighted {
+			b.WriteString(" (CJK characters and emojis count as two characters each)")
+		}
+		b.WriteString("\n")
+	}
+	if req.Tone != "" {
+		fmt.Fprintf(&b, "Tone: %s\n", req.Tone)
+	}
+	if req.Audience != "" {
+		fmt.Fprintf(&b, "Audience: %s\n", req.Audience)
+	}
+
+	b.WriteString("\nStructure: open with a short attention-grabbing hook line, then the key message, use a few relevant emojis, and end with a clear call to action. ")
+	b.WriteString("Put relevant hashtags at the end of the caption and also list them in \"hashtags\". Do not invent facts that are not in the original post.")
+	b.WriteString(instructions)
+	b.WriteString("\n\nRespond with ONLY a JSON object of the form {\"caption\":\"<full post text>\",\"hashtags\":[\"<tag without #>\"]}.\n\n")
+
+	fmt.Fprintf(&b, "Original title: %s\n", req.Title)
+	fmt.Fprintf(&b, "Original description: %s\n", req.Description)
+	if len(req.Tags) > 0 {
+		fmt.Fprintf(&b, "Original tags: %s\n", strings.Join(req.Tags, ", "))
+	}
+
+	return b.String()
+}
+
+// parseLocalizeResponse parses and validates the LLM response
+func parseLocalizeResponse(raw string, maxLength int, weighted bool) (*LocalizedCaption, error) {
+	var caption LocalizedCaption
+	if err := json.Unmarshal([]byte(extractJSONObject(raw)), &caption); err != nil {
+		return nil, fmt.Errorf("failed to parse localized caption: %w", err)
+	}
+
+	caption.Caption = strings.TrimSpace(caption.Caption)
+	if caption.Caption == "" {
+		return nil, fmt.Errorf("localized caption is empty")
+	}
+
+	if n := CaptionLength(caption.Caption, weighted); maxLength > 0 && n > maxLength {
+		return nil, fmt.Errorf("localized caption is %d characters, limit is %d", n, maxLength)
+	}
+
+	hashtags := make([]string, 0, len(caption.Hashtags))
+	for _, tag := range caption.Hashtags {
+		if tag = strings.TrimSpace(strings.TrimLeft(tag, "#")); tag != "" {
+			hashtags = append(hashtags, tag)
+		}
+	}
+	caption.Hashtags = hashtags
+
+	return &caption, nil
+}
+
+// CaptionLength returns the length of a caption in characters. When weighted is
+// true it follows Twitter's counting rules: characters outside Latin and common
+// punctuation ranges (CJK, emojis) count as two.
+func CaptionLength(text string, weighted bool) int {
+	if !weighted {
+		return utf8.RuneCountInString(text)
+	}
+
+	n := 0
+	for _, r := range text {
+		switch {
+		case r <= 0x10FF, // Latin, Greek, Cyrillic, Hebrew, Arabic, Indic, Thai...
+			r >= 0x2000 && r <= 0x200D, // spaces and zero-width joiners
+			r >= 0x2010 && r <= 0x201F, // dashes and quotes
+			r >= 0x2032 && r <= 0x2037: // primes
+			n++
+		default:
+			n += 2
+		}
+	}
+	return n
+}
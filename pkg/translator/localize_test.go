@@ -0,0 +1,45 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalizeResponse(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		raw := "```json\n{\"caption\":\"Weekend coffee run ☕ Which one would you pick?\",\"hashtags\":[\"#coffee\",\" cafe \",\"\"]}\n```"
+		got, err := parseLocalizeResponse(raw, 280, false)
+		require.NoError(t, err)
+		assert.Equal(t, "Weekend coffee run ☕ Which one would you pick?", got.Caption)
+		assert.Equal(t, []string{"coffee", "cafe"}, got.Hashtags)
+	})
+
+	t.Run("empty caption", func(t *testing.T) {
+		_, err := parseLocalizeResponse(`{"caption":"  ","hashtags":[]}`, 280, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("over limit", func(t *testing.T) {
+		_, err := parseLocalizeResponse(`{"caption":"this caption is too long"}`, 10, false)
+		assert.Error(t, err)
+	})
+
+	t.Run("weighted over limit", func(t *testing.T) {
+		// 6 CJK characters fit in 10 runes but weigh 12 on Twitter
+		_, err := parseLocalizeResponse(`{"caption":"周末咖啡时光"}`, 10, false)
+		assert.NoError(t, err)
+		_, err = parseLocalizeResponse(`{"caption":"周末咖啡时光"}`, 10, true)
+		assert.Error(t, err)
+	})
+}
+
+func TestCaptionLength(t *testing.T) {
+	assert.Equal(t, 5, CaptionLength("hello", true))
+	assert.Equal(t, 4, CaptionLength("咖啡", true))
+	assert.Equal(t, 2, CaptionLength("咖啡", false))
+	assert.Equal(t, 3, CaptionLength("a☕", true))
+	// curly quotes count as one, the ellipsis as two
+	assert.Equal(t, 6, CaptionLength("“ok”…", true))
+}
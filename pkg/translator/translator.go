@@ -17,9 +17,35 @@ type Translator interface {
 	TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error)
 }
 
+// ProviderReporter is implemented by translators that can tell which backend
+// produced a translation, e.g. ChainTranslator
+type ProviderReporter interface {
+	TranslateBatchWithProvider(texts []string, sourceLang, targetLang string) ([]string, string, error)
+}
+
+// TranslateBatchWithProvider translates texts and returns the name of the backend
+// that produced the result, for auditing
+func TranslateBatchWithProvider(t Translator, texts []string, sourceLang, targetLang string) ([]string, string, error) {
+	if reporter, ok := t.(ProviderReporter); ok {
+		return reporter.TranslateBatchWithProvider(texts, sourceLang, targetLang)
+	}
+
+	results, err := t.TranslateBatch(texts, sourceLang, targetLang)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := ""
+	if named, ok := t.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+	return results, name, nil
+}
+
 // GoogleTranslator uses Google Translate API
 type GoogleTranslator struct {
 	apiKey     string
+	glossary   *Glossary
 	httpClient *http.Client
 }
 
@@ -33,8 +59,57 @@ func NewGoogleTranslator(apiKey string) *GoogleTranslator {
 	}
 }
 
+// Name returns the translator name
+func (t *GoogleTranslator) Name() string {
+	return "google-translate"
+}
+
+// SetGlossary sets the glossary whose terms are protected from machine translation
+func (t *GoogleTranslator) SetGlossary(g *Glossary) {
+	t.glossary = g
+}
+
 // Translate translates text using Google Translate
+// Glossary terms are masked before translation and restored afterwards
 func (t *GoogleTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	masked, restore := t.glossary.Mask(sourceLang, targetLang, text)
+
+	translated, err := t.translate(masked, sourceLang, targetLang)
+	if err != nil {
+		return "", err
+	}
+
+	translated = restore(translated)
+	t.glossary.warnMissingTerms(sourceLang, targetLang, []string{text}, []string{translated})
+	return translated, nil
+}
+
+// TranslateBatch translates multiple texts
+func (t *GoogleTranslator) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
+	masked := make([]string, len(texts))
+	restores := make([]func(string) string, len(texts))
+	for i, text := range texts {
+		masked[i], restores[i] = t.glossary.Mask(sourceLang, targetLang, text)
+	}
+
+	translated, err := t.translateBatch(masked, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("got %d translations, expected %d", len(translated), len(texts))
+	}
+
+	for i := range translated {
+		translated[i] = restores[i](translated[i])
+	}
+	t.glossary.warnMissingTerms(sourceLang, targetLang, texts, translated)
+	return translated, nil
+}
+
+// translate translates a single text through the Google API
+func (t *GoogleTranslator) translate(text, sourceLang, targetLang string) (string, error) {
 	// If no API key, use free service (limited)
 	if t.apiKey == "" {
 		return t.translateFree(text, sourceLang, targetLang)
@@ -91,8 +166,8 @@ func (t *GoogleTranslator) Translate(text, sourceLang, targetLang string) (strin
 	return result.Data.Translations[0].TranslatedText, nil
 }
 
-// TranslateBatch translates multiple texts
-func (t *GoogleTranslator) TranslateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
+// translateBatch translates multiple texts through the Google API
+func (t *GoogleTranslator) translateBatch(texts []string, sourceLang, targetLang string) ([]string, error) {
 	if t.apiKey == "" {
 		var results []string
 		for _, text := range texts {
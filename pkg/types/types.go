@@ -31,11 +31,25 @@ type ProcessedContent struct {
 	MediaURLs   []string    `json:"media_urls"`
 	Tags        []string    `json:"tags"`
 
+	// MediaFallbackURLs holds alternatives for each of MediaURLs (same order), tried
+	// when the preferred URL cannot be downloaded or decoded
+	MediaFallbackURLs [][]string `json:"media_fallback_urls,omitempty"`
+
+	// MediaFiles holds local copies of MediaURLs prepared for the platform (same order)
+	MediaFiles []string `json:"media_files,omitempty"`
+
+	// Video info, only set for video content
+	CoverURL      string `json:"cover_url,omitempty"`
+	VideoDuration int    `json:"video_duration,omitempty"` // seconds
+
 	// Original info
 	OriginalTitle       string `json:"original_title"`
 	OriginalDescription string `json:"original_description"`
 	SourceID            string `json:"source_id"`
 	SourceURL           string `json:"source_url"`
+
+	// TranslationProvider records which translation backend produced the text
+	TranslationProvider string `json:"translation_provider,omitempty"`
 }
 
 // PublishRequest represents a request to publish content
@@ -61,6 +75,7 @@ type ScheduledJob struct {
 	ID          string          `json:"id"`
 	FeedID      string          `json:"feed_id"`
 	XsecToken   string          `json:"xsec_token"`
+	Account     string          `json:"account"` // Xiaohongshu account the job belongs to
 	Platforms   []Platform      `json:"platforms"`
 	ScheduledAt time.Time       `json:"scheduled_at"`
 	Status      JobStatus       `json:"status"`
@@ -21,7 +21,7 @@ func setupRoutes(appServer *AppServer) *gin.Engine {
 	router.Use(corsMiddleware())
 
 	// 健康检查
-	router.GET("/health", healthHandler)
+	router.GET("/health", appServer.healthHandler)
 
 	// MCP 端点 - 使用官方 SDK 的 Streamable HTTP Handler
 	mcpHandler := mcp.NewStreamableHTTPHandler(
@@ -37,12 +37,15 @@ func setupRoutes(appServer *AppServer) *gin.Engine {
 
 	// API 路由组
 	api := router.Group("/api/v1")
+	api.Use(accountMiddleware(appServer.xiaohongshuService.accounts))
 	{
 		api.GET("/login/status", appServer.checkLoginStatusHandler)
 		api.GET("/login/qrcode", appServer.getLoginQrcodeHandler)
+		api.GET("/login/sessions/:id", appServer.getLoginSessionHandler)
 		api.DELETE("/login/cookies", appServer.deleteCookiesHandler)
 		api.POST("/publish", appServer.publishHandler)
 		api.POST("/publish_video", appServer.publishVideoHandler)
+		api.POST("/media/upload", appServer.uploadMediaHandler)
 		api.GET("/feeds/list", appServer.listFeedsHandler)
 		api.GET("/feeds/search", appServer.searchFeedsHandler)
 		api.POST("/feeds/search", appServer.searchFeedsHandler)
@@ -50,6 +53,16 @@ func setupRoutes(appServer *AppServer) *gin.Engine {
 		api.POST("/user/profile", appServer.userProfileHandler)
 		api.POST("/feeds/comment", appServer.postCommentHandler)
 		api.GET("/user/me", appServer.myProfileHandler)
+		api.GET("/browser/pool", appServer.browserPoolHandler)
+		api.GET("/queue", appServer.actionQueueHandler)
+		api.GET("/selectors", appServer.selectorsHandler)
+		api.POST("/selectors/reload", appServer.reloadSelectorsHandler)
+		api.GET("/accounts", appServer.listAccountsHandler)
+		api.POST("/accounts", appServer.addAccountHandler)
+		api.DELETE("/accounts/:name", appServer.removeAccountHandler)
+		api.PUT("/accounts/:name/browser", appServer.setAccountBrowserHandler)
+		api.GET("/diagnostics/:id", appServer.getDiagnosticsHandler)
+		api.GET("/diagnostics/:id/:file", appServer.getDiagnosticsFileHandler)
 	}
 
 	return router
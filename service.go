@@ -3,27 +3,50 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/mattn/go-runewidth"
 	"github.com/sirupsen/logrus"
-	"github.com/xpzouying/headless_browser"
+	"github.com/xpzouying/xiaohongshu-mcp/accounts"
 	"github.com/xpzouying/xiaohongshu-mcp/browser"
-	"github.com/xpzouying/xiaohongshu-mcp/configs"
 	"github.com/xpzouying/xiaohongshu-mcp/cookies"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/actionqueue"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/diagnostics"
 	"github.com/xpzouying/xiaohongshu-mcp/pkg/downloader"
+	"github.com/xpzouying/xiaohongshu-mcp/pkg/media"
 	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
 )
 
 // XiaohongshuService 小红书业务服务
-type XiaohongshuService struct{}
+type XiaohongshuService struct {
+	accounts *accounts.Registry
+	queues   *actionqueue.Manager
 
-// NewXiaohongshuService 创建小红书服务实例
-func NewXiaohongshuService() *XiaohongshuService {
-	return &XiaohongshuService{}
+	// 每个账号使用独立的浏览器池，加载各自的 cookies，首次使用时创建
+	poolConfig browser.PoolConfig
+	poolsMu    sync.Mutex
+	pools      map[string]*browser.Pool
+
+	logins *loginSessions
+
+	// diagnostics 操作失败时保存页面诊断信息，为 nil 时不保存
+	diagnostics *diagnostics.Store
+}
+
+// NewXiaohongshuService 创建小红书服务实例，各账号的浏览器池按 poolConfig 创建，操作按账号在 queues 中排队
+func NewXiaohongshuService(registry *accounts.Registry, poolConfig browser.PoolConfig, queues *actionqueue.Manager) *XiaohongshuService {
+	return &XiaohongshuService{
+		accounts:   registry,
+		queues:     queues,
+		poolConfig: poolConfig,
+		pools:      map[string]*browser.Pool{},
+		logins:     newLoginSessions(),
+	}
 }
 
 // PublishRequest 发布请求
@@ -32,6 +55,46 @@ type PublishRequest struct {
 	Content string   `json:"content" binding:"required"`
 	Images  []string `json:"images" binding:"required,min=1"`
 	Tags    []string `json:"tags,omitempty"`
+	// BestEffort 部分图片下载失败时，使用下载成功的图片继续发布
+	BestEffort bool `json:"best_effort,omitempty"`
+	PublishSettings
+}
+
+// PublishSettings 图文和视频共用的发布设置
+type PublishSettings struct {
+	ScheduleAt string `json:"schedule_at,omitempty"` // 定时发布时间，RFC3339 格式，需在 1 小时至 14 天之间
+	Visibility string `json:"visibility,omitempty"`  // 可见范围：public、private（仅自己可见）、friends（仅互关好友可见）
+	Original   bool   `json:"original,omitempty"`    // 声明原创
+}
+
+// toOptions 解析并校验发布设置
+func (p PublishSettings) toOptions() (xiaohongshu.PublishOptions, error) {
+	var opts xiaohongshu.PublishOptions
+
+	visibility, err := xiaohongshu.ParseVisibility(p.Visibility)
+	if err != nil {
+		return opts, err
+	}
+	opts.Visibility = visibility
+	opts.Original = p.Original
+
+	if p.ScheduleAt != "" {
+		at, err := time.Parse(time.RFC3339, p.ScheduleAt)
+		if err != nil {
+			return opts, fmt.Errorf("schedule_at 格式错误，需为 RFC3339 格式: %w", err)
+		}
+		opts.ScheduleAt = at
+	}
+
+	return opts, opts.Validate(time.Now())
+}
+
+// status 返回发布后的状态描述
+func (p PublishSettings) status() string {
+	if p.ScheduleAt != "" {
+		return "已定时发布"
+	}
+	return "发布完成"
 }
 
 // LoginStatusResponse 登录状态响应
@@ -45,32 +108,47 @@ type LoginQrcodeResponse struct {
 	Timeout    string `json:"timeout"`
 	IsLoggedIn bool   `json:"is_logged_in"`
 	Img        string `json:"img,omitempty"`
+	SessionID  string `json:"session_id,omitempty"` // 登录会话 ID，用于轮询扫码状态
 }
 
 // PublishResponse 发布响应
 type PublishResponse struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Images  int    `json:"images"`
-	Status  string `json:"status"`
-	PostID  string `json:"post_id,omitempty"`
+	Title        string   `json:"title"`
+	Content      string   `json:"content"`
+	Images       int      `json:"images"`
+	FailedImages []string `json:"failed_images,omitempty"` // best-effort 模式下下载失败的图片
+	Status       string   `json:"status"`
+	ScheduleAt   string   `json:"schedule_at,omitempty"`
+	PostID       string   `json:"post_id,omitempty"`
+	XsecToken    string   `json:"xsec_token,omitempty"`
+	URL          string   `json:"url,omitempty"`
 }
 
-// PublishVideoRequest 发布视频请求（仅支持本地单个视频文件）
+// PublishVideoRequest 发布视频请求（单个视频：本地路径、HTTP/HTTPS 链接、媒体句柄、data URI 或 base64）
 type PublishVideoRequest struct {
 	Title   string   `json:"title" binding:"required"`
 	Content string   `json:"content" binding:"required"`
 	Video   string   `json:"video" binding:"required"`
 	Tags    []string `json:"tags,omitempty"`
+
+	// 封面（可选，二选一）：自定义封面图片，或截取视频第 CoverTime 秒的画面
+	// CoverTime 为指针，以便区分未设置和 0（第一帧）
+	Cover     string   `json:"cover,omitempty"`
+	CoverTime *float64 `json:"cover_time,omitempty"`
+
+	PublishSettings
 }
 
 // PublishVideoResponse 发布视频响应
 type PublishVideoResponse struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Video   string `json:"video"`
-	Status  string `json:"status"`
-	PostID  string `json:"post_id,omitempty"`
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	Video      string `json:"video"` // 内联上传的视频返回媒体句柄，不回显 base64
+	Status     string `json:"status"`
+	ScheduleAt string `json:"schedule_at,omitempty"`
+	PostID     string `json:"post_id,omitempty"`
+	XsecToken  string `json:"xsec_token,omitempty"`
+	URL        string `json:"url,omitempty"`
 }
 
 // FeedsListResponse Feeds列表响应
@@ -86,81 +164,91 @@ type UserProfileResponse struct {
 	Feeds         []xiaohongshu.Feed             `json:"feeds"`
 }
 
-// DeleteCookies 删除 cookies 文件，用于登录重置
-func (s *XiaohongshuService) DeleteCookies(ctx context.Context) error {
-	cookiePath := cookies.GetCookiesFilePath()
-	cookieLoader := cookies.NewLoadCookie(cookiePath)
-	return cookieLoader.DeleteCookies()
+// DeleteCookies 删除当前账号的 cookies 文件，用于登录重置，返回被删除的文件路径
+func (s *XiaohongshuService) DeleteCookies(ctx context.Context) (string, error) {
+	account, err := s.accounts.Get(accounts.FromContext(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	if err := account.Cookies().DeleteCookies(); err != nil {
+		return "", err
+	}
+
+	// 池中的浏览器仍持有旧 cookies，需要重建
+	s.refreshPool(account.Name)
+	s.accounts.SetLoginState(account.Name, false)
+	return account.CookiesPath, nil
 }
 
 // CheckLoginStatus 检查登录状态
 func (s *XiaohongshuService) CheckLoginStatus(ctx context.Context) (*LoginStatusResponse, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityRead, "check_login_status")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	loginAction := xiaohongshu.NewLogin(page)
 
 	isLoggedIn, err := loginAction.CheckLoginStatus(ctx)
 	if err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "check_login_status", err)
 	}
 
+	account := accounts.FromContext(ctx)
+	s.accounts.SetLoginState(account, isLoggedIn)
+
 	response := &LoginStatusResponse{
 		IsLoggedIn: isLoggedIn,
-		Username:   configs.Username,
+		Username:   account,
 	}
 
 	return response, nil
 }
 
-// GetLoginQrcode 获取登录的扫码二维码
-func (s *XiaohongshuService) GetLoginQrcode(ctx context.Context) (*LoginQrcodeResponse, error) {
-	b := newBrowser()
-	page := b.NewPage()
-
-	deferFunc := func() {
-		_ = page.Close()
-		b.Close()
-	}
+// sessionCheckQueueWait 后台会话检查在操作队列中最多等待的时间，账号忙时跳过本次检查
+const sessionCheckQueueWait = 10 * time.Second
 
-	loginAction := xiaohongshu.NewLogin(page)
+// ErrQueueBusy 在限定时间内没有轮到本次操作
+var ErrQueueBusy = errors.New("账号操作队列繁忙")
 
-	img, loggedIn, err := loginAction.FetchQrcodeImage(ctx)
-	if err != nil || loggedIn {
-		defer deferFunc()
-	}
+// ValidateLogin 使用浏览器池中的页面检查登录状态，供后台会话检查使用。
+// 最多排队 sessionCheckQueueWait，账号正在执行其他操作时返回 ErrQueueBusy
+func (s *XiaohongshuService) ValidateLogin(ctx context.Context) (bool, error) {
+	page, release, err := s.acquirePageWithin(ctx, actionqueue.PriorityRead, "validate_login", sessionCheckQueueWait)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
+	defer release()
 
-	timeout := 4 * time.Minute
+	isLoggedIn, err := xiaohongshu.NewLogin(page).CheckLoginStatus(ctx)
+	if err != nil {
+		return false, s.diagnose(ctx, page, "check_login_status", err)
+	}
 
-	if !loggedIn {
-		go func() {
-			ctxTimeout, cancel := context.WithTimeout(context.Background(), timeout)
-			defer cancel()
-			defer deferFunc()
+	s.accounts.SetLoginState(accounts.FromContext(ctx), isLoggedIn)
+	return isLoggedIn, nil
+}
 
-			if loginAction.WaitForLogin(ctxTimeout) {
-				if er := saveCookies(page); er != nil {
-					logrus.Errorf("failed to save cookies: %v", er)
-				}
-			}
-		}()
+// GetLoginQrcode 获取登录的扫码二维码，并开始一次登录会话，可用返回的 SessionID 查询扫码结果
+func (s *XiaohongshuService) GetLoginQrcode(ctx context.Context) (*LoginQrcodeResponse, error) {
+	session, err := s.StartLoginSession(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	loggedIn := session.Status == LoginConfirmed
 	return &LoginQrcodeResponse{
 		Timeout: func() string {
 			if loggedIn {
 				return "0s"
 			}
-			return timeout.String()
+			return time.Until(session.ExpiresAt).Round(time.Second).String()
 		}(),
-		Img:        img,
+		Img:        session.Img,
 		IsLoggedIn: loggedIn,
+		SessionID:  session.ID,
 	}, nil
 }
 
@@ -173,11 +261,17 @@ func (s *XiaohongshuService) PublishContent(ctx context.Context, req *PublishReq
 		return nil, fmt.Errorf("标题长度超过限制")
 	}
 
+	opts, err := req.toOptions()
+	if err != nil {
+		return nil, err
+	}
+
 	// 处理图片：下载URL图片或使用本地路径
-	imagePaths, err := s.processImages(req.Images)
+	images, err := s.processImages(ctx, req.Images, req.BestEffort)
 	if err != nil {
 		return nil, err
 	}
+	imagePaths := images.Paths
 
 	// 构建发布内容
 	content := xiaohongshu.PublishImageContent{
@@ -185,107 +279,247 @@ func (s *XiaohongshuService) PublishContent(ctx context.Context, req *PublishReq
 		Content:    req.Content,
 		Tags:       req.Tags,
 		ImagePaths: imagePaths,
+		Options:    opts,
 	}
 
 	// 执行发布
-	if err := s.publishContent(ctx, content); err != nil {
+	note, err := s.publishContent(ctx, content)
+	if err != nil {
 		logrus.Errorf("发布内容失败: title=%s %v", content.Title, err)
 		return nil, err
 	}
 
 	response := &PublishResponse{
-		Title:   req.Title,
-		Content: req.Content,
-		Images:  len(imagePaths),
-		Status:  "发布完成",
+		Title:      req.Title,
+		Content:    req.Content,
+		Images:     len(imagePaths),
+		Status:     req.status(),
+		ScheduleAt: req.ScheduleAt,
+	}
+	for _, failed := range images.Failed {
+		response.FailedImages = append(response.FailedImages, failed.URL)
+	}
+	if note != nil {
+		response.PostID = note.NoteID
+		response.XsecToken = note.XsecToken
+		response.URL = note.URL
 	}
 
 	return response, nil
 }
 
 // processImages 处理图片列表，支持URL下载和本地路径
-func (s *XiaohongshuService) processImages(images []string) ([]string, error) {
-	processor := downloader.NewImageProcessor()
-	return processor.ProcessImages(images)
+func (s *XiaohongshuService) processImages(ctx context.Context, images []string, bestEffort bool) (*downloader.ProcessResult, error) {
+	processor := downloader.NewImageProcessor(downloader.WithBestEffort(bestEffort))
+	return processor.Process(ctx, images)
 }
 
 // publishContent 执行内容发布
-func (s *XiaohongshuService) publishContent(ctx context.Context, content xiaohongshu.PublishImageContent) error {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+func (s *XiaohongshuService) publishContent(ctx context.Context, content xiaohongshu.PublishImageContent) (*xiaohongshu.PublishedNote, error) {
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityPublish, "publish_content")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action, err := xiaohongshu.NewPublishImageAction(page)
 	if err != nil {
-		return err
+		return nil, s.diagnose(ctx, page, "publish_content", err)
 	}
 
 	// 执行发布
-	return action.Publish(ctx, content)
+	note, err := action.Publish(ctx, content)
+	if err != nil {
+		return nil, s.diagnose(ctx, page, "publish_content", err)
+	}
+	return note, nil
 }
 
-// PublishVideo 发布视频（本地文件）
+// PublishVideo 发布视频
 func (s *XiaohongshuService) PublishVideo(ctx context.Context, req *PublishVideoRequest) (*PublishVideoResponse, error) {
 	// 标题长度校验
 	if titleWidth := runewidth.StringWidth(req.Title); titleWidth > 40 {
 		return nil, fmt.Errorf("标题长度超过限制")
 	}
 
-	// 本地视频文件校验
 	if req.Video == "" {
-		return nil, fmt.Errorf("必须提供本地视频文件")
+		return nil, fmt.Errorf("必须提供视频文件")
 	}
-	if _, err := os.Stat(req.Video); err != nil {
-		return nil, fmt.Errorf("视频文件不存在或不可访问: %v", err)
+
+	opts, err := req.toOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	videoPath, videoSource, release, err := s.resolveVideo(ctx, req.Video)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// 启动浏览器前先校验视频格式、编码、时长、分辨率和大小
+	info, err := media.ProbeVideo(videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法解析视频文件: %w", err)
+	}
+	if err := xiaohongshuVideoLimits.Validate(info); err != nil {
+		return nil, err
+	}
+	logrus.Infof("视频校验通过: %s %s %dx%d %s", info.Container, info.Codec, info.Width, info.Height, info.Duration)
+
+	coverPath, coverTime, err := s.resolveVideoCover(ctx, req, info.Duration)
+	if err != nil {
+		return nil, err
 	}
 
 	// 构建发布内容
 	content := xiaohongshu.PublishVideoContent{
-		Title:     req.Title,
-		Content:   req.Content,
-		Tags:      req.Tags,
-		VideoPath: req.Video,
+		Title:         req.Title,
+		Content:       req.Content,
+		Tags:          req.Tags,
+		VideoPath:     videoPath,
+		CoverPath:     coverPath,
+		CoverTime:     coverTime,
+		VideoDuration: info.Duration,
+		Options:       opts,
 	}
 
 	// 执行发布
-	if err := s.publishVideo(ctx, content); err != nil {
+	note, err := s.publishVideo(ctx, content)
+	if err != nil {
 		return nil, err
 	}
 
 	resp := &PublishVideoResponse{
-		Title:   req.Title,
-		Content: req.Content,
-		Video:   req.Video,
-		Status:  "发布完成",
+		Title:      req.Title,
+		Content:    req.Content,
+		Video:      videoSource,
+		Status:     req.status(),
+		ScheduleAt: req.ScheduleAt,
+	}
+	if note != nil {
+		resp.PostID = note.NoteID
+		resp.XsecToken = note.XsecToken
+		resp.URL = note.URL
 	}
 	return resp, nil
 }
 
-// publishVideo 执行视频发布
-func (s *XiaohongshuService) publishVideo(ctx context.Context, content xiaohongshu.PublishVideoContent) error {
-	b := newBrowser()
-	defer b.Close()
+// xiaohongshuVideoLimits 小红书创作者中心的视频限制
+var xiaohongshuVideoLimits = media.VideoLimits{
+	Containers:   []string{"mp4", "mov"},
+	Codecs:       []string{"h264", "h265"},
+	MinDuration:  time.Second,
+	MaxDuration:  60 * time.Minute,
+	MinShortSide: 360,
+	MaxLongSide:  4096,
+	MaxSize:      media.MaxVideoSize,
+}
+
+// resolveVideo 将视频输入转换为本地文件路径
+// 支持 HTTP/HTTPS 链接、媒体句柄、data URI、base64 和本地路径
+// source 是响应中展示的视频来源，内联视频为保存后的媒体句柄
+// 发布完成后需调用 release，在此之前缓存清理不会删除下载的视频
+func (s *XiaohongshuService) resolveVideo(ctx context.Context, video string) (path, source string, release func(), err error) {
+	release = func() {}
+
+	if downloader.IsHTTPURL(video) {
+		fetcher, err := media.Default()
+		if err != nil {
+			return "", "", nil, err
+		}
+		file, err := fetcher.Fetch(ctx, video, xiaohongshuVideoLimits.MaxSize)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("下载视频失败: %w", err)
+		}
+		if !file.IsVideo() {
+			file.Release()
+			return "", "", nil, fmt.Errorf("不是有效的视频文件: %s", file.ContentType)
+		}
+		return file.Path, video, file.Release, nil
+	}
+
+	// 媒体句柄、data URI 或 base64 视频先保存到服务端
+	if upload, ok, err := downloader.ResolveInline(video, xiaohongshuVideoLimits.MaxSize); ok {
+		if err != nil {
+			return "", "", nil, fmt.Errorf("解析视频失败: %w", err)
+		}
+		if !upload.IsVideo() {
+			return "", "", nil, fmt.Errorf("不是有效的视频文件: %s", upload.ContentType)
+		}
+		return upload.Path, upload.Handle, release, nil
+	}
+
+	// 本地视频文件校验
+	if _, err := os.Stat(video); err != nil {
+		return "", "", nil, fmt.Errorf("视频文件不存在或不可访问: %v", err)
+	}
+	return video, video, release, nil
+}
+
+// resolveVideoCover 校验封面参数，封面图片支持 URL、本地路径、媒体句柄、data URI 和 base64
+func (s *XiaohongshuService) resolveVideoCover(ctx context.Context, req *PublishVideoRequest, duration time.Duration) (string, *time.Duration, error) {
+	coverTime, err := validateVideoCover(req.Cover, req.CoverTime, duration)
+	if err != nil {
+		return "", nil, err
+	}
 
-	page := b.NewPage()
-	defer page.Close()
+	if req.Cover != "" {
+		result, err := s.processImages(ctx, []string{req.Cover}, false)
+		if err != nil {
+			return "", nil, fmt.Errorf("处理封面图片失败: %w", err)
+		}
+		return result.Paths[0], nil, nil
+	}
+	return "", coverTime, nil
+}
+
+// validateVideoCover 校验 cover 与 cover_time，返回截取封面的时间点（未设置 cover_time 时为 nil）
+func validateVideoCover(cover string, coverTime *float64, duration time.Duration) (*time.Duration, error) {
+	if coverTime == nil {
+		return nil, nil
+	}
+	if cover != "" {
+		return nil, fmt.Errorf("cover 和 cover_time 只能指定一个")
+	}
+	if *coverTime < 0 {
+		return nil, fmt.Errorf("cover_time 不能为负数")
+	}
+
+	at := time.Duration(*coverTime * float64(time.Second))
+	if at > duration {
+		return nil, fmt.Errorf("cover_time %.1f 秒超过视频时长 %.1f 秒", *coverTime, duration.Seconds())
+	}
+	return &at, nil
+}
+
+// publishVideo 执行视频发布
+func (s *XiaohongshuService) publishVideo(ctx context.Context, content xiaohongshu.PublishVideoContent) (*xiaohongshu.PublishedNote, error) {
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityPublish, "publish_video")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action, err := xiaohongshu.NewPublishVideoAction(page)
 	if err != nil {
-		return err
+		return nil, s.diagnose(ctx, page, "publish_video", err)
 	}
 
-	return action.PublishVideo(ctx, content)
+	note, err := action.PublishVideo(ctx, content)
+	if err != nil {
+		return nil, s.diagnose(ctx, page, "publish_video", err)
+	}
+	return note, nil
 }
 
 // ListFeeds 获取Feeds列表
 func (s *XiaohongshuService) ListFeeds(ctx context.Context) (*FeedsListResponse, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityRead, "list_feeds")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	// 创建 Feeds 列表 action
 	action := xiaohongshu.NewFeedsListAction(page)
@@ -294,7 +528,7 @@ func (s *XiaohongshuService) ListFeeds(ctx context.Context) (*FeedsListResponse,
 	feeds, err := action.GetFeedsList(ctx)
 	if err != nil {
 		logrus.Errorf("获取 Feeds 列表失败: %v", err)
-		return nil, err
+		return nil, s.diagnose(ctx, page, "list_feeds", err)
 	}
 
 	response := &FeedsListResponse{
@@ -306,17 +540,17 @@ func (s *XiaohongshuService) ListFeeds(ctx context.Context) (*FeedsListResponse,
 }
 
 func (s *XiaohongshuService) SearchFeeds(ctx context.Context, keyword string, filters ...xiaohongshu.FilterOption) (*FeedsListResponse, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityRead, "search_feeds")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewSearchAction(page)
 
 	feeds, err := action.Search(ctx, keyword, filters...)
 	if err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "search_feeds", err)
 	}
 
 	response := &FeedsListResponse{
@@ -329,11 +563,11 @@ func (s *XiaohongshuService) SearchFeeds(ctx context.Context, keyword string, fi
 
 // GetFeedDetail 获取Feed详情
 func (s *XiaohongshuService) GetFeedDetail(ctx context.Context, feedID, xsecToken string) (*FeedDetailResponse, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityRead, "get_feed_detail")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	// 创建 Feed 详情 action
 	action := xiaohongshu.NewFeedDetailAction(page)
@@ -341,7 +575,7 @@ func (s *XiaohongshuService) GetFeedDetail(ctx context.Context, feedID, xsecToke
 	// 获取 Feed 详情
 	result, err := action.GetFeedDetail(ctx, feedID, xsecToken)
 	if err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "get_feed_detail", err)
 	}
 
 	response := &FeedDetailResponse{
@@ -354,17 +588,17 @@ func (s *XiaohongshuService) GetFeedDetail(ctx context.Context, feedID, xsecToke
 
 // UserProfile 获取用户信息
 func (s *XiaohongshuService) UserProfile(ctx context.Context, userID, xsecToken string) (*UserProfileResponse, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityRead, "user_profile")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewUserProfileAction(page)
 
 	result, err := action.UserProfile(ctx, userID, xsecToken)
 	if err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "user_profile", err)
 	}
 	response := &UserProfileResponse{
 		UserBasicInfo: result.UserBasicInfo,
@@ -378,16 +612,16 @@ func (s *XiaohongshuService) UserProfile(ctx context.Context, userID, xsecToken
 
 // PostCommentToFeed 发表评论到Feed
 func (s *XiaohongshuService) PostCommentToFeed(ctx context.Context, feedID, xsecToken, content string) (*PostCommentResponse, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityInteraction, "post_comment")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewCommentFeedAction(page)
 
 	if err := action.PostComment(ctx, feedID, xsecToken, content); err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "post_comment", err)
 	}
 
 	return &PostCommentResponse{FeedID: feedID, Success: true, Message: "评论发表成功"}, nil
@@ -395,69 +629,307 @@ func (s *XiaohongshuService) PostCommentToFeed(ctx context.Context, feedID, xsec
 
 // LikeFeed 点赞笔记
 func (s *XiaohongshuService) LikeFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityInteraction, "like_feed")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewLikeAction(page)
 	if err := action.Like(ctx, feedID, xsecToken); err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "like_feed", err)
 	}
 	return &ActionResult{FeedID: feedID, Success: true, Message: "点赞成功或已点赞"}, nil
 }
 
 // UnlikeFeed 取消点赞笔记
 func (s *XiaohongshuService) UnlikeFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityInteraction, "unlike_feed")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewLikeAction(page)
 	if err := action.Unlike(ctx, feedID, xsecToken); err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "unlike_feed", err)
 	}
 	return &ActionResult{FeedID: feedID, Success: true, Message: "取消点赞成功或未点赞"}, nil
 }
 
 // FavoriteFeed 收藏笔记
 func (s *XiaohongshuService) FavoriteFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityInteraction, "favorite_feed")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewFavoriteAction(page)
 	if err := action.Favorite(ctx, feedID, xsecToken); err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "favorite_feed", err)
 	}
 	return &ActionResult{FeedID: feedID, Success: true, Message: "收藏成功或已收藏"}, nil
 }
 
 // UnfavoriteFeed 取消收藏笔记
 func (s *XiaohongshuService) UnfavoriteFeed(ctx context.Context, feedID, xsecToken string) (*ActionResult, error) {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+	page, release, err := s.acquirePage(ctx, actionqueue.PriorityInteraction, "unfavorite_feed")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 
 	action := xiaohongshu.NewFavoriteAction(page)
 	if err := action.Unfavorite(ctx, feedID, xsecToken); err != nil {
-		return nil, err
+		return nil, s.diagnose(ctx, page, "unfavorite_feed", err)
 	}
 	return &ActionResult{FeedID: feedID, Success: true, Message: "取消收藏成功或未收藏"}, nil
 }
 
-func newBrowser() *headless_browser.Browser {
-	return browser.NewBrowser(configs.IsHeadless(), browser.WithBinPath(configs.GetBinPath()))
+// acquirePage 在 ctx 所指定账号的操作队列中排队，轮到后从该账号的浏览器池租用页面，用完后调用 release 归还
+func (s *XiaohongshuService) acquirePage(ctx context.Context, priority actionqueue.Priority, action string) (*rod.Page, func(), error) {
+	return s.acquirePageWithin(ctx, priority, action, 0)
+}
+
+// acquirePageWithin 同 acquirePage，但最多在队列中等待 wait（0 表示不限），超时返回 ErrQueueBusy
+func (s *XiaohongshuService) acquirePageWithin(ctx context.Context, priority actionqueue.Priority, action string, wait time.Duration) (*rod.Page, func(), error) {
+	account := accounts.FromContext(ctx)
+	if _, err := s.accounts.Get(account); err != nil {
+		return nil, nil, err
+	}
+
+	// 只限制排队时间，租用的页面仍绑定调用方的 ctx
+	queueCtx := ctx
+	if wait > 0 {
+		var cancel context.CancelFunc
+		queueCtx, cancel = context.WithTimeout(ctx, wait)
+		defer cancel()
+	}
+	done, err := s.queues.Queue(account).Acquire(queueCtx, priority, action)
+	if err != nil {
+		if ctx.Err() == nil && queueCtx.Err() != nil {
+			return nil, nil, fmt.Errorf("%w: %s 等待超过 %s", ErrQueueBusy, action, wait)
+		}
+		return nil, nil, fmt.Errorf("等待执行 %s 失败: %w", action, err)
+	}
+
+	page, release, err := s.leasePage(ctx)
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+
+	// 记录页面控制台输出，操作失败时随诊断信息一起保存
+	unwatch := func() {}
+	if s.diagnostics != nil {
+		unwatch = s.diagnostics.Watch(page)
+	}
+
+	return page, func() {
+		unwatch()
+		release()
+		done()
+	}, nil
+}
+
+// diagnose 浏览器操作失败时保存页面截图、DOM 和控制台日志，返回的错误附带诊断记录 ID；
+// 未启用诊断、操作成功或调用方已取消时原样返回 err
+func (s *XiaohongshuService) diagnose(ctx context.Context, page *rod.Page, action string, err error) error {
+	if err == nil || s.diagnostics == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	report, captureErr := s.diagnostics.Capture(page, diagnostics.Meta{
+		Action:  action,
+		Account: accounts.FromContext(ctx),
+	}, err)
+	if captureErr != nil {
+		logrus.Warnf("保存 %s 的诊断信息失败: %v", action, captureErr)
+		return err
+	}
+
+	logrus.Warnf("%s 失败，诊断信息已保存: %s", action, report.ID)
+	return &diagnostics.Error{ReportID: report.ID, Err: err}
+}
+
+// leasePage 直接从 ctx 所指定账号的浏览器池租用页面，不经过操作队列
+func (s *XiaohongshuService) leasePage(ctx context.Context) (*rod.Page, func(), error) {
+	pool, err := s.pool(accounts.FromContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lease, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取浏览器失败: %w", err)
+	}
+	// 操作超时后 diagnose 还要截图，页面由 acquirePage 的 release 关闭，不随 ctx 自动关闭
+	lease.KeepOnCancel()
+	return lease.Page, lease.Release, nil
+}
+
+// dedicatedPage 为 ctx 所指定的账号启动一个独立浏览器，不占用浏览器池，用完后调用 release 关闭
+func (s *XiaohongshuService) dedicatedPage(ctx context.Context) (*rod.Page, func(), error) {
+	pool, err := s.pool(accounts.FromContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lease, err := pool.Dedicated(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("启动浏览器失败: %w", err)
+	}
+	return lease.Page, lease.Release, nil
+}
+
+// pool 返回账号的浏览器池，首次使用时创建
+func (s *XiaohongshuService) pool(name string) (*browser.Pool, error) {
+	account, err := s.accounts.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.poolsMu.Lock()
+	defer s.poolsMu.Unlock()
+
+	pool, ok := s.pools[account.Name]
+	if !ok {
+		cfg := s.poolConfig
+		cfg.Cookies = account.Cookies()
+		cfg.Profile = cfg.Profile.Merge(account.Browser)
+		pool = browser.NewPool(cfg)
+		s.pools[account.Name] = pool
+	}
+	return pool, nil
+}
+
+// refreshPool 账号 cookies 变更后重建其浏览器池中的浏览器
+func (s *XiaohongshuService) refreshPool(name string) {
+	s.poolsMu.Lock()
+	pool := s.pools[name]
+	s.poolsMu.Unlock()
+
+	if pool != nil {
+		pool.Refresh()
+	}
+}
+
+// WarmPool 预先启动账号的浏览器
+func (s *XiaohongshuService) WarmPool(ctx context.Context, name string) error {
+	pool, err := s.pool(name)
+	if err != nil {
+		return err
+	}
+	return pool.Warm(ctx)
+}
+
+// Close 关闭所有账号的浏览器池
+func (s *XiaohongshuService) Close() {
+	s.poolsMu.Lock()
+	pools := s.pools
+	s.pools = map[string]*browser.Pool{}
+	s.poolsMu.Unlock()
+
+	for _, pool := range pools {
+		pool.Close()
+	}
+}
+
+// ListAccounts 列出所有账号及最近一次检查到的登录状态
+func (s *XiaohongshuService) ListAccounts() []accounts.Info {
+	return s.accounts.List()
+}
+
+// AddAccount 注册新账号，之后需要指定该账号获取二维码扫码登录。
+// profile 为账号专属的浏览器配置，可为空，配置了代理时先确认代理可用。返回的账号已隐藏代理密码
+func (s *XiaohongshuService) AddAccount(ctx context.Context, name, displayName string, profile *browser.Profile) (*accounts.Account, error) {
+	if profile != nil {
+		if err := s.checkBrowserProfile(ctx, *profile); err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := s.accounts.Add(name, displayName)
+	if err == nil && profile != nil {
+		account, err = s.accounts.SetBrowser(name, profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account.Redacted(), nil
 }
 
-func saveCookies(page *rod.Page) error {
+// SetAccountBrowser 设置账号的浏览器指纹和代理，profile 为空时恢复使用全局配置。
+// 账号的浏览器池会关闭，下次使用时按新配置启动
+func (s *XiaohongshuService) SetAccountBrowser(ctx context.Context, name string, profile *browser.Profile) (*accounts.Account, error) {
+	if profile != nil {
+		if err := s.checkBrowserProfile(ctx, *profile); err != nil {
+			return nil, err
+		}
+	}
+
+	account, err := s.accounts.SetBrowser(name, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	s.closePool(name)
+	return account.Redacted(), nil
+}
+
+// checkBrowserProfile 校验叠加在全局配置上的账号配置，代理需要可用
+func (s *XiaohongshuService) checkBrowserProfile(ctx context.Context, profile browser.Profile) error {
+	merged := s.poolConfig.Profile.Merge(&profile)
+	if err := merged.Validate(); err != nil {
+		return err
+	}
+	if profile.Proxy != "" {
+		return browser.CheckProxy(ctx, profile.Proxy)
+	}
+	return nil
+}
+
+// RemoveAccount 删除账号及其 cookies，并关闭其浏览器池
+func (s *XiaohongshuService) RemoveAccount(name string) error {
+	if err := s.accounts.Remove(name); err != nil {
+		return err
+	}
+
+	s.closePool(name)
+	return nil
+}
+
+// closePool 关闭并移除账号的浏览器池
+func (s *XiaohongshuService) closePool(name string) {
+	s.poolsMu.Lock()
+	pool := s.pools[name]
+	delete(s.pools, name)
+	s.poolsMu.Unlock()
+
+	if pool != nil {
+		pool.Close()
+	}
+}
+
+// ActionQueueStats 各账号操作队列的状态
+func (s *XiaohongshuService) ActionQueueStats() []actionqueue.Stats {
+	return s.queues.Stats()
+}
+
+// BrowserPoolStats 各账号浏览器池的使用情况
+func (s *XiaohongshuService) BrowserPoolStats() map[string]browser.PoolStats {
+	s.poolsMu.Lock()
+	defer s.poolsMu.Unlock()
+
+	stats := make(map[string]browser.PoolStats, len(s.pools))
+	for name, pool := range s.pools {
+		stats[name] = pool.Stats()
+	}
+	return stats
+}
+
+func saveCookies(page *rod.Page, cookieLoader cookies.Cookier) error {
 	cks, err := page.Browser().GetCookies()
 	if err != nil {
 		return err
@@ -468,19 +940,18 @@ func saveCookies(page *rod.Page) error {
 		return err
 	}
 
-	cookieLoader := cookies.NewLoadCookie(cookies.GetCookiesFilePath())
 	return cookieLoader.SaveCookies(data)
 }
 
 // withBrowserPage 执行需要浏览器页面的操作的通用函数
-func withBrowserPage(fn func(*rod.Page) error) error {
-	b := newBrowser()
-	defer b.Close()
-
-	page := b.NewPage()
-	defer page.Close()
+func (s *XiaohongshuService) withBrowserPage(ctx context.Context, priority actionqueue.Priority, action string, fn func(*rod.Page) error) error {
+	page, release, err := s.acquirePage(ctx, priority, action)
+	if err != nil {
+		return err
+	}
+	defer release()
 
-	return fn(page)
+	return s.diagnose(ctx, page, action, fn(page))
 }
 
 // GetMyProfile 获取当前登录用户的个人信息
@@ -488,7 +959,7 @@ func (s *XiaohongshuService) GetMyProfile(ctx context.Context) (*UserProfileResp
 	var result *xiaohongshu.UserProfileResponse
 	var err error
 
-	err = withBrowserPage(func(page *rod.Page) error {
+	err = s.withBrowserPage(ctx, actionqueue.PriorityRead, "my_profile", func(page *rod.Page) error {
 		action := xiaohongshu.NewUserProfileAction(page)
 		result, err = action.GetMyProfileViaSidebar(ctx)
 		return err
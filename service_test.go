@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCoverTime(t *testing.T) {
+	assert.Nil(t, parseCoverTime(map[string]interface{}{}))
+
+	zero := parseCoverTime(map[string]interface{}{"cover_time": 0.0})
+	require.NotNil(t, zero)
+	assert.Equal(t, 0.0, *zero)
+
+	var unset *float64
+	assert.Nil(t, parseCoverTime(map[string]interface{}{"cover_time": unset}))
+
+	var req PublishVideoRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"cover_time":0}`), &req))
+	require.NotNil(t, req.CoverTime)
+	assert.Equal(t, 0.0, *req.CoverTime)
+}
+
+func TestValidateVideoCover(t *testing.T) {
+	seconds := func(v float64) *float64 { return &v }
+	duration := 30 * time.Second
+
+	at, err := validateVideoCover("", nil, duration)
+	require.NoError(t, err)
+	assert.Nil(t, at)
+
+	at, err = validateVideoCover("", seconds(0), duration)
+	require.NoError(t, err)
+	require.NotNil(t, at)
+	assert.Equal(t, time.Duration(0), *at)
+
+	at, err = validateVideoCover("", seconds(1.5), duration)
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, *at)
+
+	_, err = validateVideoCover("cover.jpg", seconds(0), duration)
+	assert.Error(t, err)
+	_, err = validateVideoCover("", seconds(-1), duration)
+	assert.Error(t, err)
+	_, err = validateVideoCover("", seconds(31), duration)
+	assert.Error(t, err)
+}
@@ -1,14 +1,18 @@
 package main
 
-import "github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+import (
+	"github.com/xpzouying/xiaohongshu-mcp/browser"
+	"github.com/xpzouying/xiaohongshu-mcp/xiaohongshu"
+)
 
 // HTTP API 响应类型
 
 // ErrorResponse 错误响应
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    string `json:"code"`
-	Details any    `json:"details,omitempty"`
+	Error         string `json:"error"`
+	Code          string `json:"code"`
+	Details       any    `json:"details,omitempty"`
+	DiagnosticsID string `json:"diagnostics_id,omitempty"` // 浏览器操作失败时保存的诊断信息 ID
 }
 
 // SuccessResponse 成功响应
@@ -71,6 +75,14 @@ type UserProfileRequest struct {
 	XsecToken string `json:"xsec_token" binding:"required"`
 }
 
+// AddAccountRequest 添加账号请求
+type AddAccountRequest struct {
+	Name        string `json:"name" binding:"required"`
+	DisplayName string `json:"display_name,omitempty"`
+	// Browser 账号专属的浏览器指纹和代理，未设置的字段使用全局配置
+	Browser *browser.Profile `json:"browser,omitempty"`
+}
+
 // ActionResult 通用动作响应（点赞/收藏等）
 type ActionResult struct {
 	FeedID  string `json:"feed_id"`
@@ -21,6 +21,7 @@ func NewCommentFeedAction(page *rod.Page) *CommentFeedAction {
 // PostComment 发表评论到 Feed
 func (f *CommentFeedAction) PostComment(ctx context.Context, feedID, xsecToken, content string) error {
 	page := f.page.Context(ctx).Timeout(60 * time.Second)
+	t := newTracker("post comment")
 
 	// 构建详情页 URL
 	url := makeFeedDetailURL(feedID, xsecToken)
@@ -28,21 +29,25 @@ func (f *CommentFeedAction) PostComment(ctx context.Context, feedID, xsecToken,
 	logrus.Infof("Opening feed detail page: %s", url)
 
 	// 导航到详情页
-	page.MustNavigate(url)
-	page.MustWaitDOMStable()
+	if err := t.navigate(page, "open feed detail page", url, waitDOMStable); err != nil {
+		return err
+	}
 
 	time.Sleep(1 * time.Second)
 
-	elem := page.MustElement("div.input-box div.content-edit span")
-	elem.MustClick()
+	if err := t.click(page, "activate comment box", SelectorCommentActivate); err != nil {
+		return err
+	}
 
-	elem2 := page.MustElement("div.input-box div.content-edit p.content-input")
-	elem2.MustInput(content)
+	if err := t.input(page, "input comment", SelectorCommentInput, content); err != nil {
+		return err
+	}
 
 	time.Sleep(1 * time.Second)
 
-	submitButton := page.MustElement("div.bottom button.submit")
-	submitButton.MustClick()
+	if err := t.click(page, "submit comment", SelectorCommentSubmit); err != nil {
+		return err
+	}
 
 	time.Sleep(1 * time.Second)
 
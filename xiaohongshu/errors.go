@@ -0,0 +1,186 @@
+package xiaohongshu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/pkg/errors"
+)
+
+// 浏览器操作失败的类别，可用 errors.Is 判断
+var (
+	ErrNavigation      = errors.New("page navigation failed")
+	ErrElementNotFound = errors.New("element not found")
+	ErrInteraction     = errors.New("element interaction failed")
+	ErrPageData        = errors.New("page data unavailable")
+)
+
+// ActionError 动作在某一步失败，记录所在页面、选择器以及已完成的步骤，
+// 调用方可据此判断失败位置和已产生的副作用（例如图片已上传但未提交）
+type ActionError struct {
+	Action    string   `json:"action"`
+	Step      string   `json:"step"`
+	URL       string   `json:"url,omitempty"`
+	Selector  string   `json:"selector,omitempty"`
+	Completed []string `json:"completed,omitempty"`
+	Kind      error    `json:"-"`
+	Err       error    `json:"-"`
+}
+
+func (e *ActionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: step %q failed: %v", e.Action, e.Step, e.Kind)
+	if e.Selector != "" {
+		fmt.Fprintf(&b, " (selector %q)", e.Selector)
+	}
+	if e.URL != "" {
+		fmt.Fprintf(&b, " on %s", e.URL)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	if len(e.Completed) > 0 {
+		fmt.Fprintf(&b, "; completed steps: %s", strings.Join(e.Completed, ", "))
+	}
+	return b.String()
+}
+
+// Unwrap 同时返回失败类别和底层错误
+func (e *ActionError) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// MarshalJSON 附带失败类别和完整的错误信息，用于 API 返回
+func (e *ActionError) MarshalJSON() ([]byte, error) {
+	type plain ActionError
+	out := struct {
+		*plain
+		Kind  string `json:"kind,omitempty"`
+		Error string `json:"error"`
+	}{plain: (*plain)(e), Error: e.Error()}
+	if e.Kind != nil {
+		out.Kind = e.Kind.Error()
+	}
+	return json.Marshal(out)
+}
+
+// tracker 记录动作的执行进度，某一步失败时生成带上下文的 ActionError
+type tracker struct {
+	action string
+	url    string
+	done   []string
+}
+
+func newTracker(action string) *tracker {
+	return &tracker{action: action}
+}
+
+// fail 生成当前进度下的 ActionError
+func (t *tracker) fail(kind error, step, selector string, err error) error {
+	return &ActionError{
+		Action:    t.action,
+		Step:      step,
+		URL:       t.url,
+		Selector:  selector,
+		Completed: append([]string(nil), t.done...),
+		Kind:      kind,
+		Err:       err,
+	}
+}
+
+// do 执行一步，成功后记入已完成的步骤
+func (t *tracker) do(step string, kind error, selector string, fn func() error) error {
+	if err := fn(); err != nil {
+		return t.fail(kind, step, selector, err)
+	}
+	t.done = append(t.done, step)
+	return nil
+}
+
+// pageWait 打开页面后的等待方式
+type pageWait func(page *rod.Page) error
+
+func waitLoad(page *rod.Page) error      { return page.WaitLoad() }
+func waitStable(page *rod.Page) error    { return page.WaitStable(time.Second) }
+func waitDOMStable(page *rod.Page) error { return page.WaitDOMStable(time.Second, 0) }
+func waitIdle(page *rod.Page) error      { return page.WaitIdle(time.Minute) }
+
+// navigate 打开 url 并依次等待
+func (t *tracker) navigate(page *rod.Page, step, url string, waits ...pageWait) error {
+	t.url = url
+	return t.do(step, ErrNavigation, "", func() error {
+		if err := page.Navigate(url); err != nil {
+			return err
+		}
+		for _, wait := range waits {
+			if err := wait(page); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// element 查找元素，页面超时前一直重试，args 用于填充选择器模板
+func (t *tracker) element(page *rod.Page, step string, sel Selector, args ...any) (*rod.Element, error) {
+	css := sel.css(args...)
+	el, err := findElement(page, css)
+	if err != nil {
+		return nil, t.fail(ErrElementNotFound, step, sel.describe(css), err)
+	}
+	return el, nil
+}
+
+// click 查找并点击元素
+func (t *tracker) click(page *rod.Page, step string, sel Selector, args ...any) error {
+	el, err := t.element(page, step, sel, args...)
+	if err != nil {
+		return err
+	}
+	return t.do(step, ErrInteraction, string(sel), func() error {
+		return el.Click(proto.InputMouseButtonLeft, 1)
+	})
+}
+
+// input 查找元素并输入文本
+func (t *tracker) input(page *rod.Page, step string, sel Selector, text string) error {
+	el, err := t.element(page, step, sel)
+	if err != nil {
+		return err
+	}
+	return t.do(step, ErrInteraction, string(sel), func() error {
+		return el.Input(text)
+	})
+}
+
+// wait 等待页面上的 js 条件成立
+func (t *tracker) wait(page *rod.Page, step, js string) error {
+	return t.do(step, ErrPageData, "", func() error {
+		return page.Wait(rod.Eval(js))
+	})
+}
+
+// eval 执行 js 并返回字符串结果
+func (t *tracker) eval(page *rod.Page, step, js string) (string, error) {
+	var result string
+	err := t.do(step, ErrPageData, "", func() error {
+		res, err := page.Eval(js)
+		if err != nil {
+			return err
+		}
+		result = res.Value.String()
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,41 @@
+package xiaohongshu
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerActionError(t *testing.T) {
+	tr := newTracker("publish image note")
+	tr.url = urlOfPublic
+
+	require.NoError(t, tr.do("upload images", ErrInteraction, ".upload-input", func() error { return nil }))
+
+	cause := errors.New("timeout")
+	err := tr.do("input title", ErrElementNotFound, "div.d-input input", func() error { return cause })
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, ErrElementNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrNavigation)
+
+	var actionErr *ActionError
+	require.ErrorAs(t, err, &actionErr)
+	assert.Equal(t, "input title", actionErr.Step)
+	assert.Equal(t, []string{"upload images"}, actionErr.Completed)
+	assert.Contains(t, err.Error(), `selector "div.d-input input"`)
+	assert.Contains(t, err.Error(), "completed steps: upload images")
+
+	data, err := json.Marshal(actionErr)
+	require.NoError(t, err)
+
+	var out map[string]any
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, "element not found", out["kind"])
+	assert.Equal(t, urlOfPublic, out["url"])
+	assert.Equal(t, actionErr.Error(), out["error"])
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/go-rod/rod"
@@ -24,6 +26,7 @@ func NewFeedDetailAction(page *rod.Page) *FeedDetailAction {
 // GetFeedDetail 获取 Feed 详情页数据
 func (f *FeedDetailAction) GetFeedDetail(ctx context.Context, feedID, xsecToken string) (*FeedDetailResponse, error) {
 	page := f.page.Context(ctx).Timeout(60 * time.Second)
+	t := newTracker("get feed detail")
 
 	// 构建详情页 URL
 	url := makeFeedDetailURL(feedID, xsecToken)
@@ -31,11 +34,12 @@ func (f *FeedDetailAction) GetFeedDetail(ctx context.Context, feedID, xsecToken
 	logrus.Infof("打开 feed 详情页: %s", url)
 
 	// 导航到详情页
-	page.MustNavigate(url)
-	page.MustWaitDOMStable()
+	if err := t.navigate(page, "open feed detail page", url, waitDOMStable); err != nil {
+		return nil, err
+	}
 	time.Sleep(1 * time.Second)
 
-	result := page.MustEval(`() => {
+	result, err := t.eval(page, "read note detail", `() => {
 		if (window.__INITIAL_STATE__ &&
 		    window.__INITIAL_STATE__.note &&
 		    window.__INITIAL_STATE__.note.noteDetailMap) {
@@ -43,10 +47,13 @@ func (f *FeedDetailAction) GetFeedDetail(ctx context.Context, feedID, xsecToken
 			return JSON.stringify(noteDetailMap);
 		}
 		return "";
-	}`).String()
+	}`)
+	if err != nil {
+		return nil, err
+	}
 
 	if result == "" {
-		return nil, errors.ErrNoFeedDetail
+		return nil, t.fail(ErrPageData, "read note detail", "", errors.ErrNoFeedDetail)
 	}
 
 	var noteDetailMap map[string]struct {
@@ -63,12 +70,88 @@ func (f *FeedDetailAction) GetFeedDetail(ctx context.Context, feedID, xsecToken
 		return nil, fmt.Errorf("feed %s not found in noteDetailMap", feedID)
 	}
 
+	normalizeImages(&noteDetail.Note)
+
+	if noteDetail.Note.Video != nil {
+		normalizeVideo(&noteDetail.Note)
+		logrus.Infof("视频笔记 %s: %d 个视频流, 时长 %d 秒", feedID, len(noteDetail.Note.Video.Streams()), noteDetail.Note.Video.DurationSeconds())
+	}
+
 	return &FeedDetailResponse{
 		Note:     noteDetail.Note,
 		Comments: noteDetail.Comments,
 	}, nil
 }
 
+// normalizeVideo 补全视频封面，并规范视频流
+func normalizeVideo(note *FeedDetail) {
+	video := note.Video
+
+	if video.Cover == "" && len(note.ImageList) > 0 {
+		video.Cover = note.ImageList[0].URLDefault
+	}
+
+	normalizeStreams(&video.Media.Stream)
+}
+
+// normalizeImages 补全无水印原图地址，并规范实况图的视频流
+func normalizeImages(note *FeedDetail) {
+	for i := range note.ImageList {
+		img := &note.ImageList[i]
+		img.OriginalURL = originalImageURL(img)
+		if img.Stream != nil {
+			normalizeStreams(img.Stream)
+		}
+	}
+}
+
+// originalImageURL 根据图片 token 生成无水印原图地址
+// urlDefault 形如 http://sns-webpic-qc.xhscdn.com/<时间>/<签名>/<token>!nd_dft_wlteh_webp_3
+func originalImageURL(img *DetailImageInfo) string {
+	token := ""
+	if u, err := url.Parse(img.URLDefault); err == nil {
+		if parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 3); len(parts) == 3 {
+			token = strings.SplitN(parts[2], "!", 2)[0]
+		}
+	}
+	if token == "" {
+		token = img.TraceID
+	}
+	if token == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://ci.xiaohongshu.com/%s?imageView2/2/w/0/format/jpg", token)
+}
+
+// normalizeStreams 补全编码信息，并将视频流地址统一为 https
+func normalizeStreams(streams *VideoStreams) {
+	groups := map[string]*[]VideoStream{
+		"h264": &streams.H264,
+		"h265": &streams.H265,
+		"h266": &streams.H266,
+		"av1":  &streams.AV1,
+	}
+	for codec, group := range groups {
+		for i := range *group {
+			stream := &(*group)[i]
+			if stream.VideoCodec == "" {
+				stream.VideoCodec = codec
+			}
+			stream.MasterURL = toHTTPS(stream.MasterURL)
+			for j := range stream.BackupURLs {
+				stream.BackupURLs[j] = toHTTPS(stream.BackupURLs[j])
+			}
+		}
+	}
+}
+
+func toHTTPS(rawURL string) string {
+	if strings.HasPrefix(rawURL, "http://") {
+		return "https://" + strings.TrimPrefix(rawURL, "http://")
+	}
+	return rawURL
+}
+
 func makeFeedDetailURL(feedID, xsecToken string) string {
 	return fmt.Sprintf("https://www.xiaohongshu.com/explore/%s?xsec_token=%s&xsec_source=pc_feed", feedID, xsecToken)
 }
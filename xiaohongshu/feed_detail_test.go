@@ -0,0 +1,53 @@
+package xiaohongshu
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeImages(t *testing.T) {
+	raw := `{
+		"imageList": [
+			{
+				"urlDefault": "http://sns-webpic-qc.xhscdn.com/202410191234/c4fcecea4bd0/1040g008310cs1hii6g6g5ngacg208q5rlf1gld8!nd_dft_wlteh_webp_3",
+				"livePhoto": true,
+				"stream": {"h264": [{"masterUrl": "http://sns-video-bd.xhscdn.com/live.mp4", "width": 1080, "height": 1440, "duration": 3000}]}
+			},
+			{"urlDefault": "", "traceId": "1040g2sg31abc", "urlPre": "http://pre"}
+		]
+	}`
+
+	var note FeedDetail
+	require.NoError(t, json.Unmarshal([]byte(raw), &note))
+	normalizeImages(&note)
+
+	first := note.ImageList[0]
+	assert.Equal(t, "https://ci.xiaohongshu.com/1040g008310cs1hii6g6g5ngacg208q5rlf1gld8?imageView2/2/w/0/format/jpg", first.BestURL())
+	require.Len(t, first.LiveStreams(), 1)
+	assert.Equal(t, "https://sns-video-bd.xhscdn.com/live.mp4", first.LiveStreams()[0].MasterURL)
+	assert.Equal(t, "h264", first.LiveStreams()[0].VideoCodec)
+
+	assert.Equal(t, "https://ci.xiaohongshu.com/1040g2sg31abc?imageView2/2/w/0/format/jpg", note.ImageList[1].BestURL())
+	assert.Empty(t, note.ImageList[1].LiveStreams())
+}
+
+func TestDetailImageURLs(t *testing.T) {
+	img := DetailImageInfo{
+		URLDefault:  "https://sns-webpic/dft",
+		URLPre:      "https://sns-webpic/prv",
+		OriginalURL: "https://ci.xiaohongshu.com/token?imageView2/2/w/0/format/jpg",
+		InfoList: []ImageInfo{
+			{ImageScene: "WB_PRV", URL: "https://sns-webpic/prv"},
+			{ImageScene: "WB_DFT", URL: "https://sns-webpic/wb-dft"},
+		},
+	}
+
+	assert.Equal(t, []string{img.OriginalURL, "https://sns-webpic/wb-dft", "https://sns-webpic/dft", "https://sns-webpic/prv"},
+		img.URLs(AllImageVariants...))
+	assert.Equal(t, []string{"https://sns-webpic/wb-dft", "https://sns-webpic/dft"}, img.URLs(ImageDefault))
+	assert.Equal(t, img.OriginalURL, img.BestURL())
+	assert.Empty(t, DetailImageInfo{}.BestURL())
+}
@@ -17,19 +17,21 @@ type FeedsListAction struct {
 func NewFeedsListAction(page *rod.Page) *FeedsListAction {
 	pp := page.Timeout(60 * time.Second)
 
-	pp.MustNavigate("https://www.xiaohongshu.com")
-	pp.MustWaitDOMStable()
-
 	return &FeedsListAction{page: pp}
 }
 
-// GetFeedsList 获取页面的 Feed 列表数据
+// GetFeedsList 打开首页并获取页面的 Feed 列表数据
 func (f *FeedsListAction) GetFeedsList(ctx context.Context) ([]Feed, error) {
 	page := f.page.Context(ctx)
+	t := newTracker("list feeds")
+
+	if err := t.navigate(page, "open home page", "https://www.xiaohongshu.com", waitDOMStable); err != nil {
+		return nil, err
+	}
 
 	time.Sleep(1 * time.Second)
 
-	result := page.MustEval(`() => {
+	result, err := t.eval(page, "read feeds", `() => {
 		if (window.__INITIAL_STATE__ &&
 		    window.__INITIAL_STATE__.feed &&
 		    window.__INITIAL_STATE__.feed.feeds) {
@@ -40,10 +42,13 @@ func (f *FeedsListAction) GetFeedsList(ctx context.Context) ([]Feed, error) {
 			}
 		}
 		return "";
-	}`).String()
+	}`)
+	if err != nil {
+		return nil, err
+	}
 
 	if result == "" {
-		return nil, errors.ErrNoFeeds
+		return nil, t.fail(ErrPageData, "read feeds", "", errors.ErrNoFeeds)
 	}
 
 	var feeds []Feed
@@ -7,20 +7,16 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
-	"github.com/xpzouying/xiaohongshu-mcp/browser"
 )
 
 func TestGetFeedsList(t *testing.T) {
 
 	t.Skip("SKIP: 测试发布")
 
-	b := browser.NewBrowser(false)
-	defer b.Close()
-
-	page := b.NewPage()
+	page := newTestPage(t)
 	defer page.Close()
 
-	// NewFeedsListAction 内部已经处理导航
+	// GetFeedsList 内部已经处理导航
 	action := NewFeedsListAction(page)
 
 	feeds, err := action.GetFeedsList(context.Background())
@@ -19,12 +19,6 @@ type ActionResult struct {
 	Message string `json:"message"`
 }
 
-// 选择器常量
-const (
-	SelectorLikeButton    = ".interact-container .left .like-lottie"
-	SelectorCollectButton = ".interact-container .left .reds-icon.collect-icon"
-)
-
 // interactActionType 交互动作类型
 type interactActionType string
 
@@ -43,21 +37,22 @@ func newInteractAction(page *rod.Page) *interactAction {
 	return &interactAction{page: page}
 }
 
-func (a *interactAction) preparePage(ctx context.Context, actionType interactActionType, feedID, xsecToken string) *rod.Page {
+func (a *interactAction) preparePage(ctx context.Context, actionType interactActionType, feedID, xsecToken string) (*rod.Page, *tracker, error) {
 	page := a.page.Context(ctx).Timeout(60 * time.Second)
+	t := newTracker(string(actionType))
 	url := makeFeedDetailURL(feedID, xsecToken)
 	logrus.Infof("Opening feed detail page for %s: %s", actionType, url)
 
-	page.MustNavigate(url)
-	page.MustWaitDOMStable()
+	if err := t.navigate(page, "open feed detail page", url, waitDOMStable); err != nil {
+		return nil, nil, err
+	}
 	time.Sleep(1 * time.Second)
 
-	return page
+	return page, t, nil
 }
 
-func (a *interactAction) performClick(page *rod.Page, selector string) {
-	element := page.MustElement(selector)
-	element.MustClick()
+func (a *interactAction) performClick(page *rod.Page, t *tracker, sel Selector) error {
+	return t.click(page, "click button", sel)
 }
 
 // LikeAction 负责处理点赞相关交互
@@ -85,12 +80,15 @@ func (a *LikeAction) perform(ctx context.Context, feedID, xsecToken string, targ
 		actionType = actionUnlike
 	}
 
-	page := a.preparePage(ctx, actionType, feedID, xsecToken)
+	page, t, err := a.preparePage(ctx, actionType, feedID, xsecToken)
+	if err != nil {
+		return err
+	}
 
 	liked, _, err := a.getInteractState(page, feedID)
 	if err != nil {
 		logrus.Warnf("failed to read interact state: %v (continue to try clicking)", err)
-		return a.toggleLike(page, feedID, targetLiked, actionType)
+		return a.toggleLike(page, t, feedID, targetLiked, actionType)
 	}
 
 	if targetLiked && liked {
@@ -102,11 +100,13 @@ func (a *LikeAction) perform(ctx context.Context, feedID, xsecToken string, targ
 		return nil
 	}
 
-	return a.toggleLike(page, feedID, targetLiked, actionType)
+	return a.toggleLike(page, t, feedID, targetLiked, actionType)
 }
 
-func (a *LikeAction) toggleLike(page *rod.Page, feedID string, targetLiked bool, actionType interactActionType) error {
-	a.performClick(page, SelectorLikeButton)
+func (a *LikeAction) toggleLike(page *rod.Page, t *tracker, feedID string, targetLiked bool, actionType interactActionType) error {
+	if err := a.performClick(page, t, SelectorLikeButton); err != nil {
+		return err
+	}
 	time.Sleep(3 * time.Second)
 
 	liked, _, err := a.getInteractState(page, feedID)
@@ -120,7 +120,9 @@ func (a *LikeAction) toggleLike(page *rod.Page, feedID string, targetLiked bool,
 	}
 
 	logrus.Warnf("feed %s %s可能未成功，状态未变化，尝试再次点击", feedID, actionType)
-	a.performClick(page, SelectorLikeButton)
+	if err := a.performClick(page, t, SelectorLikeButton); err != nil {
+		return err
+	}
 	time.Sleep(2 * time.Second)
 
 	liked, _, err = a.getInteractState(page, feedID)
@@ -161,12 +163,15 @@ func (a *FavoriteAction) perform(ctx context.Context, feedID, xsecToken string,
 		actionType = actionUnfavorite
 	}
 
-	page := a.preparePage(ctx, actionType, feedID, xsecToken)
+	page, t, err := a.preparePage(ctx, actionType, feedID, xsecToken)
+	if err != nil {
+		return err
+	}
 
 	_, collected, err := a.getInteractState(page, feedID)
 	if err != nil {
 		logrus.Warnf("failed to read interact state: %v (continue to try clicking)", err)
-		return a.toggleFavorite(page, feedID, targetCollected, actionType)
+		return a.toggleFavorite(page, t, feedID, targetCollected, actionType)
 	}
 
 	if targetCollected && collected {
@@ -178,11 +183,13 @@ func (a *FavoriteAction) perform(ctx context.Context, feedID, xsecToken string,
 		return nil
 	}
 
-	return a.toggleFavorite(page, feedID, targetCollected, actionType)
+	return a.toggleFavorite(page, t, feedID, targetCollected, actionType)
 }
 
-func (a *FavoriteAction) toggleFavorite(page *rod.Page, feedID string, targetCollected bool, actionType interactActionType) error {
-	a.performClick(page, SelectorCollectButton)
+func (a *FavoriteAction) toggleFavorite(page *rod.Page, t *tracker, feedID string, targetCollected bool, actionType interactActionType) error {
+	if err := a.performClick(page, t, SelectorCollectButton); err != nil {
+		return err
+	}
 	time.Sleep(3 * time.Second)
 
 	_, collected, err := a.getInteractState(page, feedID)
@@ -196,7 +203,9 @@ func (a *FavoriteAction) toggleFavorite(page *rod.Page, feedID string, targetCol
 	}
 
 	logrus.Warnf("feed %s %s可能未成功，状态未变化，尝试再次点击", feedID, actionType)
-	a.performClick(page, SelectorCollectButton)
+	if err := a.performClick(page, t, SelectorCollectButton); err != nil {
+		return err
+	}
 	time.Sleep(2 * time.Second)
 
 	_, collected, err = a.getInteractState(page, feedID)
@@ -215,14 +224,18 @@ func (a *FavoriteAction) toggleFavorite(page *rod.Page, feedID string, targetCol
 // getInteractState 从 __INITIAL_STATE__ 读取笔记的点赞/收藏状态
 func (a *interactAction) getInteractState(page *rod.Page, feedID string) (liked bool, collected bool, err error) {
 
-	result := page.MustEval(`() => {
+	res, err := page.Eval(`() => {
 		if (window.__INITIAL_STATE__ &&
 		    window.__INITIAL_STATE__.note &&
 		    window.__INITIAL_STATE__.note.noteDetailMap) {
 			return JSON.stringify(window.__INITIAL_STATE__.note.noteDetailMap);
 		}
 		return "";
-	}`).String()
+	}`)
+	if err != nil {
+		return false, false, errors.Wrap(err, "read noteDetailMap failed")
+	}
+	result := res.Value.String()
 	if result == "" {
 		return false, false, myerrors.ErrNoFeedDetail
 	}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
 	"github.com/pkg/errors"
 )
 
@@ -18,13 +19,18 @@ func NewLogin(page *rod.Page) *LoginAction {
 
 func (a *LoginAction) CheckLoginStatus(ctx context.Context) (bool, error) {
 	pp := a.page.Context(ctx)
-	pp.MustNavigate("https://www.xiaohongshu.com/explore").MustWaitLoad()
+	t := newTracker("check login status")
+
+	if err := t.navigate(pp, "open explore page", "https://www.xiaohongshu.com/explore", waitLoad); err != nil {
+		return false, err
+	}
 
 	time.Sleep(1 * time.Second)
 
-	exists, _, err := pp.Has(`.main-container .user .link-wrapper .channel`)
+	css := SelectorLoggedIn.css()
+	exists, _, err := hasElement(pp, css)
 	if err != nil {
-		return false, errors.Wrap(err, "check login status failed")
+		return false, t.fail(ErrPageData, "check login element", SelectorLoggedIn.describe(css), err)
 	}
 
 	if !exists {
@@ -36,44 +42,53 @@ func (a *LoginAction) CheckLoginStatus(ctx context.Context) (bool, error) {
 
 func (a *LoginAction) Login(ctx context.Context) error {
 	pp := a.page.Context(ctx)
+	t := newTracker("login")
 
 	// 导航到小红书首页，这会触发二维码弹窗
-	pp.MustNavigate("https://www.xiaohongshu.com/explore").MustWaitLoad()
+	if err := t.navigate(pp, "open explore page", "https://www.xiaohongshu.com/explore", waitLoad); err != nil {
+		return err
+	}
 
 	// 等待一小段时间让页面完全加载
 	time.Sleep(2 * time.Second)
 
 	// 检查是否已经登录
-	if exists, _, _ := pp.Has(".main-container .user .link-wrapper .channel"); exists {
+	if exists, _, _ := hasElement(pp, SelectorLoggedIn.css()); exists {
 		// 已经登录，直接返回
 		return nil
 	}
 
 	// 等待扫码成功提示或者登录完成
 	// 这里我们等待登录成功的元素出现，这样更简单可靠
-	pp.MustElement(".main-container .user .link-wrapper .channel")
-
-	return nil
+	_, err := t.element(pp, "wait for login", SelectorLoggedIn)
+	return err
 }
 
 func (a *LoginAction) FetchQrcodeImage(ctx context.Context) (string, bool, error) {
 	pp := a.page.Context(ctx)
+	t := newTracker("fetch login qrcode")
 
 	// 导航到小红书首页，这会触发二维码弹窗
-	pp.MustNavigate("https://www.xiaohongshu.com/explore").MustWaitLoad()
+	if err := t.navigate(pp, "open explore page", "https://www.xiaohongshu.com/explore", waitLoad); err != nil {
+		return "", false, err
+	}
 
 	// 等待一小段时间让页面完全加载
 	time.Sleep(2 * time.Second)
 
 	// 检查是否已经登录
-	if exists, _, _ := pp.Has(".main-container .user .link-wrapper .channel"); exists {
+	if exists, _, _ := hasElement(pp, SelectorLoggedIn.css()); exists {
 		return "", true, nil
 	}
 
 	// 获取二维码图片
-	src, err := pp.MustElement(".login-container .qrcode-img").Attribute("src")
+	el, err := t.element(pp, "find qrcode", SelectorQrcodeImage)
+	if err != nil {
+		return "", false, err
+	}
+	src, err := el.Attribute("src")
 	if err != nil {
-		return "", false, errors.Wrap(err, "get qrcode src failed")
+		return "", false, t.fail(ErrPageData, "read qrcode", string(SelectorQrcodeImage), err)
 	}
 	if src == nil || len(*src) == 0 {
 		return "", false, errors.New("qrcode src is empty")
@@ -92,10 +107,132 @@ func (a *LoginAction) WaitForLogin(ctx context.Context) bool {
 		case <-ctx.Done():
 			return false
 		case <-ticker.C:
-			el, err := pp.Element(".main-container .user .link-wrapper .channel")
+			el, err := findElement(pp, SelectorLoggedIn.css())
 			if err == nil && el != nil {
 				return true
 			}
 		}
 	}
 }
+
+// QrcodeStatus 扫码登录的二维码状态
+type QrcodeStatus string
+
+const (
+	QrcodeWaiting   QrcodeStatus = "waiting"   // 等待扫码
+	QrcodeScanned   QrcodeStatus = "scanned"   // 已扫码，等待在手机上确认
+	QrcodeConfirmed QrcodeStatus = "confirmed" // 已确认，登录成功
+	QrcodeExpired   QrcodeStatus = "expired"   // 二维码已过期
+)
+
+// QrcodeStatus 读取登录弹窗中的二维码状态
+func (a *LoginAction) QrcodeStatus(ctx context.Context) (QrcodeStatus, error) {
+	pp := a.page.Context(ctx)
+
+	if exists, _, err := hasElement(pp, SelectorLoggedIn.css()); err != nil {
+		return "", errors.Wrap(err, "check login status failed")
+	} else if exists {
+		return QrcodeConfirmed, nil
+	}
+
+	if expired, _, _ := hasElementR(pp, SelectorLoginExpired.css(), SelectorLoginExpired.text()); expired {
+		return QrcodeExpired, nil
+	}
+	if scanned, _, _ := hasElementR(pp, SelectorLoginScanned.css(), SelectorLoginScanned.text()); scanned {
+		return QrcodeScanned, nil
+	}
+	return QrcodeWaiting, nil
+}
+
+// RefreshQrcode 刷新已过期的二维码，返回新的二维码图片
+func (a *LoginAction) RefreshQrcode(ctx context.Context) (string, error) {
+	pp := a.page.Context(ctx)
+
+	// 优先点击弹窗中的刷新按钮，找不到时重新打开页面
+	if has, btn, _ := hasElementR(pp, SelectorLoginRefresh.css(), SelectorLoginRefresh.text()); has {
+		if err := btn.Click(proto.InputMouseButtonLeft, 1); err != nil {
+			return "", errors.Wrap(err, "click refresh qrcode failed")
+		}
+		time.Sleep(1 * time.Second)
+		return a.qrcodeImage(ctx)
+	}
+
+	img, loggedIn, err := a.FetchQrcodeImage(ctx)
+	if err != nil {
+		return "", err
+	}
+	if loggedIn {
+		return "", nil
+	}
+	return img, nil
+}
+
+// qrcodeImage 读取当前二维码图片
+func (a *LoginAction) qrcodeImage(ctx context.Context) (string, error) {
+	el, err := findElement(a.page.Context(ctx).Timeout(10*time.Second), SelectorQrcodeImage.css())
+	if err != nil {
+		return "", errors.Wrap(err, "qrcode not found")
+	}
+	src, err := el.Attribute("src")
+	if err != nil {
+		return "", errors.Wrap(err, "get qrcode src failed")
+	}
+	if src == nil || len(*src) == 0 {
+		return "", errors.New("qrcode src is empty")
+	}
+	return *src, nil
+}
+
+// WaitForQrcodeLogin 在 FetchQrcodeImage 之后轮询二维码状态直到登录成功或 ctx 结束。
+// 二维码过期时自动刷新，状态变化或二维码刷新时调用 onChange，img 为空表示二维码未变化。
+func (a *LoginAction) WaitForQrcodeLogin(ctx context.Context, onChange func(status QrcodeStatus, img string)) error {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	last := QrcodeWaiting
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		status, err := a.QrcodeStatus(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// 页面跳转中读取失败，下次再试
+			continue
+		}
+
+		switch status {
+		case QrcodeConfirmed:
+			onChange(QrcodeConfirmed, "")
+			return nil
+
+		case QrcodeExpired:
+			onChange(QrcodeExpired, "")
+			img, err := a.RefreshQrcode(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return errors.Wrap(err, "refresh qrcode failed")
+			}
+			if img == "" {
+				// 刷新时发现已登录
+				onChange(QrcodeConfirmed, "")
+				return nil
+			}
+			last = QrcodeWaiting
+			onChange(QrcodeWaiting, img)
+
+		default:
+			if status != last {
+				last = status
+				onChange(status, "")
+			}
+		}
+	}
+}
@@ -15,31 +15,36 @@ func NewNavigate(page *rod.Page) *NavigateAction {
 }
 
 func (n *NavigateAction) ToExplorePage(ctx context.Context) error {
-	page := n.page.Context(ctx)
-
-	page.MustNavigate("https://www.xiaohongshu.com/explore").
-		MustWaitLoad().
-		MustElement(`div#app`)
+	return n.toExplorePage(n.page.Context(ctx), newTracker("navigate to explore page"))
+}
 
-	return nil
+func (n *NavigateAction) toExplorePage(page *rod.Page, t *tracker) error {
+	if err := t.navigate(page, "open explore page", "https://www.xiaohongshu.com/explore", waitLoad); err != nil {
+		return err
+	}
+	_, err := t.element(page, "wait for app", SelectorAppRoot)
+	return err
 }
 
 func (n *NavigateAction) ToProfilePage(ctx context.Context) error {
-	page := n.page.Context(ctx)
+	return n.toProfilePage(n.page.Context(ctx), newTracker("navigate to profile page"))
+}
 
+func (n *NavigateAction) toProfilePage(page *rod.Page, t *tracker) error {
 	// First navigate to explore page
-	if err := n.ToExplorePage(ctx); err != nil {
+	if err := n.toExplorePage(page, t); err != nil {
 		return err
 	}
 
-	page.MustWaitStable()
+	if err := t.do("wait for explore page", ErrNavigation, "", func() error { return waitStable(page) }); err != nil {
+		return err
+	}
 
 	// Find and click the "我" channel link in sidebar
-	profileLink := page.MustElement(`div.main-container li.user.side-bar-component a.link-wrapper span.channel`)
-	profileLink.MustClick()
+	if err := t.click(page, "click profile link", SelectorSidebarProfile); err != nil {
+		return err
+	}
 
 	// Wait for navigation to complete
-	page.MustWaitLoad()
-
-	return nil
+	return t.do("wait for profile page", ErrNavigation, "", func() error { return waitLoad(page) })
 }
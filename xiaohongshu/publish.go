@@ -21,10 +21,15 @@ type PublishImageContent struct {
 	Content    string
 	Tags       []string
 	ImagePaths []string
+	Options    PublishOptions // 定时发布、可见范围、原创声明
 }
 
 type PublishAction struct {
-	page *rod.Page
+	page  *rod.Page
+	steps *tracker // 从打开发布页开始记录进度，失败时报告已完成的步骤
+
+	// 打开发布页前笔记管理列表中已有的笔记 ID，发布后只接受不在其中的笔记
+	existingNotes map[string]bool
 }
 
 const (
@@ -34,11 +39,15 @@ const (
 func NewPublishImageAction(page *rod.Page) (*PublishAction, error) {
 
 	pp := page.Timeout(300 * time.Second)
+	t := newTracker("publish image note")
+	existing := snapshotPostedNotes(pp)
 
-	pp.MustNavigate(urlOfPublic).MustWaitIdle().MustWaitDOMStable()
+	if err := t.navigate(pp, "open publish page", urlOfPublic, waitIdle, waitDOMStable); err != nil {
+		return nil, err
+	}
 	time.Sleep(1 * time.Second)
 
-	if err := mustClickPublishTab(page, "上传图文"); err != nil {
+	if err := clickPublishTab(pp, t, "上传图文"); err != nil {
 		logrus.Errorf("点击上传图文 TAB 失败: %v", err)
 		return nil, err
 	}
@@ -46,19 +55,22 @@ func NewPublishImageAction(page *rod.Page) (*PublishAction, error) {
 	time.Sleep(1 * time.Second)
 
 	return &PublishAction{
-		page: pp,
+		page:          pp,
+		steps:         t,
+		existingNotes: existing,
 	}, nil
 }
 
-func (p *PublishAction) Publish(ctx context.Context, content PublishImageContent) error {
+// Publish 上传图片并提交，返回发布后的笔记信息（获取失败时为 nil）
+func (p *PublishAction) Publish(ctx context.Context, content PublishImageContent) (*PublishedNote, error) {
 	if len(content.ImagePaths) == 0 {
-		return errors.New("图片不能为空")
+		return nil, errors.New("图片不能为空")
 	}
 
 	page := p.page.Context(ctx)
 
-	if err := uploadImages(page, content.ImagePaths); err != nil {
-		return errors.Wrap(err, "小红书上传图片失败")
+	if err := uploadImages(page, p.steps, content.ImagePaths); err != nil {
+		return nil, errors.Wrap(err, "小红书上传图片失败")
 	}
 
 	tags := content.Tags
@@ -69,22 +81,24 @@ func (p *PublishAction) Publish(ctx context.Context, content PublishImageContent
 
 	logrus.Infof("发布内容: title=%s, images=%v, tags=%v", content.Title, len(content.ImagePaths), tags)
 
-	if err := submitPublish(page, content.Title, content.Content, tags); err != nil {
-		return errors.Wrap(err, "小红书发布失败")
+	if err := submitPublish(page, p.steps, content.Title, content.Content, tags, content.Options); err != nil {
+		return nil, errors.Wrap(err, "小红书发布失败")
 	}
 
-	return nil
+	return publishedNote(page, content.Title, p.existingNotes), nil
 }
 
 func removePopCover(page *rod.Page) {
 
 	// 先移除弹窗封面
-	has, elem, err := page.Has("div.d-popover")
+	has, elem, err := hasElement(page, SelectorPublishPopover.css())
 	if err != nil {
 		return
 	}
 	if has {
-		elem.MustRemove()
+		if err := elem.Remove(); err != nil {
+			logrus.Warnf("移除弹窗失败: %v", err)
+		}
 	}
 
 	// 兜底：点击一下空位置吧
@@ -94,11 +108,24 @@ func removePopCover(page *rod.Page) {
 func clickEmptyPosition(page *rod.Page) {
 	x := 380 + rand.Intn(100)
 	y := 20 + rand.Intn(60)
-	page.Mouse.MustMoveTo(float64(x), float64(y)).MustClick(proto.InputMouseButtonLeft)
+	if err := page.Mouse.MoveTo(proto.Point{X: float64(x), Y: float64(y)}); err != nil {
+		logrus.Warnf("移动鼠标失败: %v", err)
+		return
+	}
+	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		logrus.Warnf("点击空白位置失败: %v", err)
+	}
 }
 
-func mustClickPublishTab(page *rod.Page, tabname string) error {
-	page.MustElement(`div.upload-content`).MustWaitVisible()
+// clickPublishTab 切换发布页的 TAB（上传图文 / 上传视频）
+func clickPublishTab(page *rod.Page, t *tracker, tabname string) error {
+	uploadContent, err := t.element(page, "wait for upload area", SelectorPublishUploadArea)
+	if err != nil {
+		return err
+	}
+	if err := t.do("wait for upload area", ErrElementNotFound, string(SelectorPublishUploadArea), uploadContent.WaitVisible); err != nil {
+		return err
+	}
 
 	deadline := time.Now().Add(15 * time.Second)
 	for time.Now().Before(deadline) {
@@ -127,14 +154,15 @@ func mustClickPublishTab(page *rod.Page, tabname string) error {
 			continue
 		}
 
+		t.done = append(t.done, "switch to tab "+tabname)
 		return nil
 	}
 
-	return errors.Errorf("没有找到发布 TAB - %s", tabname)
+	return t.fail(ErrElementNotFound, "switch to tab "+tabname, string(SelectorPublishTab), errors.Errorf("没有找到发布 TAB - %s", tabname))
 }
 
 func getTabElement(page *rod.Page, tabname string) (*rod.Element, bool, error) {
-	elems, err := page.Elements("div.creator-tab")
+	elems, err := findElements(page, SelectorPublishTab.css())
 	if err != nil {
 		return nil, false, err
 	}
@@ -183,7 +211,7 @@ func isElementBlocked(elem *rod.Element) (bool, error) {
 	return result.Value.Bool(), nil
 }
 
-func uploadImages(page *rod.Page, imagesPaths []string) error {
+func uploadImages(page *rod.Page, t *tracker, imagesPaths []string) error {
 	pp := page.Timeout(30 * time.Second)
 
 	// 验证文件路径有效性
@@ -199,13 +227,18 @@ func uploadImages(page *rod.Page, imagesPaths []string) error {
 	}
 
 	// 等待上传输入框出现
-	uploadInput := pp.MustElement(".upload-input")
-
-	// 上传多个文件
-	uploadInput.MustSetFiles(validPaths...)
+	uploadInput, err := t.element(pp, "find image upload input", SelectorPublishImageInput)
+	if err != nil {
+		return err
+	}
 
-	// 等待并验证上传完成
-	return waitForUploadComplete(pp, len(validPaths))
+	// 上传多个文件，并等待验证上传完成
+	return t.do("upload images", ErrInteraction, string(SelectorPublishImageInput), func() error {
+		if err := uploadInput.SetFiles(validPaths); err != nil {
+			return err
+		}
+		return waitForUploadComplete(pp, len(validPaths))
+	})
 }
 
 // waitForUploadComplete 等待并验证上传完成
@@ -218,7 +251,7 @@ func waitForUploadComplete(page *rod.Page, expectedCount int) error {
 
 	for time.Since(start) < maxWaitTime {
 		// 使用具体的pr类名检查已上传的图片
-		uploadedImages, err := page.Elements(".img-preview-area .pr")
+		uploadedImages, err := findElements(page, SelectorPublishImagePreview.css())
 
 		slog.Info("uploadedImages", "uploadedImages", uploadedImages)
 
@@ -239,134 +272,162 @@ func waitForUploadComplete(page *rod.Page, expectedCount int) error {
 	return errors.New("上传超时，请检查网络连接和图片大小")
 }
 
-func submitPublish(page *rod.Page, title, content string, tags []string) error {
+func submitPublish(page *rod.Page, t *tracker, title, content string, tags []string, opts PublishOptions) error {
 
-	titleElem := page.MustElement("div.d-input input")
-	titleElem.MustInput(title)
+	if err := t.input(page, "input title", SelectorPublishTitleInput, title); err != nil {
+		return err
+	}
 
 	time.Sleep(1 * time.Second)
 
-	if contentElem, ok := getContentElement(page); ok {
-		contentElem.MustInput(content)
-
-		inputTags(contentElem, tags)
-
-	} else {
-		return errors.New("没有找到内容输入框")
+	if err := inputContent(page, t, content, tags); err != nil {
+		return err
 	}
 
 	time.Sleep(1 * time.Second)
 
-	submitButton := page.MustElement("div.submit div.d-button-content")
-	submitButton.MustClick()
+	if err := t.do("apply publish options", ErrInteraction, "", func() error { return applyPublishOptions(page, opts) }); err != nil {
+		return err
+	}
+
+	if err := t.click(page, "click publish button", SelectorPublishSubmitButton); err != nil {
+		return err
+	}
 
 	time.Sleep(3 * time.Second)
 
 	return nil
 }
 
-// 查找内容输入框 - 使用Race方法处理两种样式
-func getContentElement(page *rod.Page) (*rod.Element, bool) {
-	var foundElement *rod.Element
-	var found bool
-
-	page.Race().
-		Element("div.ql-editor").MustHandle(func(e *rod.Element) {
-		foundElement = e
-		found = true
-	}).
-		ElementFunc(func(page *rod.Page) (*rod.Element, error) {
-			return findTextboxByPlaceholder(page)
-		}).MustHandle(func(e *rod.Element) {
-		foundElement = e
-		found = true
-	}).
-		MustDo()
-
-	if found {
-		return foundElement, true
-	}
-
-	slog.Warn("no content element found by any method")
-	return nil, false
+// inputContent 输入正文和标签，图文和视频共用
+func inputContent(page *rod.Page, t *tracker, content string, tags []string) error {
+	contentElem, err := getContentElement(page)
+	if err != nil {
+		return t.fail(ErrElementNotFound, "input content", string(SelectorPublishContentEditor), errors.Wrap(err, "没有找到内容输入框"))
+	}
+
+	if err := t.do("input content", ErrInteraction, string(SelectorPublishContentEditor), func() error { return contentElem.Input(content) }); err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+	return t.do("input tags", ErrInteraction, string(SelectorPublishTopicContainer), func() error { return inputTags(contentElem, tags) })
+}
+
+// 查找内容输入框 - 使用Race方法处理两种样式，页面超时前一直重试
+func getContentElement(page *rod.Page) (*rod.Element, error) {
+	race := page.Race()
+	for _, css := range SelectorPublishContentEditor.css() {
+		race = race.Element(css)
+	}
+	el, err := race.ElementFunc(findTextboxByPlaceholder).Do()
+	if err != nil {
+		slog.Warn("no content element found by any method", "error", err)
+		return nil, err
+	}
+	return el, nil
 }
 
-func inputTags(contentElem *rod.Element, tags []string) {
+func inputTags(contentElem *rod.Element, tags []string) error {
 	if len(tags) == 0 {
-		return
+		return nil
 	}
 
 	time.Sleep(1 * time.Second)
 
 	for i := 0; i < 20; i++ {
-		contentElem.MustKeyActions().
-			Type(input.ArrowDown).
-			MustDo()
+		ka, err := contentElem.KeyActions()
+		if err == nil {
+			err = ka.Type(input.ArrowDown).Do()
+		}
+		if err != nil {
+			return errors.Wrap(err, "移动到正文末尾失败")
+		}
 		time.Sleep(10 * time.Millisecond)
 	}
 
-	contentElem.MustKeyActions().
-		Press(input.Enter).
-		Press(input.Enter).
-		MustDo()
+	ka, err := contentElem.KeyActions()
+	if err == nil {
+		err = ka.Press(input.Enter).Press(input.Enter).Do()
+	}
+	if err != nil {
+		return errors.Wrap(err, "正文换行失败")
+	}
 
 	time.Sleep(1 * time.Second)
 
 	for _, tag := range tags {
 		tag = strings.TrimLeft(tag, "#")
-		inputTag(contentElem, tag)
+		if err := inputTag(contentElem, tag); err != nil {
+			return errors.Wrapf(err, "输入标签 %s 失败", tag)
+		}
 	}
+	return nil
 }
 
-func inputTag(contentElem *rod.Element, tag string) {
-	contentElem.MustInput("#")
+func inputTag(contentElem *rod.Element, tag string) error {
+	if err := contentElem.Input("#"); err != nil {
+		return err
+	}
 	time.Sleep(200 * time.Millisecond)
 
 	for _, char := range tag {
-		contentElem.MustInput(string(char))
+		if err := contentElem.Input(string(char)); err != nil {
+			return err
+		}
 		time.Sleep(50 * time.Millisecond)
 	}
 
 	time.Sleep(1 * time.Second)
 
 	page := contentElem.Page()
-	topicContainer, err := page.Element("#creator-editor-topic-container")
+	topicContainer, err := findElement(page, SelectorPublishTopicContainer.css())
 	if err == nil && topicContainer != nil {
-		firstItem, err := topicContainer.Element(".item")
+		firstItem, err := findChild(topicContainer, SelectorPublishTopicItem.css())
 		if err == nil && firstItem != nil {
-			firstItem.MustClick()
+			if err := firstItem.Click(proto.InputMouseButtonLeft, 1); err != nil {
+				return err
+			}
 			slog.Info("成功点击标签联想选项", "tag", tag)
 			time.Sleep(200 * time.Millisecond)
 		} else {
 			slog.Warn("未找到标签联想选项，直接输入空格", "tag", tag)
 			// 如果没有找到联想选项，输入空格结束
-			contentElem.MustInput(" ")
+			if err := contentElem.Input(" "); err != nil {
+				return err
+			}
 		}
 	} else {
 		slog.Warn("未找到标签联想下拉框，直接输入空格", "tag", tag)
 		// 如果没有找到下拉框，输入空格结束
-		contentElem.MustInput(" ")
+		if err := contentElem.Input(" "); err != nil {
+			return err
+		}
 	}
 
 	time.Sleep(500 * time.Millisecond) // 等待标签处理完成
+	return nil
 }
 
+// findTextboxByPlaceholder 通过正文占位文字查找输入框，未找到时返回 ElementNotFoundError 以便 Race 继续重试
 func findTextboxByPlaceholder(page *rod.Page) (*rod.Element, error) {
-	elements := page.MustElements("p")
-	if elements == nil {
-		return nil, errors.New("no p elements found")
+	elements, err := page.Elements("p")
+	if err != nil {
+		return nil, err
 	}
 
 	// 查找包含指定placeholder的元素
 	placeholderElem := findPlaceholderElement(elements, "输入正文描述")
 	if placeholderElem == nil {
-		return nil, errors.New("no placeholder element found")
+		return nil, &rod.ElementNotFoundError{}
 	}
 
 	// 向上查找textbox父元素
 	textboxElem := findTextboxParent(placeholderElem)
 	if textboxElem == nil {
-		return nil, errors.New("no textbox parent found")
+		return nil, &rod.ElementNotFoundError{}
 	}
 
 	return textboxElem, nil
@@ -0,0 +1,228 @@
+package xiaohongshu
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Visibility 笔记可见范围
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"  // 公开可见
+	VisibilityPrivate Visibility = "private" // 仅自己可见
+	VisibilityFriends Visibility = "friends" // 仅互关好友可见
+)
+
+// visibilityLabels 可见范围在创作者中心的选项文案，用于解析参数
+var visibilityLabels = map[Visibility]string{
+	VisibilityPublic:  "公开可见",
+	VisibilityPrivate: "仅自己可见",
+	VisibilityFriends: "仅互关好友可见",
+}
+
+// visibilityOptions 可见范围对应的下拉选项，页面上匹配的文案在选择器注册表中
+var visibilityOptions = map[Visibility]Selector{
+	VisibilityPublic:  SelectorPublishVisibilityPublic,
+	VisibilityPrivate: SelectorPublishVisibilityPrivate,
+	VisibilityFriends: SelectorPublishVisibilityFriends,
+}
+
+// 创作者中心定时发布的时间范围
+const (
+	minScheduleAhead = 1 * time.Hour
+	maxScheduleAhead = 14 * 24 * time.Hour
+)
+
+// ParseVisibility 解析可见范围，支持 public/private/friends 或中文选项文案，空字符串为公开
+func ParseVisibility(s string) (Visibility, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return VisibilityPublic, nil
+	}
+	for v, label := range visibilityLabels {
+		if strings.EqualFold(s, string(v)) || s == label {
+			return v, nil
+		}
+	}
+	return "", errors.Errorf("不支持的可见范围: %s", s)
+}
+
+// PublishOptions 发布设置：定时发布、可见范围、原创声明
+type PublishOptions struct {
+	ScheduleAt time.Time  // 定时发布时间，零值表示立即发布
+	Visibility Visibility // 可见范围，空值表示公开
+	Original   bool       // 是否声明原创
+}
+
+// Validate 校验发布设置，定时发布需在 1 小时至 14 天之间
+func (o PublishOptions) Validate(now time.Time) error {
+	if o.Visibility != "" {
+		if _, ok := visibilityLabels[o.Visibility]; !ok {
+			return errors.Errorf("不支持的可见范围: %s", o.Visibility)
+		}
+	}
+
+	if !o.ScheduleAt.IsZero() {
+		ahead := o.ScheduleAt.Sub(now)
+		if ahead < minScheduleAhead || ahead > maxScheduleAhead {
+			return errors.Errorf("定时发布时间需在 1 小时至 14 天之间: %s", o.ScheduleAt.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// applyPublishOptions 在点击发布前设置原创声明、可见范围和定时发布
+func applyPublishOptions(page *rod.Page, opts PublishOptions) error {
+	if opts.Original {
+		if err := declareOriginal(page); err != nil {
+			return errors.Wrap(err, "设置原创声明失败")
+		}
+	}
+
+	if opts.Visibility != "" && opts.Visibility != VisibilityPublic {
+		if err := selectVisibility(page, opts.Visibility); err != nil {
+			return errors.Wrap(err, "设置可见范围失败")
+		}
+	}
+
+	if !opts.ScheduleAt.IsZero() {
+		if err := setScheduleTime(page, opts.ScheduleAt); err != nil {
+			return errors.Wrap(err, "设置定时发布失败")
+		}
+	}
+	return nil
+}
+
+// declareOriginal 打开“原创声明”开关，并在弹窗中同意协议后确认
+func declareOriginal(page *rod.Page) error {
+	pp := page.Timeout(30 * time.Second)
+
+	label, err := findElementR(pp, SelectorPublishOriginalLabel.css(), SelectorPublishOriginalLabel.text())
+	if err != nil {
+		return errors.Wrap(err, "未找到原创声明选项")
+	}
+	if err := clickSwitchNear(label); err != nil {
+		return err
+	}
+	time.Sleep(1 * time.Second)
+
+	// 首次声明会弹出协议确认弹窗
+	has, dialog, err := hasElement(pp, SelectorPublishModal.css())
+	if err != nil || !has {
+		return nil
+	}
+	if hasCheckbox, checkbox, _ := hasChild(dialog, SelectorPublishModalCheckbox.css()); hasCheckbox {
+		if err := checkbox.Click(proto.InputMouseButtonLeft, 1); err != nil {
+			return errors.Wrap(err, "勾选原创声明协议失败")
+		}
+	}
+	confirm, err := findChildR(dialog, SelectorPublishOriginalConfirm.css(), SelectorPublishOriginalConfirm.text())
+	if err != nil {
+		return errors.Wrap(err, "未找到原创声明确认按钮")
+	}
+	if err := confirm.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return errors.Wrap(err, "点击原创声明确认按钮失败")
+	}
+	time.Sleep(500 * time.Millisecond)
+	return nil
+}
+
+// selectVisibility 在“权限设置”下拉框中选择可见范围
+func selectVisibility(page *rod.Page, v Visibility) error {
+	pp := page.Timeout(30 * time.Second)
+
+	// 下拉框默认显示“公开可见”
+	dropdown, err := findElementR(pp, SelectorPublishVisibility.css(), SelectorPublishVisibility.text())
+	if err != nil {
+		return errors.Wrap(err, "未找到权限设置下拉框")
+	}
+	if err := dropdown.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return errors.Wrap(err, "打开权限设置下拉框失败")
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	label := visibilityLabels[v]
+	item := visibilityOptions[v]
+	option, err := findElementR(pp, item.css(), item.text())
+	if err != nil {
+		return errors.Wrapf(err, "未找到可见范围选项: %s", label)
+	}
+	if err := option.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return errors.Wrapf(err, "选择可见范围失败: %s", label)
+	}
+	time.Sleep(500 * time.Millisecond)
+	return nil
+}
+
+// setScheduleTime 打开“定时发布”开关并填写发布时间
+func setScheduleTime(page *rod.Page, at time.Time) error {
+	pp := page.Timeout(30 * time.Second)
+
+	label, err := findElementR(pp, SelectorPublishScheduleLabel.css(), SelectorPublishScheduleLabel.text())
+	if err != nil {
+		return errors.Wrap(err, "未找到定时发布选项")
+	}
+	if err := clickSwitchNear(label); err != nil {
+		return err
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	input, err := findElement(pp, SelectorPublishScheduleInput.css())
+	if err != nil {
+		return errors.Wrap(err, "未找到定时发布时间输入框")
+	}
+	if err := input.SelectAllText(); err != nil {
+		return errors.Wrap(err, "选中定时发布时间失败")
+	}
+
+	// 创作者中心使用北京时间
+	value := at.In(chinaTimezone).Format("2006-01-02 15:04")
+	if err := input.Input(value); err != nil {
+		return errors.Wrap(err, "填写定时发布时间失败")
+	}
+	if err := input.Blur(); err != nil {
+		logrus.Warnf("定时发布时间输入框失焦失败: %v", err)
+	}
+
+	logrus.Infof("已设置定时发布: %s", value)
+	time.Sleep(500 * time.Millisecond)
+	return nil
+}
+
+// clickSwitchNear 点击文案所在设置项中的开关，已打开时跳过
+func clickSwitchNear(label *rod.Element) error {
+	row := label
+	for i := 0; i < 4; i++ {
+		if has, sw, err := hasChild(row, SelectorPublishSwitch.css()); err == nil && has {
+			if cls, _ := sw.Attribute("class"); cls != nil && strings.Contains(*cls, "checked") {
+				return nil
+			}
+			if err := sw.Click(proto.InputMouseButtonLeft, 1); err != nil {
+				return errors.Wrap(err, "点击开关失败")
+			}
+			return nil
+		}
+		parent, err := row.Parent()
+		if err != nil {
+			break
+		}
+		row = parent
+	}
+
+	// 没有独立开关时，直接点击文案（如勾选框样式）
+	return label.Click(proto.InputMouseButtonLeft, 1)
+}
+
+// chinaTimezone 北京时间，无时区数据库时使用固定偏移
+var chinaTimezone = func() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*60*60)
+}()
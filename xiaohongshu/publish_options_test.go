@@ -0,0 +1,42 @@
+package xiaohongshu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVisibility(t *testing.T) {
+	v, err := ParseVisibility("")
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityPublic, v)
+
+	v, err = ParseVisibility("Private")
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityPrivate, v)
+
+	v, err = ParseVisibility("仅互关好友可见")
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityFriends, v)
+
+	_, err = ParseVisibility("everyone")
+	assert.Error(t, err)
+
+	// 每个可见范围都要有可匹配文字的下拉选项
+	for v, label := range visibilityLabels {
+		assert.Regexp(t, visibilityOptions[v].text(), label)
+	}
+}
+
+func TestPublishOptionsValidate(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, PublishOptions{}.Validate(now))
+	assert.NoError(t, PublishOptions{ScheduleAt: now.Add(2 * time.Hour), Visibility: VisibilityPrivate}.Validate(now))
+
+	assert.Error(t, PublishOptions{ScheduleAt: now.Add(30 * time.Minute)}.Validate(now))
+	assert.Error(t, PublishOptions{ScheduleAt: now.Add(15 * 24 * time.Hour)}.Validate(now))
+	assert.Error(t, PublishOptions{Visibility: "everyone"}.Validate(now))
+}
@@ -0,0 +1,186 @@
+package xiaohongshu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	urlOfNoteManager = `https://creator.xiaohongshu.com/new/note-manager`
+
+	// 笔记管理页加载笔记列表的接口
+	postedNotesAPIPath = `/web_api/sns/v5/creator/note/user/posted`
+)
+
+// PublishedNote 发布成功后的笔记信息
+type PublishedNote struct {
+	NoteID    string `json:"note_id"`
+	XsecToken string `json:"xsec_token,omitempty"`
+	URL       string `json:"url"`
+}
+
+// postedNote 笔记管理列表中的笔记
+type postedNote struct {
+	ID           string `json:"id"`
+	DisplayTitle string `json:"display_title"`
+	XsecToken    string `json:"xsec_token"`
+}
+
+// publishedNote 确认发布成功并获取笔记信息，笔记已经发布，获取失败只记录日志
+// existing 为发布前已有的笔记 ID，用来排除标题相同的旧笔记
+func publishedNote(page *rod.Page, title string, existing map[string]bool) *PublishedNote {
+	waitForPublishSuccess(page)
+
+	note, err := resolvePublishedNote(page, title, existing)
+	if err != nil {
+		logrus.Warnf("发布成功，但获取笔记 ID 失败: %v", err)
+		return nil
+	}
+	return note
+}
+
+// snapshotPostedNotes 在打开发布页之前记录笔记管理列表中已有的笔记 ID，失败时返回 nil
+func snapshotPostedNotes(page *rod.Page) map[string]bool {
+	notes, err := fetchPostedNotes(page)
+	if err != nil {
+		logrus.Warnf("获取发布前的笔记列表失败，发布后将不返回笔记 ID: %v", err)
+		return nil
+	}
+
+	existing := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		existing[note.ID] = true
+	}
+	return existing
+}
+
+// waitForPublishSuccess 等待发布成功页，超时只记录日志，由后续的笔记列表确认结果
+func waitForPublishSuccess(page *rod.Page) {
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		if info, err := page.Info(); err == nil && strings.Contains(info.URL, "published=true") {
+			return
+		}
+		if has, _, err := hasElementR(page, SelectorPublishSuccess.css(), SelectorPublishSuccess.text()); err == nil && has {
+			return
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	logrus.Warn("未检测到发布成功页面，继续从笔记管理列表确认")
+}
+
+// resolvePublishedNote 轮询创作者中心的笔记管理列表，按标题找到刚发布的新笔记
+func resolvePublishedNote(page *rod.Page, title string, existing map[string]bool) (*PublishedNote, error) {
+	const attempts = 4
+
+	// 没有发布前的快照就无法区分同名的旧笔记，宁可不返回也不返回错的笔记
+	if existing == nil {
+		return nil, errors.New("没有发布前的笔记列表，无法确认新发布的笔记")
+	}
+
+	ctx := page.GetContext()
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			// 新笔记进入列表可能有延迟
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
+		}
+
+		notes, err := fetchPostedNotes(page)
+		if err != nil {
+			lastErr = err
+			logrus.Warnf("获取笔记管理列表失败(%d/%d): %v", i+1, attempts, err)
+			continue
+		}
+
+		if note, ok := matchPublishedNote(notes, title, existing); ok {
+			logrus.Infof("已获取发布笔记: %s", note.NoteID)
+			return note, nil
+		}
+		lastErr = errors.Errorf("笔记管理列表中没有找到标题为 %q 的新笔记", title)
+	}
+
+	return nil, lastErr
+}
+
+// fetchPostedNotes 打开笔记管理页，并读取页面加载的笔记列表接口响应
+func fetchPostedNotes(page *rod.Page) ([]postedNote, error) {
+	pp := page.Timeout(30 * time.Second)
+
+	var requestID proto.NetworkRequestID
+	wait := pp.EachEvent(func(e *proto.NetworkResponseReceived) {
+		if requestID == "" && strings.Contains(e.Response.URL, postedNotesAPIPath) {
+			requestID = e.RequestID
+		}
+	}, func(e *proto.NetworkLoadingFinished) bool {
+		return requestID != "" && e.RequestID == requestID
+	})
+
+	if err := pp.Navigate(urlOfNoteManager); err != nil {
+		return nil, errors.Wrap(err, "打开笔记管理页失败")
+	}
+	wait()
+
+	if requestID == "" {
+		return nil, errors.New("没有捕获到笔记列表接口响应")
+	}
+
+	body, err := proto.NetworkGetResponseBody{RequestID: requestID}.Call(pp)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取笔记列表接口响应失败")
+	}
+
+	return parsePostedNotes(body.Body)
+}
+
+// parsePostedNotes 解析笔记列表接口响应
+func parsePostedNotes(body string) ([]postedNote, error) {
+	var resp struct {
+		Success bool   `json:"success"`
+		Msg     string `json:"msg"`
+		Data    struct {
+			Notes []postedNote `json:"notes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		return nil, errors.Wrap(err, "解析笔记列表失败")
+	}
+	if !resp.Success {
+		return nil, errors.Errorf("笔记列表接口返回失败: %s", resp.Msg)
+	}
+	return resp.Data.Notes, nil
+}
+
+// matchPublishedNote 按标题匹配发布前不存在的笔记，列表按发布时间倒序，取第一条
+func matchPublishedNote(notes []postedNote, title string, existing map[string]bool) (*PublishedNote, bool) {
+	title = strings.TrimSpace(title)
+	for _, note := range notes {
+		if note.ID == "" || existing[note.ID] || strings.TrimSpace(note.DisplayTitle) != title {
+			continue
+		}
+		return &PublishedNote{
+			NoteID:    note.ID,
+			XsecToken: note.XsecToken,
+			URL:       makeNoteURL(note.ID, note.XsecToken),
+		}, true
+	}
+	return nil, false
+}
+
+func makeNoteURL(noteID, xsecToken string) string {
+	if xsecToken == "" {
+		return fmt.Sprintf("https://www.xiaohongshu.com/explore/%s", noteID)
+	}
+	return fmt.Sprintf("https://www.xiaohongshu.com/explore/%s?xsec_token=%s&xsec_source=pc_creatormng", noteID, xsecToken)
+}
@@ -0,0 +1,47 @@
+package xiaohongshu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPublishedNote(t *testing.T) {
+	body := `{
+		"code": 0,
+		"success": true,
+		"data": {
+			"notes": [
+				{"id": "6712a0000000000021003abc", "display_title": "周末去哪儿", "xsec_token": "ABtoken1", "time": "2025-01-02 20:00"},
+				{"id": "6711f0000000000021001def", "display_title": "周末去哪儿", "xsec_token": "ABtoken2", "time": "2025-01-01 09:00"}
+			]
+		}
+	}`
+
+	notes, err := parsePostedNotes(body)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+
+	note, ok := matchPublishedNote(notes, " 周末去哪儿 ", map[string]bool{})
+	require.True(t, ok)
+	assert.Equal(t, "6712a0000000000021003abc", note.NoteID)
+	assert.Equal(t, "ABtoken1", note.XsecToken)
+	assert.Equal(t, "https://www.xiaohongshu.com/explore/6712a0000000000021003abc?xsec_token=ABtoken1&xsec_source=pc_creatormng", note.URL)
+
+	_, ok = matchPublishedNote(notes, "另一篇", map[string]bool{})
+	assert.False(t, ok)
+
+	// 同名的旧笔记已在发布前的列表中，不能当作新笔记返回
+	_, ok = matchPublishedNote(notes, "周末去哪儿", map[string]bool{
+		"6712a0000000000021003abc": true,
+		"6711f0000000000021001def": true,
+	})
+	assert.False(t, ok)
+	note, ok = matchPublishedNote(notes, "周末去哪儿", map[string]bool{"6711f0000000000021001def": true})
+	require.True(t, ok)
+	assert.Equal(t, "6712a0000000000021003abc", note.NoteID)
+
+	_, err = parsePostedNotes(`{"success": false, "msg": "登录已过期"}`)
+	assert.Error(t, err)
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"testing"
 
-	"github.com/xpzouying/xiaohongshu-mcp/browser"
-
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,16 +12,13 @@ func TestPublish(t *testing.T) {
 
 	t.Skip("SKIP: 测试发布")
 
-	b := browser.NewBrowser(false)
-	defer b.Close()
-
-	page := b.NewPage()
+	page := newTestPage(t)
 	defer page.Close()
 
 	action, err := NewPublishImageAction(page)
 	require.NoError(t, err)
 
-	err = action.Publish(context.Background(), PublishImageContent{
+	_, err = action.Publish(context.Background(), PublishImageContent{
 		Title:      "Hello World",
 		Content:    "Hello World",
 		ImagePaths: []string{"/tmp/1.jpg"},
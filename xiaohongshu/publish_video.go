@@ -18,44 +18,61 @@ type PublishVideoContent struct {
 	Content   string
 	Tags      []string
 	VideoPath string
+
+	// 封面（可选）：CoverPath 为自定义封面图片，否则 CoverTime 不为 nil 时截取该时间点的画面作为封面
+	CoverPath     string
+	CoverTime     *time.Duration
+	VideoDuration time.Duration // 视频时长，用于在时间轴上定位封面帧
+
+	Options PublishOptions // 定时发布、可见范围、原创声明
 }
 
 // NewPublishVideoAction 进入发布页并切换到“上传视频”
 func NewPublishVideoAction(page *rod.Page) (*PublishAction, error) {
 	pp := page.Timeout(300 * time.Second)
+	t := newTracker("publish video note")
+	existing := snapshotPostedNotes(pp)
 
-	pp.MustNavigate(urlOfPublic).MustWaitIdle().MustWaitDOMStable()
+	if err := t.navigate(pp, "open publish page", urlOfPublic, waitIdle, waitDOMStable); err != nil {
+		return nil, err
+	}
 	time.Sleep(1 * time.Second)
 
-	if err := mustClickPublishTab(page, "上传视频"); err != nil {
+	if err := clickPublishTab(pp, t, "上传视频"); err != nil {
 		return nil, errors.Wrap(err, "切换到上传视频失败")
 	}
 
 	time.Sleep(1 * time.Second)
 
-	return &PublishAction{page: pp}, nil
+	return &PublishAction{page: pp, steps: t, existingNotes: existing}, nil
 }
 
-// PublishVideo 上传视频并提交
-func (p *PublishAction) PublishVideo(ctx context.Context, content PublishVideoContent) error {
+// PublishVideo 上传视频并提交，返回发布后的笔记信息（获取失败时为 nil）
+func (p *PublishAction) PublishVideo(ctx context.Context, content PublishVideoContent) (*PublishedNote, error) {
 	if content.VideoPath == "" {
-		return errors.New("视频不能为空")
+		return nil, errors.New("视频不能为空")
 	}
 
 	page := p.page.Context(ctx)
 
-	if err := uploadVideo(page, content.VideoPath); err != nil {
-		return errors.Wrap(err, "小红书上传视频失败")
+	if err := uploadVideo(page, p.steps, content.VideoPath); err != nil {
+		return nil, errors.Wrap(err, "小红书上传视频失败")
 	}
 
-	if err := submitPublishVideo(page, content.Title, content.Content, content.Tags); err != nil {
-		return errors.Wrap(err, "小红书发布失败")
+	if content.CoverPath != "" || content.CoverTime != nil {
+		if err := p.steps.do("set video cover", ErrInteraction, string(SelectorPublishModal), func() error { return setVideoCover(page, content) }); err != nil {
+			return nil, errors.Wrap(err, "设置视频封面失败")
+		}
 	}
-	return nil
+
+	if err := submitPublishVideo(page, p.steps, content.Title, content.Content, content.Tags, content.Options); err != nil {
+		return nil, errors.Wrap(err, "小红书发布失败")
+	}
+	return publishedNote(page, content.Title, p.existingNotes), nil
 }
 
 // uploadVideo 上传单个本地视频
-func uploadVideo(page *rod.Page, videoPath string) error {
+func uploadVideo(page *rod.Page, t *tracker, videoPath string) error {
 	pp := page.Timeout(5 * time.Minute) // 视频处理耗时更长
 
 	if _, err := os.Stat(videoPath); os.IsNotExist(err) {
@@ -63,20 +80,21 @@ func uploadVideo(page *rod.Page, videoPath string) error {
 	}
 
 	// 寻找文件上传输入框（与图文一致的 class，或退回到 input[type=file]）
-	var fileInput *rod.Element
-	var err error
-	fileInput, err = pp.Element(".upload-input")
-	if err != nil || fileInput == nil {
-		fileInput, err = pp.Element("input[type='file']")
-		if err != nil || fileInput == nil {
-			return errors.New("未找到视频上传输入框")
-		}
+	fileInput, err := t.element(pp, "find video upload input", SelectorPublishVideoInput)
+	if err != nil {
+		return err
 	}
 
-	fileInput.MustSetFiles(videoPath)
+	if err := t.do("upload video", ErrInteraction, string(SelectorPublishVideoInput), func() error { return fileInput.SetFiles([]string{videoPath}) }); err != nil {
+		return err
+	}
 
 	// 对于视频，等待发布按钮变为可点击即表示处理完成
-	btn, err := waitForPublishButtonClickable(pp)
+	var btn *rod.Element
+	err = t.do("wait for video processing", ErrPageData, string(SelectorPublishVideoButton), func() error {
+		btn, err = waitForPublishButtonClickable(pp)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -84,17 +102,144 @@ func uploadVideo(page *rod.Page, videoPath string) error {
 	return nil
 }
 
+// setVideoCover 打开封面编辑弹窗，上传自定义封面或截取视频帧，并确认
+func setVideoCover(page *rod.Page, content PublishVideoContent) error {
+	pp := page.Timeout(2 * time.Minute)
+
+	if content.CoverPath != "" {
+		if _, err := os.Stat(content.CoverPath); err != nil {
+			return errors.Wrapf(err, "封面文件不存在: %s", content.CoverPath)
+		}
+	}
+
+	// 视频处理完成后才会出现封面入口
+	entry, err := findElementR(pp, SelectorPublishCoverEntry.css(), SelectorPublishCoverEntry.text())
+	if err != nil {
+		return errors.Wrap(err, "未找到封面编辑入口")
+	}
+	if err := entry.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return errors.Wrap(err, "点击封面编辑入口失败")
+	}
+
+	dialog, err := findElement(pp, SelectorPublishModal.css())
+	if err != nil {
+		return errors.Wrap(err, "未找到封面编辑弹窗")
+	}
+	if err := dialog.WaitVisible(); err != nil {
+		return errors.Wrap(err, "等待封面编辑弹窗失败")
+	}
+	time.Sleep(1 * time.Second)
+
+	if content.CoverPath != "" {
+		err = uploadCoverImage(dialog, content.CoverPath)
+	} else {
+		err = selectCoverFrame(dialog, *content.CoverTime, content.VideoDuration)
+	}
+	if err != nil {
+		return err
+	}
+
+	confirm, err := findChildR(dialog, SelectorPublishCoverConfirm.css(), SelectorPublishCoverConfirm.text())
+	if err != nil {
+		return errors.Wrap(err, "未找到封面确认按钮")
+	}
+	if err := confirm.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return errors.Wrap(err, "点击封面确认按钮失败")
+	}
+
+	// 等待弹窗关闭，封面生效
+	if err := dialog.WaitInvisible(); err != nil {
+		return errors.Wrap(err, "等待封面编辑弹窗关闭失败")
+	}
+	if content.CoverPath != "" {
+		slog.Info("视频封面设置完成", "cover", content.CoverPath)
+	} else {
+		slog.Info("视频封面设置完成", "time", *content.CoverTime)
+	}
+	return nil
+}
+
+// uploadCoverImage 在封面弹窗中切换到“上传封面”并上传图片
+func uploadCoverImage(dialog *rod.Element, coverPath string) error {
+	// 弹窗默认停留在上一次的标签页，存在该标签时先切换过去
+	if has, tab, err := hasChildR(dialog, SelectorPublishCoverUploadTab.css(), SelectorPublishCoverUploadTab.text()); err == nil && has {
+		if err := tab.Click(proto.InputMouseButtonLeft, 1); err != nil {
+			return errors.Wrap(err, "切换到上传封面失败")
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	fileInput, err := findChild(dialog, SelectorPublishCoverInput.css())
+	if err != nil {
+		return errors.Wrap(err, "未找到封面上传输入框")
+	}
+	if err := fileInput.SetFiles([]string{coverPath}); err != nil {
+		return errors.Wrap(err, "上传封面图片失败")
+	}
+
+	// 等待封面预览加载
+	time.Sleep(3 * time.Second)
+	return nil
+}
+
+// selectCoverFrame 在封面弹窗的视频时间轴上点击指定时间点的画面
+func selectCoverFrame(dialog *rod.Element, at, duration time.Duration) error {
+	// 同上，先切换到截取封面标签页
+	if has, tab, err := hasChildR(dialog, SelectorPublishCoverFrameTab.css(), SelectorPublishCoverFrameTab.text()); err == nil && has {
+		if err := tab.Click(proto.InputMouseButtonLeft, 1); err != nil {
+			return errors.Wrap(err, "切换到截取封面失败")
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	timeline, err := findChild(dialog, SelectorPublishCoverTimeline.css())
+	if err != nil {
+		return errors.Wrap(err, "未找到封面时间轴")
+	}
+
+	shape, err := timeline.Shape()
+	if err != nil {
+		return errors.Wrap(err, "获取封面时间轴位置失败")
+	}
+	box := shape.Box()
+
+	// 两端各留 1 像素，保证 cover_time=0 和视频末尾也能点中时间轴
+	x := box.X + 1 + (box.Width-2)*coverFrameRatio(at, duration)
+	y := box.Y + box.Height/2
+
+	mouse := dialog.Page().Mouse
+	if err := mouse.MoveTo(proto.Point{X: x, Y: y}); err != nil {
+		return errors.Wrap(err, "移动到封面帧失败")
+	}
+	if err := mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
+		return errors.Wrap(err, "点击封面帧失败")
+	}
+
+	// 等待截取的画面刷新到预览区
+	time.Sleep(1 * time.Second)
+	return nil
+}
+
+// coverFrameRatio 计算封面时间点在时间轴上的相对位置，范围 [0, 1]
+func coverFrameRatio(at, duration time.Duration) float64 {
+	if duration <= 0 || at <= 0 {
+		return 0
+	}
+	if at >= duration {
+		return 1
+	}
+	return float64(at) / float64(duration)
+}
+
 // waitForPublishButtonClickable 等待发布按钮可点击
 func waitForPublishButtonClickable(page *rod.Page) (*rod.Element, error) {
 	maxWait := 10 * time.Minute
 	interval := 1 * time.Second
 	start := time.Now()
-	selector := "button.publishBtn"
-
 	slog.Info("开始等待发布按钮可点击(视频)")
 
 	for time.Since(start) < maxWait {
-		btn, err := page.Element(selector)
+		btn, err := findElement(page, SelectorPublishVideoButton.css())
 		if err == nil && btn != nil {
 			// 可见性
 			vis, verr := btn.Visible()
@@ -115,32 +260,35 @@ func waitForPublishButtonClickable(page *rod.Page) (*rod.Element, error) {
 	return nil, errors.New("等待发布按钮可点击超时")
 }
 
-// submitPublishVideo 填写标题、正文、标签并点击发布（等待按钮可点击后再提交）
-func submitPublishVideo(page *rod.Page, title, content string, tags []string) error {
+// submitPublishVideo 填写标题、正文、标签和发布设置并点击发布（等待按钮可点击后再提交）
+func submitPublishVideo(page *rod.Page, t *tracker, title, content string, tags []string, opts PublishOptions) error {
 	// 标题
-	titleElem := page.MustElement("div.d-input input")
-	titleElem.MustInput(title)
+	if err := t.input(page, "input title", SelectorPublishTitleInput, title); err != nil {
+		return err
+	}
 	time.Sleep(1 * time.Second)
 
 	// 正文 + 标签
-	if contentElem, ok := getContentElement(page); ok {
-		contentElem.MustInput(content)
-		inputTags(contentElem, tags)
-	} else {
-		return errors.New("没有找到内容输入框")
+	if err := inputContent(page, t, content, tags); err != nil {
+		return err
 	}
 
 	time.Sleep(1 * time.Second)
 
-	// 等待发布按钮可点击
-	btn, err := waitForPublishButtonClickable(page)
-	if err != nil {
+	if err := t.do("apply publish options", ErrInteraction, "", func() error { return applyPublishOptions(page, opts) }); err != nil {
 		return err
 	}
 
-	// 点击发布
-	if err := btn.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return errors.Wrap(err, "点击发布按钮失败")
+	// 等待发布按钮可点击后点击发布
+	err := t.do("click publish button", ErrInteraction, string(SelectorPublishVideoButton), func() error {
+		btn, err := waitForPublishButtonClickable(page)
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(btn.Click(proto.InputMouseButtonLeft, 1), "点击发布按钮失败")
+	})
+	if err != nil {
+		return err
 	}
 
 	time.Sleep(3 * time.Second)
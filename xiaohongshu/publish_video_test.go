@@ -0,0 +1,17 @@
+package xiaohongshu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverFrameRatio(t *testing.T) {
+	duration := 40 * time.Second
+
+	assert.Equal(t, 0.0, coverFrameRatio(0, duration))
+	assert.Equal(t, 0.25, coverFrameRatio(10*time.Second, duration))
+	assert.Equal(t, 1.0, coverFrameRatio(time.Minute, duration))
+	assert.Equal(t, 0.0, coverFrameRatio(10*time.Second, 0))
+}
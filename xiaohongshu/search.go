@@ -167,54 +167,66 @@ func NewSearchAction(page *rod.Page) *SearchAction {
 
 func (s *SearchAction) Search(ctx context.Context, keyword string, filters ...FilterOption) ([]Feed, error) {
 	page := s.page.Context(ctx)
+	t := newTracker("search")
+
+	// 先校验筛选条件，避免打开页面后才发现参数错误
+	var allInternalFilters []internalFilterOption
+	for _, filter := range filters {
+		internalFilters, err := convertToInternalFilters(filter)
+		if err != nil {
+			return nil, fmt.Errorf("筛选选项转换失败: %w", err)
+		}
+		allInternalFilters = append(allInternalFilters, internalFilters...)
+	}
+	for _, filter := range allInternalFilters {
+		if err := validateInternalFilterOption(filter); err != nil {
+			return nil, fmt.Errorf("筛选选项验证失败: %w", err)
+		}
+	}
 
 	searchURL := makeSearchURL(keyword)
-	page.MustNavigate(searchURL)
-	page.MustWaitStable()
+	if err := t.navigate(page, "open search page", searchURL, waitStable); err != nil {
+		return nil, err
+	}
 
-	page.MustWait(`() => window.__INITIAL_STATE__ !== undefined`)
+	if err := t.wait(page, "wait for initial state", `() => window.__INITIAL_STATE__ !== undefined`); err != nil {
+		return nil, err
+	}
 
 	// 如果有筛选条件，则应用筛选
-	if len(filters) > 0 {
-		// 将所有 FilterOption 转换为内部筛选选项
-		var allInternalFilters []internalFilterOption
-		for _, filter := range filters {
-			internalFilters, err := convertToInternalFilters(filter)
-			if err != nil {
-				return nil, fmt.Errorf("筛选选项转换失败: %w", err)
-			}
-			allInternalFilters = append(allInternalFilters, internalFilters...)
+	if len(allInternalFilters) > 0 {
+		// 悬停在筛选按钮上
+		filterButton, err := t.element(page, "open filter panel", SelectorSearchFilterButton)
+		if err != nil {
+			return nil, err
 		}
-
-		// 验证所有内部筛选选项
-		for _, filter := range allInternalFilters {
-			if err := validateInternalFilterOption(filter); err != nil {
-				return nil, fmt.Errorf("筛选选项验证失败: %w", err)
-			}
+		if err := t.do("open filter panel", ErrInteraction, string(SelectorSearchFilterButton), filterButton.Hover); err != nil {
+			return nil, err
 		}
 
-		// 悬停在筛选按钮上
-		filterButton := page.MustElement(`div.filter`)
-		filterButton.MustHover()
-
 		// 等待筛选面板出现
-		page.MustWait(`() => document.querySelector('div.filter-panel') !== null`)
+		if _, err := t.element(page, "wait for filter panel", SelectorSearchFilterPanel); err != nil {
+			return nil, err
+		}
 
 		// 应用所有筛选条件
 		for _, filter := range allInternalFilters {
-			selector := fmt.Sprintf(`div.filter-panel div.filters:nth-child(%d) div.tags:nth-child(%d)`,
-				filter.FiltersIndex, filter.TagsIndex)
-			option := page.MustElement(selector)
-			option.MustClick()
+			if err := t.click(page, "select filter "+filter.Text, SelectorSearchFilterTag, filter.FiltersIndex, filter.TagsIndex); err != nil {
+				return nil, err
+			}
 		}
 
 		// 等待页面更新
-		page.MustWaitStable()
+		if err := t.do("wait for filtered results", ErrNavigation, "", func() error { return waitStable(page) }); err != nil {
+			return nil, err
+		}
 		// 重新等待 __INITIAL_STATE__ 更新
-		page.MustWait(`() => window.__INITIAL_STATE__ !== undefined`)
+		if err := t.wait(page, "wait for initial state", `() => window.__INITIAL_STATE__ !== undefined`); err != nil {
+			return nil, err
+		}
 	}
 
-	result := page.MustEval(`() => {
+	result, err := t.eval(page, "read search results", `() => {
 		if (window.__INITIAL_STATE__ &&
 		    window.__INITIAL_STATE__.search &&
 		    window.__INITIAL_STATE__.search.feeds) {
@@ -225,10 +237,13 @@ func (s *SearchAction) Search(ctx context.Context, keyword string, filters ...Fi
 			}
 		}
 		return "";
-	}`).String()
+	}`)
+	if err != nil {
+		return nil, err
+	}
 
 	if result == "" {
-		return nil, errors.ErrNoFeeds
+		return nil, t.fail(ErrPageData, "read search results", "", errors.ErrNoFeeds)
 	}
 
 	var feeds []Feed
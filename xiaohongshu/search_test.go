@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/go-rod/rod"
 	"github.com/stretchr/testify/require"
 	"github.com/xpzouying/xiaohongshu-mcp/browser"
 )
@@ -13,10 +14,7 @@ func TestSearch(t *testing.T) {
 
 	t.Skip("SKIP: 测试发布")
 
-	b := browser.NewBrowser(false)
-	defer b.Close()
-
-	page := b.NewPage()
+	page := newTestPage(t)
 	defer func() {
 		_ = page.Close()
 	}()
@@ -39,10 +37,7 @@ func TestSearchWithFilters(t *testing.T) {
 
 	//t.Skip("SKIP: 测试筛选功能")
 
-	b := browser.NewBrowser(false)
-	defer b.Close()
-
-	page := b.NewPage()
+	page := newTestPage(t)
 	defer func() {
 		_ = page.Close()
 	}()
@@ -103,3 +98,18 @@ func TestFilterValidation(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, internalFilters, 5)
 }
+
+// newTestPage 启动浏览器并打开页面，测试结束时关闭浏览器；没有可用的浏览器时跳过测试
+func newTestPage(t *testing.T) *rod.Page {
+	t.Helper()
+
+	b, err := browser.NewBrowser(false)
+	if err != nil {
+		t.Skipf("SKIP: 没有可用的浏览器: %v", err)
+	}
+	t.Cleanup(b.Close)
+
+	page, err := b.OpenPage()
+	require.NoError(t, err)
+	return page
+}
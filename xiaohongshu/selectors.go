@@ -0,0 +1,472 @@
+package xiaohongshu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// BuiltinSelectorsVersion 内置选择器的版本，小红书页面改版、内置选择器随之更新时递增
+const BuiltinSelectorsVersion = "2026.10.3"
+
+// Selector 页面元素在选择器注册表中的名称，对应一个或多个候选 CSS 选择器
+type Selector string
+
+// 页面元素
+const (
+	SelectorAppRoot        Selector = "app.root"
+	SelectorLoggedIn       Selector = "app.logged_in"
+	SelectorSidebarProfile Selector = "app.sidebar_profile"
+
+	SelectorQrcodeImage Selector = "login.qrcode_image"
+
+	// 登录弹窗中的提示和按钮按文字匹配
+	SelectorLoginExpired Selector = "login.expired_text"
+	SelectorLoginScanned Selector = "login.scanned_text"
+	SelectorLoginRefresh Selector = "login.refresh_button"
+
+	SelectorLikeButton    Selector = "feed.like_button"
+	SelectorCollectButton Selector = "feed.collect_button"
+
+	SelectorCommentActivate Selector = "comment.activate"
+	SelectorCommentInput    Selector = "comment.input"
+	SelectorCommentSubmit   Selector = "comment.submit"
+
+	SelectorSearchFilterButton Selector = "search.filter_button"
+	SelectorSearchFilterPanel  Selector = "search.filter_panel"
+	SelectorSearchFilterTag    Selector = "search.filter_tag" // 模板，依次填入筛选组和标签的序号
+
+	SelectorPublishPopover        Selector = "publish.popover"
+	SelectorPublishUploadArea     Selector = "publish.upload_area"
+	SelectorPublishTab            Selector = "publish.tab"
+	SelectorPublishImageInput     Selector = "publish.image_input"
+	SelectorPublishImagePreview   Selector = "publish.image_preview"
+	SelectorPublishTitleInput     Selector = "publish.title_input"
+	SelectorPublishContentEditor  Selector = "publish.content_editor"
+	SelectorPublishTopicContainer Selector = "publish.topic_container"
+	SelectorPublishTopicItem      Selector = "publish.topic_item"
+	SelectorPublishSubmitButton   Selector = "publish.submit_button"
+	SelectorPublishModal          Selector = "publish.modal"
+	SelectorPublishModalCheckbox  Selector = "publish.modal_checkbox"
+	SelectorPublishVisibility     Selector = "publish.visibility_dropdown"
+	SelectorPublishScheduleInput  Selector = "publish.schedule_input"
+	SelectorPublishSwitch         Selector = "publish.switch"
+	SelectorPublishVideoInput     Selector = "publish.video_input"
+	SelectorPublishVideoButton    Selector = "publish.video_submit_button"
+	SelectorPublishCoverInput     Selector = "publish.cover_input"
+	SelectorPublishCoverTimeline  Selector = "publish.cover_timeline"
+
+	// 以下元素按文字匹配，候选选择器限定元素类型，文字正则见 builtinSelectorTexts
+	SelectorPublishOriginalLabel   Selector = "publish.original_label"
+	SelectorPublishOriginalConfirm Selector = "publish.original_confirm"
+	SelectorPublishScheduleLabel   Selector = "publish.schedule_label"
+	SelectorPublishSuccess         Selector = "publish.success"
+	SelectorPublishCoverEntry      Selector = "publish.cover_entry"
+	SelectorPublishCoverConfirm    Selector = "publish.cover_confirm"
+	SelectorPublishCoverUploadTab  Selector = "publish.cover_upload_tab"
+	SelectorPublishCoverFrameTab   Selector = "publish.cover_frame_tab"
+
+	// 可见范围下拉框中的选项
+	SelectorPublishVisibilityPublic  Selector = "publish.visibility_public"
+	SelectorPublishVisibilityPrivate Selector = "publish.visibility_private"
+	SelectorPublishVisibilityFriends Selector = "publish.visibility_friends"
+)
+
+// builtinSelectors 内置的候选选择器，按顺序尝试，靠前的优先
+var builtinSelectors = map[Selector][]string{
+	SelectorAppRoot:        {`div#app`},
+	SelectorLoggedIn:       {`.main-container .user .link-wrapper .channel`},
+	SelectorSidebarProfile: {`div.main-container li.user.side-bar-component a.link-wrapper span.channel`},
+
+	SelectorQrcodeImage:  {`.login-container .qrcode-img`},
+	SelectorLoginExpired: {`.login-container div, .login-container span, .login-container p`},
+	SelectorLoginScanned: {`.login-container div, .login-container span, .login-container p`},
+	SelectorLoginRefresh: {`.login-container div, .login-container span, .login-container p`},
+
+	SelectorLikeButton:    {`.interact-container .left .like-lottie`},
+	SelectorCollectButton: {`.interact-container .left .reds-icon.collect-icon`},
+
+	SelectorCommentActivate: {`div.input-box div.content-edit span`},
+	SelectorCommentInput:    {`div.input-box div.content-edit p.content-input`},
+	SelectorCommentSubmit:   {`div.bottom button.submit`},
+
+	SelectorSearchFilterButton: {`div.filter`},
+	SelectorSearchFilterPanel:  {`div.filter-panel`},
+	SelectorSearchFilterTag:    {`div.filter-panel div.filters:nth-child(%d) div.tags:nth-child(%d)`},
+
+	SelectorPublishPopover:        {`div.d-popover`},
+	SelectorPublishUploadArea:     {`div.upload-content`},
+	SelectorPublishTab:            {`div.creator-tab`},
+	SelectorPublishImageInput:     {`.upload-input`},
+	SelectorPublishImagePreview:   {`.img-preview-area .pr`},
+	SelectorPublishTitleInput:     {`div.d-input input`},
+	SelectorPublishContentEditor:  {`div.ql-editor`},
+	SelectorPublishTopicContainer: {`#creator-editor-topic-container`},
+	SelectorPublishTopicItem:      {`.item`},
+	SelectorPublishSubmitButton:   {`div.submit div.d-button-content`},
+	SelectorPublishModal:          {`div.d-modal`},
+	SelectorPublishModalCheckbox:  {`input[type='checkbox']`, `.d-checkbox`},
+	SelectorPublishVisibility:     {`div.d-select-wrapper`, `div.permission-card-wrapper div`},
+	SelectorPublishScheduleInput:  {`div.date-picker input`, `input[placeholder*='时间']`},
+	SelectorPublishSwitch:         {`.d-switch`},
+	SelectorPublishVideoInput:     {`.upload-input`, `input[type='file']`},
+	SelectorPublishVideoButton:    {`button.publishBtn`},
+	SelectorPublishCoverInput:     {`input[type='file']`},
+	SelectorPublishCoverTimeline:  {`.frame-list`, `.frames`, `.video-frames`},
+
+	SelectorPublishOriginalLabel:   {`span, div`},
+	SelectorPublishOriginalConfirm: {`button`},
+	SelectorPublishScheduleLabel:   {`span, div`},
+	SelectorPublishSuccess:         {`div, span`},
+	SelectorPublishCoverEntry:      {`div, span, button`},
+	SelectorPublishCoverConfirm:    {`button`},
+	SelectorPublishCoverUploadTab:  {`div, span`},
+	SelectorPublishCoverFrameTab:   {`div, span`},
+
+	SelectorPublishVisibilityPublic:  {`div.d-options-wrapper div`, `div.custom-option`},
+	SelectorPublishVisibilityPrivate: {`div.d-options-wrapper div`, `div.custom-option`},
+	SelectorPublishVisibilityFriends: {`div.d-options-wrapper div`, `div.custom-option`},
+}
+
+// builtinSelectorTexts 按文字匹配的元素的文字正则（JavaScript 语法）
+var builtinSelectorTexts = map[Selector]string{
+	SelectorLoginExpired: `二维码已过期|二维码已失效|已过期`,
+	SelectorLoginScanned: `扫码成功|已扫码|请在手机上确认`,
+	// 需要锚定整段文字，否则会先匹配到包含按钮文字的外层容器，点到弹窗中间
+	SelectorLoginRefresh: `^\s*(点击刷新|刷新二维码|重新获取)\s*$`,
+
+	SelectorPublishVisibility:      `^\s*公开可见\s*$`, // 下拉框默认显示“公开可见”
+	SelectorPublishOriginalLabel:   `^\s*原创声明\s*$`,
+	SelectorPublishOriginalConfirm: `声明原创|确定`,
+	SelectorPublishScheduleLabel:   `^\s*定时发布\s*$`,
+	SelectorPublishSuccess:         `^\s*发布成功\s*$`,
+	SelectorPublishCoverEntry:      `^\s*(设置封面|修改封面|编辑封面)\s*$`,
+	SelectorPublishCoverConfirm:    `^\s*(确定|完成)\s*$`,
+	SelectorPublishCoverUploadTab:  `^\s*上传封面\s*$`,
+	SelectorPublishCoverFrameTab:   `^\s*截取封面\s*$`,
+
+	SelectorPublishVisibilityPublic:  `^\s*公开可见`,
+	SelectorPublishVisibilityPrivate: `^\s*仅自己可见`,
+	SelectorPublishVisibilityFriends: `^\s*仅互关好友可见`,
+}
+
+// SelectorsFile 选择器覆盖文件（JSON 或 YAML），用于小红书改版后不重新编译即可修复选择器：
+//
+//	version: "2026-10-19-hotfix"
+//	selectors:
+//	  comment.input:
+//	    - div.comment-box p.content-input
+//	texts:
+//	  publish.success: ^\s*(发布成功|已发布)\s*$
+//
+// 文件中的候选排在内置候选之前，内置候选作为兜底；文字正则与内置正则取“或”，同样优先匹配文件中的
+type SelectorsFile struct {
+	Version   string                `json:"version" yaml:"version"`
+	Selectors map[Selector][]string `json:"selectors" yaml:"selectors"`
+	Texts     map[Selector]string   `json:"texts,omitempty" yaml:"texts"`
+}
+
+// SelectorsInfo 当前生效的选择器
+type SelectorsInfo struct {
+	Version        string                `json:"version"`
+	BuiltinVersion string                `json:"builtin_version"`
+	Source         string                `json:"source,omitempty"` // 覆盖文件路径，未加载时为空
+	Selectors      map[Selector][]string `json:"selectors"`
+	Texts          map[Selector]string   `json:"texts"`
+}
+
+// selectorRegistry 当前生效的选择器，覆盖文件可在运行时重新加载
+type selectorRegistry struct {
+	mu        sync.RWMutex
+	version   string
+	source    string
+	selectors map[Selector][]string
+	texts     map[Selector]string
+}
+
+var selectors = &selectorRegistry{
+	version:   BuiltinSelectorsVersion,
+	selectors: builtinSelectors,
+	texts:     builtinSelectorTexts,
+}
+
+// LoadSelectors 从覆盖文件加载选择器，替换之前加载的覆盖；path 为空时恢复为内置选择器。
+// 文件中有未知名称或空的候选列表时返回错误，当前选择器保持不变
+func LoadSelectors(path string) error {
+	if path == "" {
+		selectors.set(BuiltinSelectorsVersion, "", builtinSelectors, builtinSelectorTexts)
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "读取选择器文件失败")
+	}
+
+	var file SelectorsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	default:
+		err = json.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "解析选择器文件 %s 失败", path)
+	}
+
+	merged, err := mergeSelectors(file.Selectors)
+	if err != nil {
+		return errors.Wrapf(err, "选择器文件 %s 无效", path)
+	}
+	texts, err := mergeSelectorTexts(file.Texts)
+	if err != nil {
+		return errors.Wrapf(err, "选择器文件 %s 无效", path)
+	}
+
+	version := file.Version
+	if version == "" {
+		version = BuiltinSelectorsVersion + "+" + filepath.Base(path)
+	}
+	selectors.set(version, path, merged, texts)
+	logrus.Infof("已加载选择器 %s（%d 项覆盖）: %s", version, len(file.Selectors)+len(file.Texts), path)
+	return nil
+}
+
+// ReloadSelectors 重新读取上次加载的覆盖文件
+func ReloadSelectors() error {
+	return LoadSelectors(CurrentSelectors().Source)
+}
+
+// CurrentSelectors 返回当前生效的选择器
+func CurrentSelectors() SelectorsInfo {
+	selectors.mu.RLock()
+	defer selectors.mu.RUnlock()
+
+	out := make(map[Selector][]string, len(selectors.selectors))
+	for name, css := range selectors.selectors {
+		out[name] = append([]string(nil), css...)
+	}
+	texts := make(map[Selector]string, len(selectors.texts))
+	for name, text := range selectors.texts {
+		texts[name] = text
+	}
+	return SelectorsInfo{
+		Version:        selectors.version,
+		BuiltinVersion: BuiltinSelectorsVersion,
+		Source:         selectors.source,
+		Selectors:      out,
+		Texts:          texts,
+	}
+}
+
+// mergeSelectors 将覆盖的候选放在内置候选之前，去掉重复项
+func mergeSelectors(overrides map[Selector][]string) (map[Selector][]string, error) {
+	var unknown []string
+	for name, css := range overrides {
+		if _, ok := builtinSelectors[name]; !ok {
+			unknown = append(unknown, string(name))
+			continue
+		}
+		if len(css) == 0 {
+			return nil, errors.Errorf("%s 没有候选选择器", name)
+		}
+		for _, c := range css {
+			if strings.TrimSpace(c) == "" {
+				return nil, errors.Errorf("%s 包含空的选择器", name)
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.Errorf("未知的选择器名称: %s", strings.Join(unknown, ", "))
+	}
+
+	merged := make(map[Selector][]string, len(builtinSelectors))
+	for name, builtin := range builtinSelectors {
+		var css []string
+		seen := map[string]bool{}
+		for _, c := range append(append([]string(nil), overrides[name]...), builtin...) {
+			if !seen[c] {
+				seen[c] = true
+				css = append(css, c)
+			}
+		}
+		merged[name] = css
+	}
+	return merged, nil
+}
+
+// mergeSelectorTexts 将覆盖的文字正则与内置正则合并为“或”，覆盖的在前
+func mergeSelectorTexts(overrides map[Selector]string) (map[Selector]string, error) {
+	var unknown []string
+	for name, text := range overrides {
+		if _, ok := builtinSelectorTexts[name]; !ok {
+			unknown = append(unknown, string(name))
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			return nil, errors.Errorf("%s 的文字正则为空", name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, errors.Errorf("未知的文字选择器名称: %s", strings.Join(unknown, ", "))
+	}
+
+	merged := make(map[Selector]string, len(builtinSelectorTexts))
+	for name, builtin := range builtinSelectorTexts {
+		merged[name] = builtin
+		if text, ok := overrides[name]; ok && text != builtin {
+			merged[name] = fmt.Sprintf("(?:%s)|(?:%s)", text, builtin)
+		}
+	}
+	return merged, nil
+}
+
+func (r *selectorRegistry) set(version, source string, sels map[Selector][]string, texts map[Selector]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.version = version
+	r.source = source
+	r.selectors = sels
+	r.texts = texts
+}
+
+// css 返回当前生效的候选选择器，args 用于填充模板中的 %d
+func (s Selector) css(args ...any) []string {
+	selectors.mu.RLock()
+	candidates := selectors.selectors[s]
+	selectors.mu.RUnlock()
+
+	if len(args) == 0 {
+		return candidates
+	}
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		out[i] = fmt.Sprintf(c, args...)
+	}
+	return out
+}
+
+// text 返回按文字匹配的元素当前生效的文字正则，用于 findElementR 等函数
+func (s Selector) text() string {
+	selectors.mu.RLock()
+	defer selectors.mu.RUnlock()
+	return selectors.texts[s]
+}
+
+// describe 用于错误信息，包含名称和尝试过的候选
+func (s Selector) describe(css []string) string {
+	return fmt.Sprintf("%s (%s)", s, strings.Join(css, " | "))
+}
+
+// findElement 查找任一候选匹配的元素，页面超时前一直重试；多个候选同时存在时使用靠前的候选
+func findElement(page *rod.Page, css []string) (*rod.Element, error) {
+	if len(css) == 1 {
+		return page.Element(css[0])
+	}
+	race := page.Race()
+	for _, c := range css {
+		race = race.Element(c)
+	}
+	return race.Do()
+}
+
+// findElementR 查找任一候选匹配且文字符合 jsRegex 的元素，页面超时前一直重试
+func findElementR(page *rod.Page, css []string, jsRegex string) (*rod.Element, error) {
+	race := page.Race()
+	for _, c := range css {
+		race = race.ElementR(c, jsRegex)
+	}
+	return race.Do()
+}
+
+// hasElement 按顺序检查候选，返回第一个存在的元素，不等待
+func hasElement(page *rod.Page, css []string) (bool, *rod.Element, error) {
+	for _, c := range css {
+		has, el, err := page.Has(c)
+		if err != nil {
+			return false, nil, err
+		}
+		if has {
+			return true, el, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// hasElementR 按顺序检查候选中文字符合 jsRegex 的元素，不等待
+func hasElementR(page *rod.Page, css []string, jsRegex string) (bool, *rod.Element, error) {
+	for _, c := range css {
+		has, el, err := page.HasR(c, jsRegex)
+		if err != nil {
+			return false, nil, err
+		}
+		if has {
+			return true, el, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// findElements 返回第一个有匹配结果的候选匹配到的全部元素，不等待
+func findElements(page *rod.Page, css []string) (rod.Elements, error) {
+	for _, c := range css {
+		elems, err := page.Elements(c)
+		if err != nil {
+			return nil, err
+		}
+		if len(elems) > 0 {
+			return elems, nil
+		}
+	}
+	return nil, nil
+}
+
+// hasChild 按顺序检查 el 下的候选，返回第一个存在的元素，不等待
+func hasChild(el *rod.Element, css []string) (bool, *rod.Element, error) {
+	for _, c := range css {
+		has, child, err := el.Has(c)
+		if err != nil {
+			return false, nil, err
+		}
+		if has {
+			return true, child, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// hasChildR 按顺序检查 el 下文字符合 jsRegex 的候选，返回第一个存在的元素，不等待
+func hasChildR(el *rod.Element, css []string, jsRegex string) (bool, *rod.Element, error) {
+	for _, c := range css {
+		has, child, err := el.HasR(c, jsRegex)
+		if err != nil {
+			return false, nil, err
+		}
+		if has {
+			return true, child, nil
+		}
+	}
+	return false, nil, nil
+}
+
+// findChildR 查找 el 下任一候选匹配且文字符合 jsRegex 的元素，超时前一直重试，候选合并方式同 findChild
+func findChildR(el *rod.Element, css []string, jsRegex string) (*rod.Element, error) {
+	return el.ElementR(strings.Join(css, ", "), jsRegex)
+}
+
+// findChild 查找 el 下任一候选匹配的元素，超时前一直重试；元素内没有 Race，候选合并为一个选择器列表，
+// 同时存在时按文档顺序返回
+func findChild(el *rod.Element, css []string) (*rod.Element, error) {
+	return el.Element(strings.Join(css, ", "))
+}
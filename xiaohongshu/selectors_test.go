@@ -0,0 +1,77 @@
+package xiaohongshu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSelectors(t *testing.T) {
+	t.Cleanup(func() { _ = LoadSelectors("") })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "selectors.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+version: 2026-10-19-hotfix
+selectors:
+  comment.input:
+    - div.comment-box p.content-input
+    - div.input-box div.content-edit p.content-input
+`), 0600))
+
+	require.NoError(t, LoadSelectors(path))
+
+	info := CurrentSelectors()
+	assert.Equal(t, "2026-10-19-hotfix", info.Version)
+	assert.Equal(t, path, info.Source)
+	// 覆盖的候选在前，内置候选兜底且不重复
+	assert.Equal(t, []string{
+		"div.comment-box p.content-input",
+		"div.input-box div.content-edit p.content-input",
+	}, SelectorCommentInput.css())
+	assert.Equal(t, builtinSelectors[SelectorCommentSubmit], SelectorCommentSubmit.css())
+
+	// 无效的文件不影响当前生效的选择器
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"selectors": {"comment.inptu": ["div.x"]}}`), 0600))
+	err := LoadSelectors(bad)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "comment.inptu")
+	assert.Equal(t, "2026-10-19-hotfix", CurrentSelectors().Version)
+
+	require.NoError(t, LoadSelectors(""))
+	assert.Equal(t, BuiltinSelectorsVersion, CurrentSelectors().Version)
+	assert.Equal(t, builtinSelectors[SelectorCommentInput], SelectorCommentInput.css())
+}
+
+func TestLoadSelectorTexts(t *testing.T) {
+	t.Cleanup(func() { _ = LoadSelectors("") })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "selectors.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"texts": {"publish.success": "^\\s*已发布\\s*$"}}`), 0600))
+
+	require.NoError(t, LoadSelectors(path))
+	// 覆盖的正则在前，内置正则兜底
+	assert.Equal(t, `(?:^\s*已发布\s*$)|(?:^\s*发布成功\s*$)`, SelectorPublishSuccess.text())
+	assert.Equal(t, builtinSelectorTexts[SelectorPublishCoverEntry], SelectorPublishCoverEntry.text())
+	assert.Equal(t, builtinSelectors[SelectorPublishSuccess], SelectorPublishSuccess.css())
+
+	// 只有按文字匹配的元素可以覆盖文字正则
+	bad := filepath.Join(dir, "bad.json")
+	require.NoError(t, os.WriteFile(bad, []byte(`{"texts": {"comment.input": "评论"}}`), 0600))
+	err := LoadSelectors(bad)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "comment.input")
+
+	require.NoError(t, LoadSelectors(""))
+	assert.Equal(t, builtinSelectorTexts[SelectorPublishSuccess], SelectorPublishSuccess.text())
+}
+
+func TestSelectorTemplate(t *testing.T) {
+	assert.Equal(t, []string{"div.filter-panel div.filters:nth-child(2) div.tags:nth-child(3)"},
+		SelectorSearchFilterTag.css(2, 3))
+}
@@ -103,15 +103,168 @@ type FeedDetail struct {
 	User         User              `json:"user"`
 	InteractInfo InteractInfo      `json:"interactInfo"`
 	ImageList    []DetailImageInfo `json:"imageList"`
+	TagList      []NoteTag         `json:"tagList,omitempty"`
+	Video        *DetailVideo      `json:"video,omitempty"` // 视频笔记的视频信息，图文笔记为空
+}
+
+// DetailVideo 表示详情页的视频信息
+type DetailVideo struct {
+	Capa  VideoCapability `json:"capa"`
+	Media VideoMedia      `json:"media"`
+	Cover string          `json:"cover,omitempty"` // 视频封面，取自 imageList 首图
+}
+
+// VideoMedia 表示视频的媒体信息
+type VideoMedia struct {
+	Stream VideoStreams `json:"stream"`
+}
+
+// VideoStreams 表示按编码分组的视频流
+type VideoStreams struct {
+	H264 []VideoStream `json:"h264"`
+	H265 []VideoStream `json:"h265"`
+	H266 []VideoStream `json:"h266"`
+	AV1  []VideoStream `json:"av1"`
+}
+
+// VideoStream 表示单个视频流
+type VideoStream struct {
+	MasterURL   string   `json:"masterUrl"`
+	BackupURLs  []string `json:"backupUrls"`
+	VideoCodec  string   `json:"videoCodec"`
+	Width       int      `json:"width"`
+	Height      int      `json:"height"`
+	Duration    int      `json:"duration"` // 视频时长，单位毫秒
+	Size        int64    `json:"size"`     // 文件大小，单位字节
+	AvgBitrate  int      `json:"avgBitrate"`
+	Fps         int      `json:"fps"`
+	Format      string   `json:"format"`
+	QualityType string   `json:"qualityType"`
+}
+
+// All 返回所有编码中有地址的视频流
+func (s VideoStreams) All() []VideoStream {
+	var streams []VideoStream
+	for _, group := range [][]VideoStream{s.H264, s.H265, s.H266, s.AV1} {
+		for _, stream := range group {
+			if stream.MasterURL != "" {
+				streams = append(streams, stream)
+			}
+		}
+	}
+	return streams
+}
+
+// Streams 返回所有编码的视频流
+func (v *DetailVideo) Streams() []VideoStream {
+	if v == nil {
+		return nil
+	}
+	return v.Media.Stream.All()
+}
+
+// DurationSeconds 返回视频时长，单位秒
+func (v *DetailVideo) DurationSeconds() int {
+	if v == nil {
+		return 0
+	}
+	if v.Capa.Duration > 0 {
+		return v.Capa.Duration
+	}
+	for _, stream := range v.Streams() {
+		if stream.Duration > 0 {
+			return stream.Duration / 1000
+		}
+	}
+	return 0
+}
+
+// NoteTag 表示笔记的话题标签
+type NoteTag struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
 }
 
 // DetailImageInfo 表示详情页的图片信息
 type DetailImageInfo struct {
-	Width      int    `json:"width"`
-	Height     int    `json:"height"`
-	URLDefault string `json:"urlDefault"`
-	URLPre     string `json:"urlPre"`
-	LivePhoto  bool   `json:"livePhoto,omitempty"`
+	Width       int           `json:"width"`
+	Height      int           `json:"height"`
+	URLDefault  string        `json:"urlDefault"`
+	URLPre      string        `json:"urlPre"`
+	LivePhoto   bool          `json:"livePhoto,omitempty"`
+	TraceID     string        `json:"traceId,omitempty"`
+	InfoList    []ImageInfo   `json:"infoList,omitempty"`    // 各场景的图片地址，如 WB_DFT、WB_PRV
+	Stream      *VideoStreams `json:"stream,omitempty"`      // 实况图的视频流
+	OriginalURL string        `json:"originalUrl,omitempty"` // 无水印原图地址，解析后补全
+}
+
+// ImageVariant 图片的质量档位
+type ImageVariant string
+
+const (
+	ImageOriginal ImageVariant = "original" // 无水印原图，由 token 拼接的非公开地址，可能被拒绝
+	ImageDefault  ImageVariant = "default"  // 页面展示的大图：WB_DFT 或 urlDefault
+	ImagePreview  ImageVariant = "preview"  // 预览小图：WB_PRV 或 urlPre
+)
+
+// AllImageVariants 按质量从高到低排列的全部档位
+var AllImageVariants = []ImageVariant{ImageOriginal, ImageDefault, ImagePreview}
+
+// BestURL 返回质量最高的图片地址：无水印原图 > WB_DFT > urlDefault > urlPre
+func (img DetailImageInfo) BestURL() string {
+	if urls := img.URLs(AllImageVariants...); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// URLs 按 variants 的顺序返回图片地址，跳过空地址和重复地址，
+// 前面的地址下载失败时可以依次使用后面的地址
+func (img DetailImageInfo) URLs(variants ...ImageVariant) []string {
+	var urls []string
+	add := func(u string) {
+		if u == "" {
+			return
+		}
+		for _, existing := range urls {
+			if existing == u {
+				return
+			}
+		}
+		urls = append(urls, u)
+	}
+
+	for _, variant := range variants {
+		switch variant {
+		case ImageOriginal:
+			add(img.OriginalURL)
+		case ImageDefault:
+			add(img.sceneURL("WB_DFT"))
+			add(img.URLDefault)
+		case ImagePreview:
+			add(img.sceneURL("WB_PRV"))
+			add(img.URLPre)
+		}
+	}
+	return urls
+}
+
+func (img DetailImageInfo) sceneURL(scene string) string {
+	for _, info := range img.InfoList {
+		if info.ImageScene == scene {
+			return info.URL
+		}
+	}
+	return ""
+}
+
+// LiveStreams 返回实况图的视频流，非实况图返回空
+func (img DetailImageInfo) LiveStreams() []VideoStream {
+	if !img.LivePhoto || img.Stream == nil {
+		return nil
+	}
+	return img.Stream.All()
 }
 
 // CommentList 表示评论列表
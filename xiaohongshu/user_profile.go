@@ -22,18 +22,23 @@ func NewUserProfileAction(page *rod.Page) *UserProfileAction {
 func (u *UserProfileAction) UserProfile(ctx context.Context, userID, xsecToken string) (*UserProfileResponse, error) {
 	page := u.page.Context(ctx)
 
+	t := newTracker("get user profile")
+
 	searchURL := makeUserProfileURL(userID, xsecToken)
-	page.MustNavigate(searchURL)
-	page.MustWaitStable()
+	if err := t.navigate(page, "open user profile page", searchURL, waitStable); err != nil {
+		return nil, err
+	}
 
-	return u.extractUserProfileData(page)
+	return u.extractUserProfileData(page, t)
 }
 
 // extractUserProfileData 从页面中提取用户资料数据的通用方法
-func (u *UserProfileAction) extractUserProfileData(page *rod.Page) (*UserProfileResponse, error) {
-	page.MustWait(`() => window.__INITIAL_STATE__ !== undefined`)
+func (u *UserProfileAction) extractUserProfileData(page *rod.Page, t *tracker) (*UserProfileResponse, error) {
+	if err := t.wait(page, "wait for initial state", `() => window.__INITIAL_STATE__ !== undefined`); err != nil {
+		return nil, err
+	}
 
-	userDataResult := page.MustEval(`() => {
+	userDataResult, err := t.eval(page, "read user info", `() => {
 		if (window.__INITIAL_STATE__ &&
 		    window.__INITIAL_STATE__.user &&
 		    window.__INITIAL_STATE__.user.userPageData) {
@@ -44,14 +49,17 @@ func (u *UserProfileAction) extractUserProfileData(page *rod.Page) (*UserProfile
 			}
 		}
 		return "";
-	}`).String()
+	}`)
+	if err != nil {
+		return nil, err
+	}
 
 	if userDataResult == "" {
-		return nil, fmt.Errorf("user.userPageData.value not found in __INITIAL_STATE__")
+		return nil, t.fail(ErrPageData, "read user info", "", fmt.Errorf("user.userPageData.value not found in __INITIAL_STATE__"))
 	}
 
 	// 2. 获取用户帖子：window.__INITIAL_STATE__.user.notes.value
-	notesResult := page.MustEval(`() => {
+	notesResult, err := t.eval(page, "read user notes", `() => {
 		if (window.__INITIAL_STATE__ &&
 		    window.__INITIAL_STATE__.user &&
 		    window.__INITIAL_STATE__.user.notes) {
@@ -63,10 +71,13 @@ func (u *UserProfileAction) extractUserProfileData(page *rod.Page) (*UserProfile
 			}
 		}
 		return "";
-	}`).String()
+	}`)
+	if err != nil {
+		return nil, err
+	}
 
 	if notesResult == "" {
-		return nil, fmt.Errorf("user.notes.value not found in __INITIAL_STATE__")
+		return nil, t.fail(ErrPageData, "read user notes", "", fmt.Errorf("user.notes.value not found in __INITIAL_STATE__"))
 	}
 
 	// 解析用户信息
@@ -106,17 +117,20 @@ func makeUserProfileURL(userID, xsecToken string) string {
 
 func (u *UserProfileAction) GetMyProfileViaSidebar(ctx context.Context) (*UserProfileResponse, error) {
 	page := u.page.Context(ctx)
+	t := newTracker("get my profile")
 
 	// 创建导航动作
 	navigate := NewNavigate(page)
 
 	// 通过侧边栏导航到个人主页
-	if err := navigate.ToProfilePage(ctx); err != nil {
-		return nil, fmt.Errorf("failed to navigate to profile page via sidebar: %w", err)
+	if err := navigate.toProfilePage(page, t); err != nil {
+		return nil, err
 	}
 
 	// 等待页面加载完成并获取 __INITIAL_STATE__
-	page.MustWaitStable()
+	if err := t.do("wait for profile page", ErrNavigation, "", func() error { return waitStable(page) }); err != nil {
+		return nil, err
+	}
 
-	return u.extractUserProfileData(page)
+	return u.extractUserProfileData(page, t)
 }